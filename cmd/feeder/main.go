@@ -2,16 +2,20 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"slices"
 	"syscall"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/nats-io/nats.go/jetstream"
 	"github.com/prometheus/client_golang/prometheus"
+	goredis "github.com/redis/go-redis/v9"
 	"golang.org/x/sync/errgroup"
 
 	"github.com/lidofinance/onchain-mon/internal/app/feeder"
@@ -19,8 +23,14 @@ import (
 	"github.com/lidofinance/onchain-mon/internal/connectors/logger"
 	"github.com/lidofinance/onchain-mon/internal/connectors/metrics"
 	nc "github.com/lidofinance/onchain-mon/internal/connectors/nats"
+	"github.com/lidofinance/onchain-mon/internal/connectors/redis"
 	"github.com/lidofinance/onchain-mon/internal/env"
+	"github.com/lidofinance/onchain-mon/internal/pkg/archive"
+	"github.com/lidofinance/onchain-mon/internal/pkg/beacon"
 	"github.com/lidofinance/onchain-mon/internal/pkg/chain"
+	"github.com/lidofinance/onchain-mon/internal/pkg/codec"
+	"github.com/lidofinance/onchain-mon/internal/pkg/leader"
+	"github.com/lidofinance/onchain-mon/pkg/envelope"
 )
 
 func main() {
@@ -49,6 +59,22 @@ func run() error {
 		defer sentryClient.Flush(2 * time.Second)
 	}
 
+	chains, chainsErr := env.ReadChains(&cfg.AppConfig)
+	if chainsErr != nil {
+		return fmt.Errorf("read chains: %w", chainsErr)
+	}
+
+	for _, c := range chains {
+		if err := validateChain(&c); err != nil {
+			return err
+		}
+	}
+
+	// The instance id is what the lease and the mirror tell feeders apart by.
+	if cfg.AppConfig.LeaderElection && cfg.AppConfig.Source == "" {
+		return errors.New("SOURCE must be set and unique among feeders when LEADER_ELECTION is on")
+	}
+
 	natsClient, natsErr := nc.New(&cfg.AppConfig, log)
 	if natsErr != nil {
 		return fmt.Errorf("connect to nats: %w", natsErr)
@@ -63,7 +89,12 @@ func run() error {
 	log.Info("Nats jetStream connected")
 
 	r := chi.NewRouter()
-	metricsStore := metrics.New(prometheus.NewRegistry(), cfg.AppConfig.MetricsPrefix, cfg.AppConfig.Name, cfg.AppConfig.Env)
+
+	names := make([]string, 0, len(chains))
+	for _, c := range chains {
+		names = append(names, c.Name)
+	}
+	stores := metrics.NewChains(prometheus.NewRegistry(), cfg.AppConfig.MetricsPrefix, cfg.AppConfig.Name, cfg.AppConfig.Env, names)
 
 	transport := &http.Transport{
 		MaxIdleConns:          10,
@@ -78,13 +109,39 @@ func run() error {
 		Timeout:   10 * time.Second,
 	}
 
-	chainSrv := chain.NewChain(cfg.AppConfig.JsonRpcURL, httpClient, metricsStore)
-	app := server.New(&cfg.AppConfig, log, metricsStore, js, natsClient)
+	// The HTTP server is the process's, its metrics go with the first chain.
+	app := server.New(&cfg.AppConfig, log, stores[0], js, natsClient)
+
+	s := &supervisor{
+		cfg:        &cfg.AppConfig,
+		log:        log,
+		js:         js,
+		maxPayload: natsClient.MaxPayload(),
+		httpClient: httpClient,
+	}
+	// Runs after g.Wait, once no feeder appends to an archive any more.
+	defer s.closeArchives()
 
-	app.Metrics.BuildInfo.Inc()
+	if cfg.AppConfig.LeaderElection {
+		// Cells share nothing but Redis, so the lease, the cursor and the
+		// fan-out of blocks all live there.
+		rdb, redisErr := redis.NewRedisClient(cfg.AppConfig.RedisConfig.URL, cfg.AppConfig.RedisConfig.DB, log, 4*len(chains))
+		if redisErr != nil {
+			return fmt.Errorf("connect to redis: %w", redisErr)
+		}
+		defer rdb.Close()
 
-	feederWrk := feeder.New(log, chainSrv, js, metricsStore, cfg.AppConfig.BlockTopic)
-	feederWrk.Run(gCtx, g)
+		s.rdb = rdb
+		log.Info("Leader election is on", slog.String("id", cfg.AppConfig.Source))
+	}
+
+	for i := range chains {
+		stores[i].BuildInfo.Inc()
+
+		if err := s.start(ctx, gCtx, g, &chains[i], stores[i]); err != nil {
+			return fmt.Errorf("start chain %s: %w", chains[i].Name, err)
+		}
+	}
 
 	app.RegisterWorkerRoutes(r)
 	app.RunHTTPServer(gCtx, g, cfg.AppConfig.Port, r)
@@ -99,3 +156,268 @@ func run() error {
 
 	return nil
 }
+
+// validateChain checks the settings only the feeder knows the vocabulary of.
+func validateChain(c *env.ChainConfig) error {
+	switch c.Traces {
+	case "", chain.TraceAPIDebug, chain.TraceAPIParity:
+	default:
+		return fmt.Errorf("chain '%s': traces must be %s, %s or empty, got %q", c.Name, chain.TraceAPIDebug, chain.TraceAPIParity, c.Traces)
+	}
+
+	for _, tag := range c.Streams {
+		if !feeder.IsFinalityTag(tag) {
+			return fmt.Errorf("chain '%s': unknown block stream %q: want %s or %s", c.Name, tag, chain.TagSafe, chain.TagFinalized)
+		}
+	}
+
+	for _, partition := range c.LogSubjects {
+		if !feeder.IsLogPartition(partition) {
+			return fmt.Errorf("chain '%s': unknown log subject %q: want %s or %s", c.Name, partition, feeder.LogsByAddress, feeder.LogsByTopic0)
+		}
+	}
+
+	for _, encoding := range c.Encodings {
+		if !envelope.IsEncoding(encoding) {
+			return fmt.Errorf("chain '%s': unknown encoding %q: want %s or %s", c.Name, encoding, envelope.JSON, envelope.MsgPack)
+		}
+	}
+
+	// The archive keeps the JSON blocks replay serves, and nothing else.
+	if c.Archive != "" && len(c.Encodings) != 0 && !slices.Contains(c.Encodings, envelope.JSON) {
+		return fmt.Errorf("chain '%s': archive needs the %s encoding, got %v", c.Name, envelope.JSON, c.Encodings)
+	}
+
+	switch {
+	case c.Mempool == "":
+	case !feeder.IsMempoolSource(c.Mempool):
+		return fmt.Errorf("chain '%s': mempool must be %s, %s or empty, got %q", c.Name, feeder.MempoolSubscribe, feeder.MempoolTxPool, c.Mempool)
+	case c.Mempool == feeder.MempoolSubscribe && c.WS == "":
+		return fmt.Errorf("chain '%s': mempool %s needs a ws endpoint", c.Name, feeder.MempoolSubscribe)
+	case len(c.MempoolWatch) == 0:
+		// The whole mempool of mainnet is thousands of transactions a second.
+		return fmt.Errorf("chain '%s': mempool needs mempool_watch addresses", c.Name)
+	}
+
+	return nil
+}
+
+// newCompressor loads the zstd dictionary at path, or compresses without one
+// when path is empty.
+func newCompressor(path string) (*codec.Compressor, error) {
+	var dict []byte
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read zstd dictionary: %w", err)
+		}
+
+		dict = data
+	}
+
+	return codec.NewCompressor(dict)
+}
+
+// newStateWatchers reads the watchers file at path and encodes every call, so
+// a watcher the contract cannot be asked about stops the start.
+func newStateWatchers(path string) ([]*feeder.StateWatcher, error) {
+	configs, err := env.ReadStateWatchers(path)
+	if err != nil {
+		return nil, fmt.Errorf("read state watchers: %w", err)
+	}
+
+	watchers := make([]*feeder.StateWatcher, 0, len(configs))
+	for _, c := range configs {
+		w, watcherErr := feeder.NewStateWatcher(c.Name, c.Address, c.Function, c.Args, c.Returns, c.Interval)
+		if watcherErr != nil {
+			return nil, watcherErr
+		}
+
+		watchers = append(watchers, w)
+	}
+
+	return watchers, nil
+}
+
+// newStorageWatchers reads the storage slots file at path, so a slot that is
+// not a word or an EIP-1967 name stops the start.
+func newStorageWatchers(path string) ([]*feeder.StorageWatcher, error) {
+	configs, err := env.ReadStorageSlots(path)
+	if err != nil {
+		return nil, fmt.Errorf("read storage slots: %w", err)
+	}
+
+	slots := make([]*feeder.StorageWatcher, 0, len(configs))
+	for _, c := range configs {
+		s, slotErr := feeder.NewStorageWatcher(c.Name, c.Address, c.Slot)
+		if slotErr != nil {
+			return nil, slotErr
+		}
+
+		slots = append(slots, s)
+	}
+
+	return slots, nil
+}
+
+// supervisor runs one feeder per chain in the process's errgroup: a chain
+// that fails stops the process, the way a single feeder always did.
+type supervisor struct {
+	cfg        *env.AppConfig
+	log        *slog.Logger
+	js         jetstream.JetStream
+	maxPayload int64
+	rdb        *goredis.Client
+	httpClient *http.Client
+	archives   []*archive.Writer
+}
+
+// closeArchives closes the open segment of every chain's archive.
+func (s *supervisor) closeArchives() {
+	for _, w := range s.archives {
+		if err := w.Close(); err != nil {
+			s.log.Error(fmt.Sprintf("Could not close the block archive: %v", err))
+		}
+	}
+}
+
+func (s *supervisor) start(ctx, gCtx context.Context, g *errgroup.Group, c *env.ChainConfig, metricsStore *metrics.Store) error {
+	log := s.log.With(slog.String("chain", c.Name))
+
+	chainSrv := chain.NewChain(c.RPC, s.httpClient, metricsStore)
+
+	var heads feeder.HeadSource
+	if c.WS != "" {
+		heads = chain.NewHeadSubscriber(c.WS, log, metricsStore)
+		log.Info("Following newHeads over WebSocket, polling as fallback")
+	}
+
+	var (
+		cursor  feeder.CursorStore
+		elector feeder.Leadership
+		mirror  feeder.Mirror
+	)
+
+	switch {
+	case s.rdb != nil:
+	case s.cfg.CursorBucket == "":
+		log.Info("Keeping no cursor, a restart starts at latest")
+	default:
+		kvCursor, cursorErr := feeder.NewKVCursor(ctx, s.js, s.cfg.CursorBucket, c.Topic)
+		if cursorErr != nil {
+			return fmt.Errorf("open feeder cursor: %w", cursorErr)
+		}
+
+		cursor = kvCursor
+	}
+
+	// Everything in Redis is keyed by the topic, so every chain elects its
+	// own leader and keeps its own cursor and mirror.
+	if s.rdb != nil {
+		e := leader.New(s.rdb, "feeder:leader:"+c.Topic, s.cfg.Source, s.cfg.LeaderLease, log, metricsStore)
+		e.Run(gCtx, g)
+
+		elector = e
+		cursor = feeder.NewRedisCursor(s.rdb, "feeder:cursor:"+c.Topic)
+		mirror = feeder.NewRedisMirror(s.rdb, "feeder:mirror:"+c.Topic, s.cfg.Source)
+	}
+
+	compressor, err := newCompressor(c.ZstdDictionary)
+	if err != nil {
+		return fmt.Errorf("chain '%s': %w", c.Name, err)
+	}
+
+	if dictID := compressor.DictID(); dictID != 0 {
+		log.Info("Compressing with a trained zstd dictionary", slog.String("path", c.ZstdDictionary), slog.Uint64("dictID", uint64(dictID)))
+	}
+
+	var blockArchive feeder.BlockArchive
+	if c.Archive != "" {
+		w, archiveErr := archive.NewWriter(c.Archive, c.ArchiveSegmentBlocks, c.ArchiveMaxSegments)
+		if archiveErr != nil {
+			return fmt.Errorf("chain '%s': %w", c.Name, archiveErr)
+		}
+
+		s.archives = append(s.archives, w)
+		blockArchive = w
+		log.Info("Archiving published blocks", slog.String("dir", c.Archive), slog.Int64("segmentBlocks", c.ArchiveSegmentBlocks), slog.Int("maxSegments", c.ArchiveMaxSegments))
+	}
+
+	feederWrk := feeder.New(feeder.Config{
+		Log:              log,
+		ChainSrv:         chainSrv,
+		Heads:            heads,
+		Cursor:           cursor,
+		MaxBackfill:      c.MaxBackfill,
+		Leader:           elector,
+		Mirror:           mirror,
+		Consensus:        c.Consensus,
+		VerifyReceipts:   *c.VerifyReceipts,
+		FullTransactions: c.FullTransactions,
+		TraceAPI:         c.Traces,
+		LogSubjects:      c.LogSubjects,
+		Encodings:        c.Encodings,
+		Compressor:       compressor,
+		Archive:          blockArchive,
+		JS:               s.js,
+		MaxPayload:       s.maxPayload,
+		Metrics:          metricsStore,
+		Topic:            c.Topic,
+		BlockTime:        c.BlockTime,
+	})
+	if c.StateWatchers != "" {
+		watchers, watchersErr := newStateWatchers(c.StateWatchers)
+		if watchersErr != nil {
+			return fmt.Errorf("chain '%s': %w", c.Name, watchersErr)
+		}
+
+		// Blocks are handed over as they are published, so this goes first.
+		subject := feeder.StateSubject(c.Name)
+		feederWrk.RunState(gCtx, g, chainSrv, subject, watchers)
+		log.Info("Watching contract state", slog.String("subject", subject), slog.Int("watchers", len(watchers)))
+	}
+
+	if c.StorageSlots != "" {
+		slots, slotsErr := newStorageWatchers(c.StorageSlots)
+		if slotsErr != nil {
+			return fmt.Errorf("chain '%s': %w", c.Name, slotsErr)
+		}
+
+		subject := feeder.StorageSubject(c.Name)
+		feederWrk.RunStorage(gCtx, g, chainSrv, subject, slots)
+		log.Info("Watching storage slots", slog.String("subject", subject), slog.Int("slots", len(slots)))
+	}
+
+	feederWrk.Run(gCtx, g)
+	feederWrk.RunMirror(gCtx, g)
+
+	for _, tag := range c.Streams {
+		if err := feederWrk.RunFinalityStream(gCtx, g, tag); err != nil {
+			return fmt.Errorf("start %s stream: %w", tag, err)
+		}
+	}
+
+	if c.Beacon != "" {
+		// The event stream is one request that never ends: same transport,
+		// no timeout.
+		events := beacon.NewEventSubscriber(c.Beacon, &http.Client{Transport: s.httpClient.Transport}, log, metricsStore)
+
+		feederWrk.RunBeacon(gCtx, g, beacon.NewClient(c.Beacon, s.httpClient, metricsStore), events)
+		log.Info("Following the beacon chain", slog.String("subject", c.Topic+feeder.BeaconSubjectSuffix))
+	}
+
+	if c.Mempool != "" {
+		var src feeder.PendingSource = feeder.NewTxPoolPoller(chainSrv, log)
+		if c.Mempool == feeder.MempoolSubscribe {
+			src = chain.NewPendingSubscriber(c.WS, log, metricsStore)
+		}
+
+		subject := feeder.MempoolSubject(c.Name)
+		feederWrk.RunMempool(gCtx, g, src, subject, c.MempoolWatch)
+		log.Info("Following the mempool", slog.String("source", c.Mempool), slog.String("subject", subject), slog.Int("watched", len(c.MempoolWatch)))
+	}
+
+	log.Info("Started chain", slog.String("topic", c.Topic), slog.Duration("blockTime", c.BlockTime))
+
+	return nil
+}
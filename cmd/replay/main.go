@@ -0,0 +1,222 @@
+// Command replay publishes a historical block range to an isolated subject, so
+// new detectors can be run against a past incident without touching the topics
+// live bots follow.
+//
+//	replay -id euler -from 16817996 -to 16818065 -rate 2
+//	replay -id euler -from 16817996 -to 16818065 -archive /data/archive/mainnet
+//
+// With -archive the blocks are read from a feeder's BLOCK_ARCHIVE_DIR and
+// published byte for byte as bots saw them, without asking the RPC.
+//
+// Blocks go to replay.<id>.blocks (logs and traces under it, as on a live
+// topic) in a stream of their own, REPLAY_<id>, which is created if missing.
+// Point the bot at replay.<id>.blocks instead of BLOCK_TOPIC.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/lidofinance/onchain-mon/internal/app/feeder"
+	"github.com/lidofinance/onchain-mon/internal/connectors/metrics"
+	nc "github.com/lidofinance/onchain-mon/internal/connectors/nats"
+	"github.com/lidofinance/onchain-mon/internal/pkg/chain"
+	"github.com/lidofinance/onchain-mon/internal/pkg/codec"
+	"github.com/lidofinance/onchain-mon/pkg/envelope"
+)
+
+// replayID ends up in a stream name, which allows no dots or spaces.
+var replayID = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// AckTimeout is how long the last publishes get to be acknowledged before the
+// command exits.
+const AckTimeout = 30 * time.Second
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	id := flag.String("id", "", "replay id; blocks go to replay.<id>.blocks")
+	from := flag.Int64("from", -1, "first block to replay")
+	to := flag.Int64("to", -1, "last block to replay")
+	rate := flag.Float64("rate", 5, "most blocks published per second, 0 for no limit")
+	rpc := flag.String("rpc", envOr("JSON_RPC_URL", ""), "JSON-RPC endpoints, comma-separated")
+	natsURL := flag.String("nats", envOr("NATS_DEFAULT_URL", nats.DefaultURL), "NATS server to publish to")
+	maxAge := flag.Duration("max-age", 24*time.Hour, "how long the replay stream keeps its messages")
+	verifyReceipts := flag.Bool("verify-receipts", true, "check receipts against their blocks, like BLOCK_VERIFY_RECEIPTS")
+	fullTransactions := flag.Bool("full-transactions", false, "add transactions with their calldata, like BLOCK_FULL_TRANSACTIONS")
+	traces := flag.String("traces", "", "publish call traces, like BLOCK_TRACES: debug or parity")
+	logSubjects := flag.String("log-subjects", "", "log batches, like BLOCK_LOG_SUBJECTS: address, topic0")
+	encodings := flag.String("encodings", envelope.JSON, "payload encodings, like BLOCK_ENCODINGS")
+	dictPath := flag.String("dict", "", "zstd dictionary, like ZSTD_DICTIONARY")
+	archiveDir := flag.String("archive", "", "replay from this block archive, like BLOCK_ARCHIVE_DIR, instead of the RPC")
+	flag.Parse()
+
+	if !replayID.MatchString(*id) {
+		return fmt.Errorf("-id must be letters, digits, - or _, got %q", *id)
+	}
+
+	if *from < 0 || *to < *from {
+		return fmt.Errorf("-from and -to must be a block range, got %d..%d", *from, *to)
+	}
+
+	if *rpc == "" && *archiveDir == "" {
+		return errors.New("-rpc, JSON_RPC_URL or -archive must be set")
+	}
+
+	switch *traces {
+	case "", chain.TraceAPIDebug, chain.TraceAPIParity:
+	default:
+		return fmt.Errorf("-traces must be %s, %s or empty, got %q", chain.TraceAPIDebug, chain.TraceAPIParity, *traces)
+	}
+
+	for _, partition := range list(*logSubjects) {
+		if !feeder.IsLogPartition(partition) {
+			return fmt.Errorf("unknown log subject %q: want %s or %s", partition, feeder.LogsByAddress, feeder.LogsByTopic0)
+		}
+	}
+
+	for _, encoding := range list(*encodings) {
+		if !envelope.IsEncoding(encoding) {
+			return fmt.Errorf("unknown encoding %q: want %s or %s", encoding, envelope.JSON, envelope.MsgPack)
+		}
+	}
+
+	compressor, err := newCompressor(*dictPath)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	log := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	natsClient, err := nats.Connect(*natsURL)
+	if err != nil {
+		return fmt.Errorf("connect to nats at %s: %w", *natsURL, err)
+	}
+	defer natsClient.Close()
+
+	js, err := jetstream.New(natsClient)
+	if err != nil {
+		return fmt.Errorf("connect to jetstream: %w", err)
+	}
+
+	subject := feeder.ReplaySubject(*id)
+	streamName := "REPLAY_" + *id
+
+	if _, err := js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:       streamName,
+		Subjects:   []string{subject, subject + ".>"},
+		MaxAge:     *maxAge,
+		MaxMsgSize: nc.MaxMsgSize,
+		Discard:    jetstream.DiscardOld,
+	}); err != nil {
+		return fmt.Errorf("create %s stream: %w", streamName, err)
+	}
+
+	// The metrics go nowhere, but every component of the feeder reports to them.
+	metricsStore := metrics.New(prometheus.NewRegistry(), "replay", "replay", "replay")
+	chainSrv := chain.NewChain(list(*rpc), &http.Client{Timeout: 30 * time.Second}, metricsStore)
+
+	w := feeder.New(feeder.Config{
+		Log:              log,
+		ChainSrv:         chainSrv,
+		VerifyReceipts:   *verifyReceipts,
+		FullTransactions: *fullTransactions,
+		TraceAPI:         *traces,
+		LogSubjects:      list(*logSubjects),
+		Encodings:        list(*encodings),
+		Compressor:       compressor,
+		JS:               js,
+		MaxPayload:       natsClient.MaxPayload(),
+		Metrics:          metricsStore,
+		Topic:            subject,
+	})
+
+	log.Info("Replaying",
+		slog.Int64("from", *from),
+		slog.Int64("to", *to),
+		slog.String("subject", subject),
+		slog.String("stream", streamName),
+		slog.Float64("rate", *rate),
+	)
+
+	var (
+		last      int64
+		replayErr error
+	)
+	if *archiveDir != "" {
+		last, replayErr = w.ReplayArchive(ctx, *archiveDir, *from, *to, *rate)
+	} else {
+		last, replayErr = w.Replay(ctx, *from, *to, *rate)
+	}
+
+	select {
+	case <-js.PublishAsyncComplete():
+	case <-time.After(AckTimeout):
+		log.Warn("Not every publish was acknowledged", slog.Int("pending", js.PublishAsyncPending()))
+	}
+
+	if replayErr != nil {
+		return fmt.Errorf("replayed up to %d, resume with -from %d: %w", last, last+1, replayErr)
+	}
+
+	log.Info(fmt.Sprintf("Replayed blocks %d..%d to %s", *from, last, subject))
+
+	return nil
+}
+
+// newCompressor loads the zstd dictionary at path, or compresses without one
+// when path is empty.
+func newCompressor(path string) (*codec.Compressor, error) {
+	var dict []byte
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read zstd dictionary: %w", err)
+		}
+
+		dict = data
+	}
+
+	return codec.NewCompressor(dict)
+}
+
+func list(s string) []string {
+	var out []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+
+	return out
+}
+
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+
+	return fallback
+}
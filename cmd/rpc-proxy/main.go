@@ -0,0 +1,115 @@
+// Command rpc-proxy answers bots' eth_call, eth_getBalance and friends over
+// NATS request-reply on RPC_PROXY_SUBJECT, so identical reads for the same
+// block reach JSON_RPC_URL once, whichever bots ask.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/lidofinance/onchain-mon/internal/app/rpcproxy"
+	"github.com/lidofinance/onchain-mon/internal/app/server"
+	"github.com/lidofinance/onchain-mon/internal/connectors/logger"
+	"github.com/lidofinance/onchain-mon/internal/connectors/metrics"
+	nc "github.com/lidofinance/onchain-mon/internal/connectors/nats"
+	"github.com/lidofinance/onchain-mon/internal/env"
+	"github.com/lidofinance/onchain-mon/internal/pkg/chain"
+)
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+	g, gCtx := errgroup.WithContext(ctx)
+
+	cfg, envErr := env.Read("")
+	if envErr != nil {
+		return fmt.Errorf("read env: %w", envErr)
+	}
+
+	log, sentryClient, logErr := logger.New(&cfg.AppConfig)
+	if logErr != nil {
+		return fmt.Errorf("create logger: %w", logErr)
+	}
+	if sentryClient != nil {
+		defer sentryClient.Flush(2 * time.Second)
+	}
+
+	if len(cfg.AppConfig.JsonRpcURLs) == 0 {
+		return errors.New("JSON_RPC_URL must be set")
+	}
+
+	natsClient, natsErr := nc.New(&cfg.AppConfig, log)
+	if natsErr != nil {
+		return fmt.Errorf("connect to nats: %w", natsErr)
+	}
+	defer natsClient.Close()
+	log.Info("Nats connected")
+
+	js, jetStreamErr := jetstream.New(natsClient)
+	if jetStreamErr != nil {
+		return fmt.Errorf("connect to jetstream: %w", jetStreamErr)
+	}
+
+	metricsStore := metrics.New(prometheus.NewRegistry(), cfg.AppConfig.MetricsPrefix, cfg.AppConfig.Name, cfg.AppConfig.Env)
+	metricsStore.BuildInfo.Inc()
+
+	// Bots wait on the answer, so up to concurrency calls go out at once.
+	transport := &http.Transport{
+		MaxIdleConns:          cfg.AppConfig.RpcProxyConcurrency,
+		MaxIdleConnsPerHost:   cfg.AppConfig.RpcProxyConcurrency,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+
+	httpClient := &http.Client{
+		Transport: transport,
+		Timeout:   10 * time.Second,
+	}
+
+	chainSrv := chain.NewChain(cfg.AppConfig.JsonRpcURLs, httpClient, metricsStore)
+
+	proxy := rpcproxy.New(log, chainSrv, metricsStore, cfg.AppConfig.RpcProxyCacheSize, cfg.AppConfig.RpcProxyCacheTTL)
+	if err := proxy.Run(gCtx, g, natsClient, cfg.AppConfig.RpcProxySubject, cfg.AppConfig.RpcProxyConcurrency); err != nil {
+		return err
+	}
+
+	r := chi.NewRouter()
+
+	app := server.New(&cfg.AppConfig, log, metricsStore, js, natsClient)
+	app.RegisterWorkerRoutes(r)
+	app.RunHTTPServer(gCtx, g, cfg.AppConfig.Port, r)
+
+	log.Info("Started rpc proxy",
+		slog.String("subject", cfg.AppConfig.RpcProxySubject),
+		slog.Int("cacheSize", cfg.AppConfig.RpcProxyCacheSize),
+		slog.Duration("cacheTTL", cfg.AppConfig.RpcProxyCacheTTL),
+	)
+
+	if err := g.Wait(); err != nil {
+		return fmt.Errorf("%s stopped: %w", cfg.AppConfig.Name, err)
+	}
+
+	log.Info("Main done rpc proxy")
+
+	return nil
+}
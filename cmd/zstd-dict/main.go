@@ -0,0 +1,182 @@
+// Command zstd-dict trains a zstd dictionary for the feeder's payloads from a
+// sample of recorded blocks: files given as arguments, or messages read live
+// from a NATS subject.
+//
+//	zstd-dict -subject blocks.mainnet.l1 -samples 300 -out mainnet.zdict
+//	zstd-dict -out mainnet.zdict recorded/*.json
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/nats-io/nats.go"
+
+	"github.com/lidofinance/onchain-mon/internal/pkg/codec"
+	"github.com/lidofinance/onchain-mon/pkg/envelope"
+)
+
+// zstdMagic starts every zstd frame; recorded files without it are taken as
+// uncompressed payloads.
+var zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	natsURL := flag.String("nats", envOr("NATS_DEFAULT_URL", nats.DefaultURL), "NATS server to read samples from")
+	subject := flag.String("subject", envOr("BLOCK_TOPIC", "blocks.mainnet.l1"), "subject to read samples from")
+	samples := flag.Int("samples", 200, "how many messages to read from the subject")
+	current := flag.String("dict", "", "dictionary the subject is compressed with now, if any")
+	size := flag.Int("size", codec.DefaultDictionarySize, "maximum dictionary size in bytes")
+	out := flag.String("out", "zstd.dict", "where to write the dictionary")
+	flag.Parse()
+
+	var decoderOpts []zstd.DOption
+	if *current != "" {
+		dict, err := os.ReadFile(*current)
+		if err != nil {
+			return fmt.Errorf("read current dictionary: %w", err)
+		}
+
+		decoderOpts = append(decoderOpts, zstd.WithDecoderDicts(dict))
+	}
+
+	dec, err := zstd.NewReader(nil, decoderOpts...)
+	if err != nil {
+		return fmt.Errorf("create zstd decoder: %w", err)
+	}
+	defer dec.Close()
+
+	var payloads [][]byte
+	if flag.NArg() > 0 {
+		payloads, err = readFiles(dec, flag.Args())
+	} else {
+		payloads, err = readSubject(dec, *natsURL, *subject, *samples)
+	}
+	if err != nil {
+		return err
+	}
+
+	dict, err := codec.TrainDictionary(payloads, *size)
+	if err != nil {
+		return fmt.Errorf("train dictionary: %w", err)
+	}
+
+	compressor, err := codec.NewCompressor(dict)
+	if err != nil {
+		return fmt.Errorf("load trained dictionary: %w", err)
+	}
+
+	// Measured on the training set, so it is the best case; the feeder's
+	// block_payload_bytes{stage="compressed_no_dict"} tells the real gain.
+	var raw, plain, trained int
+	for _, p := range payloads {
+		raw += len(p)
+		plain += compressor.SizeWithoutDictionary(p)
+		trained += len(compressor.Compress(p))
+	}
+
+	if err := os.WriteFile(*out, dict, 0o644); err != nil {
+		return fmt.Errorf("write dictionary: %w", err)
+	}
+
+	fmt.Printf("Wrote dictionary %d (%d bytes) to %s from %d samples\n", compressor.DictID(), len(dict), *out, len(payloads))
+	fmt.Printf("Samples: %d bytes raw, %d compressed without the dictionary, %d with it (%.1f%%)\n",
+		raw, plain, trained, 100*float64(trained)/float64(plain))
+
+	return nil
+}
+
+func readFiles(dec *zstd.Decoder, paths []string) ([][]byte, error) {
+	payloads := make([][]byte, 0, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read sample: %w", err)
+		}
+
+		payload, err := decompress(dec, data)
+		if err != nil {
+			return nil, fmt.Errorf("sample %s: %w", path, err)
+		}
+
+		payloads = append(payloads, payload)
+	}
+
+	return payloads, nil
+}
+
+// readSubject collects n messages published to subject. A plain subscription
+// sees JetStream publishes too, so nothing is consumed from the stream.
+// Chunks of oversized payloads are left out: none is a payload on its own.
+func readSubject(dec *zstd.Decoder, url, subject string, n int) ([][]byte, error) {
+	nc, err := nats.Connect(url)
+	if err != nil {
+		return nil, fmt.Errorf("connect to nats at %s: %w", url, err)
+	}
+	defer nc.Close()
+
+	sub, err := nc.SubscribeSync(subject)
+	if err != nil {
+		return nil, fmt.Errorf("subscribe to %s: %w", subject, err)
+	}
+	defer func() { _ = sub.Unsubscribe() }()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	fmt.Printf("Reading %d messages from %s, interrupt to train on what is there\n", n, subject)
+
+	payloads := make([][]byte, 0, n)
+	for len(payloads) < n {
+		msg, err := sub.NextMsgWithContext(ctx)
+		if err != nil {
+			if errors.Is(err, context.Canceled) && len(payloads) > 0 {
+				break
+			}
+
+			return nil, fmt.Errorf("read %s: %w", subject, err)
+		}
+
+		if msg.Header.Get(envelope.HeaderChunkID) != "" {
+			continue
+		}
+
+		payload, err := decompress(dec, msg.Data)
+		if err != nil {
+			return nil, fmt.Errorf("message on %s: %w", subject, err)
+		}
+
+		payloads = append(payloads, payload)
+	}
+
+	return payloads, nil
+}
+
+func decompress(dec *zstd.Decoder, data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, zstdMagic) {
+		return data, nil
+	}
+
+	return dec.DecodeAll(data, nil)
+}
+
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+
+	return fallback
+}
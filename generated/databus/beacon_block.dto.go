@@ -0,0 +1,226 @@
+// Code generated by github.com/atombender/go-jsonschema, DO NOT EDIT.
+
+package databus
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Consensus-layer block of one slot with the validator operations it carries.
+type BeaconBlockDtoJson struct {
+	// AttesterSlashings corresponds to the JSON schema field "attesterSlashings".
+	AttesterSlashings []BeaconBlockDtoJsonAttesterSlashingsElem `json:"attesterSlashings" yaml:"attesterSlashings" mapstructure:"attesterSlashings"`
+
+	// Epoch corresponds to the JSON schema field "epoch".
+	Epoch int `json:"epoch" yaml:"epoch" mapstructure:"epoch"`
+
+	// ExecutionBlockHash corresponds to the JSON schema field "executionBlockHash".
+	ExecutionBlockHash string `json:"executionBlockHash" yaml:"executionBlockHash" mapstructure:"executionBlockHash"`
+
+	// Number of the execution block in this slot, 0 before the merge.
+	ExecutionBlockNumber int `json:"executionBlockNumber" yaml:"executionBlockNumber" mapstructure:"executionBlockNumber"`
+
+	// ParentRoot corresponds to the JSON schema field "parentRoot".
+	ParentRoot string `json:"parentRoot" yaml:"parentRoot" mapstructure:"parentRoot"`
+
+	// ProposerIndex corresponds to the JSON schema field "proposerIndex".
+	ProposerIndex int `json:"proposerIndex" yaml:"proposerIndex" mapstructure:"proposerIndex"`
+
+	// ProposerSlashings corresponds to the JSON schema field "proposerSlashings".
+	ProposerSlashings []BeaconBlockDtoJsonProposerSlashingsElem `json:"proposerSlashings" yaml:"proposerSlashings" mapstructure:"proposerSlashings"`
+
+	// Root corresponds to the JSON schema field "root".
+	Root string `json:"root" yaml:"root" mapstructure:"root"`
+
+	// Slot corresponds to the JSON schema field "slot".
+	Slot int `json:"slot" yaml:"slot" mapstructure:"slot"`
+
+	// StateRoot corresponds to the JSON schema field "stateRoot".
+	StateRoot string `json:"stateRoot" yaml:"stateRoot" mapstructure:"stateRoot"`
+
+	// VoluntaryExits corresponds to the JSON schema field "voluntaryExits".
+	VoluntaryExits []BeaconBlockDtoJsonVoluntaryExitsElem `json:"voluntaryExits" yaml:"voluntaryExits" mapstructure:"voluntaryExits"`
+
+	// Withdrawals corresponds to the JSON schema field "withdrawals".
+	Withdrawals []BeaconBlockDtoJsonWithdrawalsElem `json:"withdrawals" yaml:"withdrawals" mapstructure:"withdrawals"`
+}
+
+type BeaconBlockDtoJsonAttesterSlashingsElem struct {
+	// TargetEpoch corresponds to the JSON schema field "targetEpoch".
+	TargetEpoch int `json:"targetEpoch" yaml:"targetEpoch" mapstructure:"targetEpoch"`
+
+	// Validators that signed both conflicting attestations.
+	ValidatorIndices []int `json:"validatorIndices" yaml:"validatorIndices" mapstructure:"validatorIndices"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (j *BeaconBlockDtoJsonAttesterSlashingsElem) UnmarshalJSON(b []byte) error {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	if _, ok := raw["targetEpoch"]; raw != nil && !ok {
+		return fmt.Errorf("field targetEpoch in BeaconBlockDtoJsonAttesterSlashingsElem: required")
+	}
+	if _, ok := raw["validatorIndices"]; raw != nil && !ok {
+		return fmt.Errorf("field validatorIndices in BeaconBlockDtoJsonAttesterSlashingsElem: required")
+	}
+	type Plain BeaconBlockDtoJsonAttesterSlashingsElem
+	var plain Plain
+	if err := json.Unmarshal(b, &plain); err != nil {
+		return err
+	}
+	*j = BeaconBlockDtoJsonAttesterSlashingsElem(plain)
+	return nil
+}
+
+type BeaconBlockDtoJsonProposerSlashingsElem struct {
+	// ProposerIndex corresponds to the JSON schema field "proposerIndex".
+	ProposerIndex int `json:"proposerIndex" yaml:"proposerIndex" mapstructure:"proposerIndex"`
+
+	// Slot corresponds to the JSON schema field "slot".
+	Slot int `json:"slot" yaml:"slot" mapstructure:"slot"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (j *BeaconBlockDtoJsonProposerSlashingsElem) UnmarshalJSON(b []byte) error {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	if _, ok := raw["proposerIndex"]; raw != nil && !ok {
+		return fmt.Errorf("field proposerIndex in BeaconBlockDtoJsonProposerSlashingsElem: required")
+	}
+	if _, ok := raw["slot"]; raw != nil && !ok {
+		return fmt.Errorf("field slot in BeaconBlockDtoJsonProposerSlashingsElem: required")
+	}
+	type Plain BeaconBlockDtoJsonProposerSlashingsElem
+	var plain Plain
+	if err := json.Unmarshal(b, &plain); err != nil {
+		return err
+	}
+	*j = BeaconBlockDtoJsonProposerSlashingsElem(plain)
+	return nil
+}
+
+type BeaconBlockDtoJsonVoluntaryExitsElem struct {
+	// Epoch corresponds to the JSON schema field "epoch".
+	Epoch int `json:"epoch" yaml:"epoch" mapstructure:"epoch"`
+
+	// ValidatorIndex corresponds to the JSON schema field "validatorIndex".
+	ValidatorIndex int `json:"validatorIndex" yaml:"validatorIndex" mapstructure:"validatorIndex"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (j *BeaconBlockDtoJsonVoluntaryExitsElem) UnmarshalJSON(b []byte) error {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	if _, ok := raw["epoch"]; raw != nil && !ok {
+		return fmt.Errorf("field epoch in BeaconBlockDtoJsonVoluntaryExitsElem: required")
+	}
+	if _, ok := raw["validatorIndex"]; raw != nil && !ok {
+		return fmt.Errorf("field validatorIndex in BeaconBlockDtoJsonVoluntaryExitsElem: required")
+	}
+	type Plain BeaconBlockDtoJsonVoluntaryExitsElem
+	var plain Plain
+	if err := json.Unmarshal(b, &plain); err != nil {
+		return err
+	}
+	*j = BeaconBlockDtoJsonVoluntaryExitsElem(plain)
+	return nil
+}
+
+type BeaconBlockDtoJsonWithdrawalsElem struct {
+	// Address corresponds to the JSON schema field "address".
+	Address string `json:"address" yaml:"address" mapstructure:"address"`
+
+	// Amount in gwei.
+	Amount int `json:"amount" yaml:"amount" mapstructure:"amount"`
+
+	// Index corresponds to the JSON schema field "index".
+	Index int `json:"index" yaml:"index" mapstructure:"index"`
+
+	// ValidatorIndex corresponds to the JSON schema field "validatorIndex".
+	ValidatorIndex int `json:"validatorIndex" yaml:"validatorIndex" mapstructure:"validatorIndex"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (j *BeaconBlockDtoJsonWithdrawalsElem) UnmarshalJSON(b []byte) error {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	if _, ok := raw["address"]; raw != nil && !ok {
+		return fmt.Errorf("field address in BeaconBlockDtoJsonWithdrawalsElem: required")
+	}
+	if _, ok := raw["amount"]; raw != nil && !ok {
+		return fmt.Errorf("field amount in BeaconBlockDtoJsonWithdrawalsElem: required")
+	}
+	if _, ok := raw["index"]; raw != nil && !ok {
+		return fmt.Errorf("field index in BeaconBlockDtoJsonWithdrawalsElem: required")
+	}
+	if _, ok := raw["validatorIndex"]; raw != nil && !ok {
+		return fmt.Errorf("field validatorIndex in BeaconBlockDtoJsonWithdrawalsElem: required")
+	}
+	type Plain BeaconBlockDtoJsonWithdrawalsElem
+	var plain Plain
+	if err := json.Unmarshal(b, &plain); err != nil {
+		return err
+	}
+	*j = BeaconBlockDtoJsonWithdrawalsElem(plain)
+	return nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (j *BeaconBlockDtoJson) UnmarshalJSON(b []byte) error {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	if _, ok := raw["attesterSlashings"]; raw != nil && !ok {
+		return fmt.Errorf("field attesterSlashings in BeaconBlockDtoJson: required")
+	}
+	if _, ok := raw["epoch"]; raw != nil && !ok {
+		return fmt.Errorf("field epoch in BeaconBlockDtoJson: required")
+	}
+	if _, ok := raw["executionBlockHash"]; raw != nil && !ok {
+		return fmt.Errorf("field executionBlockHash in BeaconBlockDtoJson: required")
+	}
+	if _, ok := raw["executionBlockNumber"]; raw != nil && !ok {
+		return fmt.Errorf("field executionBlockNumber in BeaconBlockDtoJson: required")
+	}
+	if _, ok := raw["parentRoot"]; raw != nil && !ok {
+		return fmt.Errorf("field parentRoot in BeaconBlockDtoJson: required")
+	}
+	if _, ok := raw["proposerIndex"]; raw != nil && !ok {
+		return fmt.Errorf("field proposerIndex in BeaconBlockDtoJson: required")
+	}
+	if _, ok := raw["proposerSlashings"]; raw != nil && !ok {
+		return fmt.Errorf("field proposerSlashings in BeaconBlockDtoJson: required")
+	}
+	if _, ok := raw["root"]; raw != nil && !ok {
+		return fmt.Errorf("field root in BeaconBlockDtoJson: required")
+	}
+	if _, ok := raw["slot"]; raw != nil && !ok {
+		return fmt.Errorf("field slot in BeaconBlockDtoJson: required")
+	}
+	if _, ok := raw["stateRoot"]; raw != nil && !ok {
+		return fmt.Errorf("field stateRoot in BeaconBlockDtoJson: required")
+	}
+	if _, ok := raw["voluntaryExits"]; raw != nil && !ok {
+		return fmt.Errorf("field voluntaryExits in BeaconBlockDtoJson: required")
+	}
+	if _, ok := raw["withdrawals"]; raw != nil && !ok {
+		return fmt.Errorf("field withdrawals in BeaconBlockDtoJson: required")
+	}
+	type Plain BeaconBlockDtoJson
+	var plain Plain
+	if err := json.Unmarshal(b, &plain); err != nil {
+		return err
+	}
+	*j = BeaconBlockDtoJson(plain)
+	return nil
+}
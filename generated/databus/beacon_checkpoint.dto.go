@@ -0,0 +1,45 @@
+// Code generated by github.com/atombender/go-jsonschema, DO NOT EDIT.
+
+package databus
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Finalized checkpoint of the consensus layer: everything up to its slot is
+// irreversible.
+type BeaconCheckpointDtoJson struct {
+	// Epoch corresponds to the JSON schema field "epoch".
+	Epoch int `json:"epoch" yaml:"epoch" mapstructure:"epoch"`
+
+	// Root corresponds to the JSON schema field "root".
+	Root string `json:"root" yaml:"root" mapstructure:"root"`
+
+	// First slot of the epoch.
+	Slot int `json:"slot" yaml:"slot" mapstructure:"slot"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (j *BeaconCheckpointDtoJson) UnmarshalJSON(b []byte) error {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	if _, ok := raw["epoch"]; raw != nil && !ok {
+		return fmt.Errorf("field epoch in BeaconCheckpointDtoJson: required")
+	}
+	if _, ok := raw["root"]; raw != nil && !ok {
+		return fmt.Errorf("field root in BeaconCheckpointDtoJson: required")
+	}
+	if _, ok := raw["slot"]; raw != nil && !ok {
+		return fmt.Errorf("field slot in BeaconCheckpointDtoJson: required")
+	}
+	type Plain BeaconCheckpointDtoJson
+	var plain Plain
+	if err := json.Unmarshal(b, &plain); err != nil {
+		return err
+	}
+	*j = BeaconCheckpointDtoJson(plain)
+	return nil
+}
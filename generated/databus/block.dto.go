@@ -8,9 +8,28 @@ import (
 )
 
 type BlockDtoJson struct {
+	// Wei as a decimal string. Absent before London.
+	BaseFeePerGas *string `json:"baseFeePerGas,omitempty" yaml:"baseFeePerGas,omitempty" mapstructure:"baseFeePerGas,omitempty"`
+
+	// Absent before Cancun.
+	BlobGasUsed *int `json:"blobGasUsed,omitempty" yaml:"blobGasUsed,omitempty" mapstructure:"blobGasUsed,omitempty"`
+
+	// Absent before Cancun.
+	ExcessBlobGas *int `json:"excessBlobGas,omitempty" yaml:"excessBlobGas,omitempty" mapstructure:"excessBlobGas,omitempty"`
+
+	// GasLimit corresponds to the JSON schema field "gasLimit".
+	GasLimit *int `json:"gasLimit,omitempty" yaml:"gasLimit,omitempty" mapstructure:"gasLimit,omitempty"`
+
+	// GasUsed corresponds to the JSON schema field "gasUsed".
+	GasUsed *int `json:"gasUsed,omitempty" yaml:"gasUsed,omitempty" mapstructure:"gasUsed,omitempty"`
+
 	// Hash corresponds to the JSON schema field "hash".
 	Hash string `json:"hash" yaml:"hash" mapstructure:"hash"`
 
+	// Fee recipient of the block: the builder, or the proposer's address without
+	// MEV-Boost.
+	Miner *string `json:"miner,omitempty" yaml:"miner,omitempty" mapstructure:"miner,omitempty"`
+
 	// Number corresponds to the JSON schema field "number".
 	Number int `json:"number" yaml:"number" mapstructure:"number"`
 
@@ -20,22 +39,49 @@ type BlockDtoJson struct {
 	// Receipts corresponds to the JSON schema field "receipts".
 	Receipts []BlockDtoJsonReceiptsElem `json:"receipts" yaml:"receipts" mapstructure:"receipts"`
 
+	// Version of this schema, raised when fields are added. Absent means 1.
+	SchemaVersion *int `json:"schemaVersion,omitempty" yaml:"schemaVersion,omitempty" mapstructure:"schemaVersion,omitempty"`
+
 	// Timestamp corresponds to the JSON schema field "timestamp".
 	Timestamp int `json:"timestamp" yaml:"timestamp" mapstructure:"timestamp"`
+
+	// Full transactions in block order. Only sent when the feeder runs with
+	// BLOCK_FULL_TRANSACTIONS.
+	Transactions []BlockDtoJsonTransactionsElem `json:"transactions,omitempty" yaml:"transactions,omitempty" mapstructure:"transactions,omitempty"`
+
+	// EIP-4895 withdrawals from the beacon chain credited in this block. Absent
+	// before Shanghai.
+	Withdrawals []BlockDtoJsonWithdrawalsElem `json:"withdrawals,omitempty" yaml:"withdrawals,omitempty" mapstructure:"withdrawals,omitempty"`
 }
 
 type BlockDtoJsonReceiptsElem struct {
+	// Address of the deployed contract, contract creations only. Since schemaVersion
+	// 2.
+	ContractAddress *string `json:"contractAddress,omitempty" yaml:"contractAddress,omitempty" mapstructure:"contractAddress,omitempty"`
+
+	// Wei as a decimal string. Since schemaVersion 2.
+	EffectiveGasPrice *string `json:"effectiveGasPrice,omitempty" yaml:"effectiveGasPrice,omitempty" mapstructure:"effectiveGasPrice,omitempty"`
+
 	// From corresponds to the JSON schema field "from".
 	From string `json:"from" yaml:"from" mapstructure:"from"`
 
+	// Since schemaVersion 2.
+	GasUsed *int `json:"gasUsed,omitempty" yaml:"gasUsed,omitempty" mapstructure:"gasUsed,omitempty"`
+
 	// Logs corresponds to the JSON schema field "logs".
 	Logs []BlockDtoJsonReceiptsElemLogsElem `json:"logs" yaml:"logs" mapstructure:"logs"`
 
+	// 1 succeeded, 0 reverted. Since schemaVersion 2.
+	Status *int `json:"status,omitempty" yaml:"status,omitempty" mapstructure:"status,omitempty"`
+
 	// To corresponds to the JSON schema field "to".
 	To *string `json:"to,omitempty" yaml:"to,omitempty" mapstructure:"to,omitempty"`
 
 	// TransactionHash corresponds to the JSON schema field "transactionHash".
 	TransactionHash string `json:"transactionHash" yaml:"transactionHash" mapstructure:"transactionHash"`
+
+	// Transaction type. Since schemaVersion 2.
+	Type *int `json:"type,omitempty" yaml:"type,omitempty" mapstructure:"type,omitempty"`
 }
 
 type BlockDtoJsonReceiptsElemLogsElem struct {
@@ -133,6 +179,128 @@ func (j *BlockDtoJsonReceiptsElem) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+type BlockDtoJsonTransactionsElem struct {
+	// From corresponds to the JSON schema field "from".
+	From string `json:"from" yaml:"from" mapstructure:"from"`
+
+	// Gas corresponds to the JSON schema field "gas".
+	Gas int `json:"gas" yaml:"gas" mapstructure:"gas"`
+
+	// Wei as a decimal string.
+	GasPrice *string `json:"gasPrice,omitempty" yaml:"gasPrice,omitempty" mapstructure:"gasPrice,omitempty"`
+
+	// Hash corresponds to the JSON schema field "hash".
+	Hash string `json:"hash" yaml:"hash" mapstructure:"hash"`
+
+	// Input corresponds to the JSON schema field "input".
+	Input string `json:"input" yaml:"input" mapstructure:"input"`
+
+	// Wei as a decimal string, EIP-1559 transactions only.
+	MaxFeePerGas *string `json:"maxFeePerGas,omitempty" yaml:"maxFeePerGas,omitempty" mapstructure:"maxFeePerGas,omitempty"`
+
+	// Wei as a decimal string, EIP-1559 transactions only.
+	MaxPriorityFeePerGas *string `json:"maxPriorityFeePerGas,omitempty" yaml:"maxPriorityFeePerGas,omitempty" mapstructure:"maxPriorityFeePerGas,omitempty"`
+
+	// Nonce corresponds to the JSON schema field "nonce".
+	Nonce int `json:"nonce" yaml:"nonce" mapstructure:"nonce"`
+
+	// Receipt status: 1 succeeded, 0 reverted. Absent when the receipt is missing or
+	// predates Byzantium.
+	Status *int `json:"status,omitempty" yaml:"status,omitempty" mapstructure:"status,omitempty"`
+
+	// Absent for contract creation.
+	To *string `json:"to,omitempty" yaml:"to,omitempty" mapstructure:"to,omitempty"`
+
+	// TransactionIndex corresponds to the JSON schema field "transactionIndex".
+	TransactionIndex int `json:"transactionIndex" yaml:"transactionIndex" mapstructure:"transactionIndex"`
+
+	// Type corresponds to the JSON schema field "type".
+	Type int `json:"type" yaml:"type" mapstructure:"type"`
+
+	// Wei as a decimal string.
+	Value string `json:"value" yaml:"value" mapstructure:"value"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (j *BlockDtoJsonTransactionsElem) UnmarshalJSON(b []byte) error {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	if _, ok := raw["from"]; raw != nil && !ok {
+		return fmt.Errorf("field from in BlockDtoJsonTransactionsElem: required")
+	}
+	if _, ok := raw["gas"]; raw != nil && !ok {
+		return fmt.Errorf("field gas in BlockDtoJsonTransactionsElem: required")
+	}
+	if _, ok := raw["hash"]; raw != nil && !ok {
+		return fmt.Errorf("field hash in BlockDtoJsonTransactionsElem: required")
+	}
+	if _, ok := raw["input"]; raw != nil && !ok {
+		return fmt.Errorf("field input in BlockDtoJsonTransactionsElem: required")
+	}
+	if _, ok := raw["nonce"]; raw != nil && !ok {
+		return fmt.Errorf("field nonce in BlockDtoJsonTransactionsElem: required")
+	}
+	if _, ok := raw["transactionIndex"]; raw != nil && !ok {
+		return fmt.Errorf("field transactionIndex in BlockDtoJsonTransactionsElem: required")
+	}
+	if _, ok := raw["type"]; raw != nil && !ok {
+		return fmt.Errorf("field type in BlockDtoJsonTransactionsElem: required")
+	}
+	if _, ok := raw["value"]; raw != nil && !ok {
+		return fmt.Errorf("field value in BlockDtoJsonTransactionsElem: required")
+	}
+	type Plain BlockDtoJsonTransactionsElem
+	var plain Plain
+	if err := json.Unmarshal(b, &plain); err != nil {
+		return err
+	}
+	*j = BlockDtoJsonTransactionsElem(plain)
+	return nil
+}
+
+type BlockDtoJsonWithdrawalsElem struct {
+	// Address corresponds to the JSON schema field "address".
+	Address string `json:"address" yaml:"address" mapstructure:"address"`
+
+	// Amount in gwei.
+	Amount int `json:"amount" yaml:"amount" mapstructure:"amount"`
+
+	// Index corresponds to the JSON schema field "index".
+	Index int `json:"index" yaml:"index" mapstructure:"index"`
+
+	// ValidatorIndex corresponds to the JSON schema field "validatorIndex".
+	ValidatorIndex int `json:"validatorIndex" yaml:"validatorIndex" mapstructure:"validatorIndex"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (j *BlockDtoJsonWithdrawalsElem) UnmarshalJSON(b []byte) error {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	if _, ok := raw["address"]; raw != nil && !ok {
+		return fmt.Errorf("field address in BlockDtoJsonWithdrawalsElem: required")
+	}
+	if _, ok := raw["amount"]; raw != nil && !ok {
+		return fmt.Errorf("field amount in BlockDtoJsonWithdrawalsElem: required")
+	}
+	if _, ok := raw["index"]; raw != nil && !ok {
+		return fmt.Errorf("field index in BlockDtoJsonWithdrawalsElem: required")
+	}
+	if _, ok := raw["validatorIndex"]; raw != nil && !ok {
+		return fmt.Errorf("field validatorIndex in BlockDtoJsonWithdrawalsElem: required")
+	}
+	type Plain BlockDtoJsonWithdrawalsElem
+	var plain Plain
+	if err := json.Unmarshal(b, &plain); err != nil {
+		return err
+	}
+	*j = BlockDtoJsonWithdrawalsElem(plain)
+	return nil
+}
+
 // UnmarshalJSON implements json.Unmarshaler.
 func (j *BlockDtoJson) UnmarshalJSON(b []byte) error {
 	var raw map[string]interface{}
@@ -0,0 +1,128 @@
+// Code generated by github.com/atombender/go-jsonschema, DO NOT EDIT.
+
+package databus
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Logs of one block emitted by one contract, or carrying one topic0, in block
+// order.
+type LogsDtoJson struct {
+	// Hash corresponds to the JSON schema field "hash".
+	Hash string `json:"hash" yaml:"hash" mapstructure:"hash"`
+
+	// Logs corresponds to the JSON schema field "logs".
+	Logs []LogsDtoJsonLogsElem `json:"logs" yaml:"logs" mapstructure:"logs"`
+
+	// Number corresponds to the JSON schema field "number".
+	Number int `json:"number" yaml:"number" mapstructure:"number"`
+
+	// ParentHash corresponds to the JSON schema field "parentHash".
+	ParentHash string `json:"parentHash" yaml:"parentHash" mapstructure:"parentHash"`
+
+	// Timestamp corresponds to the JSON schema field "timestamp".
+	Timestamp int `json:"timestamp" yaml:"timestamp" mapstructure:"timestamp"`
+}
+
+type LogsDtoJsonLogsElem struct {
+	// Address corresponds to the JSON schema field "address".
+	Address string `json:"address" yaml:"address" mapstructure:"address"`
+
+	// BlockHash corresponds to the JSON schema field "blockHash".
+	BlockHash string `json:"blockHash" yaml:"blockHash" mapstructure:"blockHash"`
+
+	// BlockNumber corresponds to the JSON schema field "blockNumber".
+	BlockNumber int `json:"blockNumber" yaml:"blockNumber" mapstructure:"blockNumber"`
+
+	// Data corresponds to the JSON schema field "data".
+	Data string `json:"data" yaml:"data" mapstructure:"data"`
+
+	// LogIndex corresponds to the JSON schema field "logIndex".
+	LogIndex int `json:"logIndex" yaml:"logIndex" mapstructure:"logIndex"`
+
+	// Removed corresponds to the JSON schema field "removed".
+	Removed bool `json:"removed" yaml:"removed" mapstructure:"removed"`
+
+	// Topics corresponds to the JSON schema field "topics".
+	Topics []string `json:"topics" yaml:"topics" mapstructure:"topics"`
+
+	// TransactionHash corresponds to the JSON schema field "transactionHash".
+	TransactionHash string `json:"transactionHash" yaml:"transactionHash" mapstructure:"transactionHash"`
+
+	// TransactionIndex corresponds to the JSON schema field "transactionIndex".
+	TransactionIndex int `json:"transactionIndex" yaml:"transactionIndex" mapstructure:"transactionIndex"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (j *LogsDtoJsonLogsElem) UnmarshalJSON(b []byte) error {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	if _, ok := raw["address"]; raw != nil && !ok {
+		return fmt.Errorf("field address in LogsDtoJsonLogsElem: required")
+	}
+	if _, ok := raw["blockHash"]; raw != nil && !ok {
+		return fmt.Errorf("field blockHash in LogsDtoJsonLogsElem: required")
+	}
+	if _, ok := raw["blockNumber"]; raw != nil && !ok {
+		return fmt.Errorf("field blockNumber in LogsDtoJsonLogsElem: required")
+	}
+	if _, ok := raw["data"]; raw != nil && !ok {
+		return fmt.Errorf("field data in LogsDtoJsonLogsElem: required")
+	}
+	if _, ok := raw["logIndex"]; raw != nil && !ok {
+		return fmt.Errorf("field logIndex in LogsDtoJsonLogsElem: required")
+	}
+	if _, ok := raw["removed"]; raw != nil && !ok {
+		return fmt.Errorf("field removed in LogsDtoJsonLogsElem: required")
+	}
+	if _, ok := raw["topics"]; raw != nil && !ok {
+		return fmt.Errorf("field topics in LogsDtoJsonLogsElem: required")
+	}
+	if _, ok := raw["transactionHash"]; raw != nil && !ok {
+		return fmt.Errorf("field transactionHash in LogsDtoJsonLogsElem: required")
+	}
+	if _, ok := raw["transactionIndex"]; raw != nil && !ok {
+		return fmt.Errorf("field transactionIndex in LogsDtoJsonLogsElem: required")
+	}
+	type Plain LogsDtoJsonLogsElem
+	var plain Plain
+	if err := json.Unmarshal(b, &plain); err != nil {
+		return err
+	}
+	*j = LogsDtoJsonLogsElem(plain)
+	return nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (j *LogsDtoJson) UnmarshalJSON(b []byte) error {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	if _, ok := raw["hash"]; raw != nil && !ok {
+		return fmt.Errorf("field hash in LogsDtoJson: required")
+	}
+	if _, ok := raw["logs"]; raw != nil && !ok {
+		return fmt.Errorf("field logs in LogsDtoJson: required")
+	}
+	if _, ok := raw["number"]; raw != nil && !ok {
+		return fmt.Errorf("field number in LogsDtoJson: required")
+	}
+	if _, ok := raw["parentHash"]; raw != nil && !ok {
+		return fmt.Errorf("field parentHash in LogsDtoJson: required")
+	}
+	if _, ok := raw["timestamp"]; raw != nil && !ok {
+		return fmt.Errorf("field timestamp in LogsDtoJson: required")
+	}
+	type Plain LogsDtoJson
+	var plain Plain
+	if err := json.Unmarshal(b, &plain); err != nil {
+		return err
+	}
+	*j = LogsDtoJson(plain)
+	return nil
+}
@@ -0,0 +1,90 @@
+// Code generated by github.com/atombender/go-jsonschema, DO NOT EDIT.
+
+package databus
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Transaction seen in the mempool, not yet in a block, sent to one of the watched
+// addresses. It may never be included.
+type PendingTransactionDtoJson struct {
+	// From corresponds to the JSON schema field "from".
+	From string `json:"from" yaml:"from" mapstructure:"from"`
+
+	// Gas corresponds to the JSON schema field "gas".
+	Gas int `json:"gas" yaml:"gas" mapstructure:"gas"`
+
+	// Wei as a decimal string.
+	GasPrice *string `json:"gasPrice,omitempty" yaml:"gasPrice,omitempty" mapstructure:"gasPrice,omitempty"`
+
+	// Hash corresponds to the JSON schema field "hash".
+	Hash string `json:"hash" yaml:"hash" mapstructure:"hash"`
+
+	// Input corresponds to the JSON schema field "input".
+	Input string `json:"input" yaml:"input" mapstructure:"input"`
+
+	// Wei as a decimal string, EIP-1559 transactions only.
+	MaxFeePerGas *string `json:"maxFeePerGas,omitempty" yaml:"maxFeePerGas,omitempty" mapstructure:"maxFeePerGas,omitempty"`
+
+	// Wei as a decimal string, EIP-1559 transactions only.
+	MaxPriorityFeePerGas *string `json:"maxPriorityFeePerGas,omitempty" yaml:"maxPriorityFeePerGas,omitempty" mapstructure:"maxPriorityFeePerGas,omitempty"`
+
+	// Nonce corresponds to the JSON schema field "nonce".
+	Nonce int `json:"nonce" yaml:"nonce" mapstructure:"nonce"`
+
+	// Unix time in milliseconds the feeder first saw the transaction.
+	SeenAt int `json:"seenAt" yaml:"seenAt" mapstructure:"seenAt"`
+
+	// The watched address the transaction calls, lowercase.
+	To string `json:"to" yaml:"to" mapstructure:"to"`
+
+	// Type corresponds to the JSON schema field "type".
+	Type int `json:"type" yaml:"type" mapstructure:"type"`
+
+	// Wei as a decimal string.
+	Value string `json:"value" yaml:"value" mapstructure:"value"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (j *PendingTransactionDtoJson) UnmarshalJSON(b []byte) error {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	if _, ok := raw["from"]; raw != nil && !ok {
+		return fmt.Errorf("field from in PendingTransactionDtoJson: required")
+	}
+	if _, ok := raw["gas"]; raw != nil && !ok {
+		return fmt.Errorf("field gas in PendingTransactionDtoJson: required")
+	}
+	if _, ok := raw["hash"]; raw != nil && !ok {
+		return fmt.Errorf("field hash in PendingTransactionDtoJson: required")
+	}
+	if _, ok := raw["input"]; raw != nil && !ok {
+		return fmt.Errorf("field input in PendingTransactionDtoJson: required")
+	}
+	if _, ok := raw["nonce"]; raw != nil && !ok {
+		return fmt.Errorf("field nonce in PendingTransactionDtoJson: required")
+	}
+	if _, ok := raw["seenAt"]; raw != nil && !ok {
+		return fmt.Errorf("field seenAt in PendingTransactionDtoJson: required")
+	}
+	if _, ok := raw["to"]; raw != nil && !ok {
+		return fmt.Errorf("field to in PendingTransactionDtoJson: required")
+	}
+	if _, ok := raw["type"]; raw != nil && !ok {
+		return fmt.Errorf("field type in PendingTransactionDtoJson: required")
+	}
+	if _, ok := raw["value"]; raw != nil && !ok {
+		return fmt.Errorf("field value in PendingTransactionDtoJson: required")
+	}
+	type Plain PendingTransactionDtoJson
+	var plain Plain
+	if err := json.Unmarshal(b, &plain); err != nil {
+		return err
+	}
+	*j = PendingTransactionDtoJson(plain)
+	return nil
+}
@@ -0,0 +1,162 @@
+// Code generated by github.com/atombender/go-jsonschema, DO NOT EDIT.
+
+package databus
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+type ReorgDtoJson struct {
+	// BeyondWindow corresponds to the JSON schema field "beyondWindow".
+	BeyondWindow bool `json:"beyondWindow" yaml:"beyondWindow" mapstructure:"beyondWindow"`
+
+	// CommonAncestorHash corresponds to the JSON schema field "commonAncestorHash".
+	CommonAncestorHash string `json:"commonAncestorHash" yaml:"commonAncestorHash" mapstructure:"commonAncestorHash"`
+
+	// CommonAncestorNumber corresponds to the JSON schema field
+	// "commonAncestorNumber".
+	CommonAncestorNumber int `json:"commonAncestorNumber" yaml:"commonAncestorNumber" mapstructure:"commonAncestorNumber"`
+
+	// Depth corresponds to the JSON schema field "depth".
+	Depth int `json:"depth" yaml:"depth" mapstructure:"depth"`
+
+	// Orphaned corresponds to the JSON schema field "orphaned".
+	Orphaned []ReorgDtoJsonOrphanedElem `json:"orphaned" yaml:"orphaned" mapstructure:"orphaned"`
+
+	// RemovedLogs corresponds to the JSON schema field "removedLogs".
+	RemovedLogs []ReorgDtoJsonRemovedLogsElem `json:"removedLogs" yaml:"removedLogs" mapstructure:"removedLogs"`
+}
+
+type ReorgDtoJsonOrphanedElem struct {
+	// Hash corresponds to the JSON schema field "hash".
+	Hash string `json:"hash" yaml:"hash" mapstructure:"hash"`
+
+	// Number corresponds to the JSON schema field "number".
+	Number int `json:"number" yaml:"number" mapstructure:"number"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (j *ReorgDtoJsonOrphanedElem) UnmarshalJSON(b []byte) error {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	if _, ok := raw["hash"]; raw != nil && !ok {
+		return fmt.Errorf("field hash in ReorgDtoJsonOrphanedElem: required")
+	}
+	if _, ok := raw["number"]; raw != nil && !ok {
+		return fmt.Errorf("field number in ReorgDtoJsonOrphanedElem: required")
+	}
+	type Plain ReorgDtoJsonOrphanedElem
+	var plain Plain
+	if err := json.Unmarshal(b, &plain); err != nil {
+		return err
+	}
+	*j = ReorgDtoJsonOrphanedElem(plain)
+	return nil
+}
+
+type ReorgDtoJsonRemovedLogsElem struct {
+	// Address corresponds to the JSON schema field "address".
+	Address string `json:"address" yaml:"address" mapstructure:"address"`
+
+	// BlockHash corresponds to the JSON schema field "blockHash".
+	BlockHash string `json:"blockHash" yaml:"blockHash" mapstructure:"blockHash"`
+
+	// BlockNumber corresponds to the JSON schema field "blockNumber".
+	BlockNumber int `json:"blockNumber" yaml:"blockNumber" mapstructure:"blockNumber"`
+
+	// Data corresponds to the JSON schema field "data".
+	Data string `json:"data" yaml:"data" mapstructure:"data"`
+
+	// LogIndex corresponds to the JSON schema field "logIndex".
+	LogIndex int `json:"logIndex" yaml:"logIndex" mapstructure:"logIndex"`
+
+	// Removed corresponds to the JSON schema field "removed".
+	Removed bool `json:"removed" yaml:"removed" mapstructure:"removed"`
+
+	// Topics corresponds to the JSON schema field "topics".
+	Topics []string `json:"topics" yaml:"topics" mapstructure:"topics"`
+
+	// TransactionHash corresponds to the JSON schema field "transactionHash".
+	TransactionHash string `json:"transactionHash" yaml:"transactionHash" mapstructure:"transactionHash"`
+
+	// TransactionIndex corresponds to the JSON schema field "transactionIndex".
+	TransactionIndex int `json:"transactionIndex" yaml:"transactionIndex" mapstructure:"transactionIndex"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (j *ReorgDtoJsonRemovedLogsElem) UnmarshalJSON(b []byte) error {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	if _, ok := raw["address"]; raw != nil && !ok {
+		return fmt.Errorf("field address in ReorgDtoJsonRemovedLogsElem: required")
+	}
+	if _, ok := raw["blockHash"]; raw != nil && !ok {
+		return fmt.Errorf("field blockHash in ReorgDtoJsonRemovedLogsElem: required")
+	}
+	if _, ok := raw["blockNumber"]; raw != nil && !ok {
+		return fmt.Errorf("field blockNumber in ReorgDtoJsonRemovedLogsElem: required")
+	}
+	if _, ok := raw["data"]; raw != nil && !ok {
+		return fmt.Errorf("field data in ReorgDtoJsonRemovedLogsElem: required")
+	}
+	if _, ok := raw["logIndex"]; raw != nil && !ok {
+		return fmt.Errorf("field logIndex in ReorgDtoJsonRemovedLogsElem: required")
+	}
+	if _, ok := raw["removed"]; raw != nil && !ok {
+		return fmt.Errorf("field removed in ReorgDtoJsonRemovedLogsElem: required")
+	}
+	if _, ok := raw["topics"]; raw != nil && !ok {
+		return fmt.Errorf("field topics in ReorgDtoJsonRemovedLogsElem: required")
+	}
+	if _, ok := raw["transactionHash"]; raw != nil && !ok {
+		return fmt.Errorf("field transactionHash in ReorgDtoJsonRemovedLogsElem: required")
+	}
+	if _, ok := raw["transactionIndex"]; raw != nil && !ok {
+		return fmt.Errorf("field transactionIndex in ReorgDtoJsonRemovedLogsElem: required")
+	}
+	type Plain ReorgDtoJsonRemovedLogsElem
+	var plain Plain
+	if err := json.Unmarshal(b, &plain); err != nil {
+		return err
+	}
+	*j = ReorgDtoJsonRemovedLogsElem(plain)
+	return nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (j *ReorgDtoJson) UnmarshalJSON(b []byte) error {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	if _, ok := raw["beyondWindow"]; raw != nil && !ok {
+		return fmt.Errorf("field beyondWindow in ReorgDtoJson: required")
+	}
+	if _, ok := raw["commonAncestorHash"]; raw != nil && !ok {
+		return fmt.Errorf("field commonAncestorHash in ReorgDtoJson: required")
+	}
+	if _, ok := raw["commonAncestorNumber"]; raw != nil && !ok {
+		return fmt.Errorf("field commonAncestorNumber in ReorgDtoJson: required")
+	}
+	if _, ok := raw["depth"]; raw != nil && !ok {
+		return fmt.Errorf("field depth in ReorgDtoJson: required")
+	}
+	if _, ok := raw["orphaned"]; raw != nil && !ok {
+		return fmt.Errorf("field orphaned in ReorgDtoJson: required")
+	}
+	if _, ok := raw["removedLogs"]; raw != nil && !ok {
+		return fmt.Errorf("field removedLogs in ReorgDtoJson: required")
+	}
+	type Plain ReorgDtoJson
+	var plain Plain
+	if err := json.Unmarshal(b, &plain); err != nil {
+		return err
+	}
+	*j = ReorgDtoJson(plain)
+	return nil
+}
@@ -0,0 +1,113 @@
+// Code generated by github.com/atombender/go-jsonschema, DO NOT EDIT.
+
+package databus
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Values of the watched view functions, called at one published block. Only the
+// watchers whose interval is due are in it.
+type StateDtoJson struct {
+	// The block every call was made against.
+	BlockHash string `json:"blockHash" yaml:"blockHash" mapstructure:"blockHash"`
+
+	// BlockNumber corresponds to the JSON schema field "blockNumber".
+	BlockNumber int `json:"blockNumber" yaml:"blockNumber" mapstructure:"blockNumber"`
+
+	// Timestamp corresponds to the JSON schema field "timestamp".
+	Timestamp int `json:"timestamp" yaml:"timestamp" mapstructure:"timestamp"`
+
+	// Values corresponds to the JSON schema field "values".
+	Values []StateDtoJsonValuesElem `json:"values" yaml:"values" mapstructure:"values"`
+}
+
+type StateDtoJsonValuesElem struct {
+	// Address corresponds to the JSON schema field "address".
+	Address string `json:"address" yaml:"address" mapstructure:"address"`
+
+	// Args corresponds to the JSON schema field "args".
+	Args []string `json:"args" yaml:"args" mapstructure:"args"`
+
+	// True when current differs from previous.
+	Changed bool `json:"changed" yaml:"changed" mapstructure:"changed"`
+
+	// What the function returned, one entry per returned type: integers in decimal,
+	// addresses and bytes in 0x hex, bools as true or false. Absent when the call
+	// failed.
+	Current []string `json:"current,omitempty" yaml:"current,omitempty" mapstructure:"current,omitempty"`
+
+	// Why the call has no value: the revert, or an output that does not decode as the
+	// watched types.
+	Error *string `json:"error,omitempty" yaml:"error,omitempty" mapstructure:"error,omitempty"`
+
+	// Canonical signature, as in getBufferedEther() or balanceOf(address).
+	Function string `json:"function" yaml:"function" mapstructure:"function"`
+
+	// Name corresponds to the JSON schema field "name".
+	Name string `json:"name" yaml:"name" mapstructure:"name"`
+
+	// The last value the watcher got before this one. Absent until it has one.
+	Previous []string `json:"previous,omitempty" yaml:"previous,omitempty" mapstructure:"previous,omitempty"`
+
+	// The block previous was read at.
+	PreviousBlockNumber *int `json:"previousBlockNumber,omitempty" yaml:"previousBlockNumber,omitempty" mapstructure:"previousBlockNumber,omitempty"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (j *StateDtoJsonValuesElem) UnmarshalJSON(b []byte) error {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	if _, ok := raw["address"]; raw != nil && !ok {
+		return fmt.Errorf("field address in StateDtoJsonValuesElem: required")
+	}
+	if _, ok := raw["args"]; raw != nil && !ok {
+		return fmt.Errorf("field args in StateDtoJsonValuesElem: required")
+	}
+	if _, ok := raw["changed"]; raw != nil && !ok {
+		return fmt.Errorf("field changed in StateDtoJsonValuesElem: required")
+	}
+	if _, ok := raw["function"]; raw != nil && !ok {
+		return fmt.Errorf("field function in StateDtoJsonValuesElem: required")
+	}
+	if _, ok := raw["name"]; raw != nil && !ok {
+		return fmt.Errorf("field name in StateDtoJsonValuesElem: required")
+	}
+	type Plain StateDtoJsonValuesElem
+	var plain Plain
+	if err := json.Unmarshal(b, &plain); err != nil {
+		return err
+	}
+	*j = StateDtoJsonValuesElem(plain)
+	return nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (j *StateDtoJson) UnmarshalJSON(b []byte) error {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	if _, ok := raw["blockHash"]; raw != nil && !ok {
+		return fmt.Errorf("field blockHash in StateDtoJson: required")
+	}
+	if _, ok := raw["blockNumber"]; raw != nil && !ok {
+		return fmt.Errorf("field blockNumber in StateDtoJson: required")
+	}
+	if _, ok := raw["timestamp"]; raw != nil && !ok {
+		return fmt.Errorf("field timestamp in StateDtoJson: required")
+	}
+	if _, ok := raw["values"]; raw != nil && !ok {
+		return fmt.Errorf("field values in StateDtoJson: required")
+	}
+	type Plain StateDtoJson
+	var plain Plain
+	if err := json.Unmarshal(b, &plain); err != nil {
+		return err
+	}
+	*j = StateDtoJson(plain)
+	return nil
+}
@@ -0,0 +1,109 @@
+// Code generated by github.com/atombender/go-jsonschema, DO NOT EDIT.
+
+package databus
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// The watched storage slots that changed at one published block. Slots that did
+// not change are not in it, and a block without changes publishes nothing.
+type StorageDtoJson struct {
+	// The block every slot was read at.
+	BlockHash string `json:"blockHash" yaml:"blockHash" mapstructure:"blockHash"`
+
+	// BlockNumber corresponds to the JSON schema field "blockNumber".
+	BlockNumber int `json:"blockNumber" yaml:"blockNumber" mapstructure:"blockNumber"`
+
+	// Diffs corresponds to the JSON schema field "diffs".
+	Diffs []StorageDtoJsonDiffsElem `json:"diffs" yaml:"diffs" mapstructure:"diffs"`
+
+	// Timestamp corresponds to the JSON schema field "timestamp".
+	Timestamp int `json:"timestamp" yaml:"timestamp" mapstructure:"timestamp"`
+}
+
+type StorageDtoJsonDiffsElem struct {
+	// Address corresponds to the JSON schema field "address".
+	Address string `json:"address" yaml:"address" mapstructure:"address"`
+
+	// The 32-byte word the slot holds at this block.
+	Current string `json:"current" yaml:"current" mapstructure:"current"`
+
+	// Name corresponds to the JSON schema field "name".
+	Name string `json:"name" yaml:"name" mapstructure:"name"`
+
+	// The 32-byte word the slot held at previousBlockNumber.
+	Previous string `json:"previous" yaml:"previous" mapstructure:"previous"`
+
+	// The block previous was read at. When it is not the parent block, the change
+	// happened at a block in between.
+	PreviousBlockNumber int `json:"previousBlockNumber" yaml:"previousBlockNumber" mapstructure:"previousBlockNumber"`
+
+	// The slot as a 0x-prefixed 32-byte word, EIP-1967 slots included.
+	Slot string `json:"slot" yaml:"slot" mapstructure:"slot"`
+
+	// The last transaction of the block that wrote the slot. Absent when the change
+	// may predate the block or the node does not trace.
+	TransactionHash *string `json:"transactionHash,omitempty" yaml:"transactionHash,omitempty" mapstructure:"transactionHash,omitempty"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (j *StorageDtoJsonDiffsElem) UnmarshalJSON(b []byte) error {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	if _, ok := raw["address"]; raw != nil && !ok {
+		return fmt.Errorf("field address in StorageDtoJsonDiffsElem: required")
+	}
+	if _, ok := raw["current"]; raw != nil && !ok {
+		return fmt.Errorf("field current in StorageDtoJsonDiffsElem: required")
+	}
+	if _, ok := raw["name"]; raw != nil && !ok {
+		return fmt.Errorf("field name in StorageDtoJsonDiffsElem: required")
+	}
+	if _, ok := raw["previous"]; raw != nil && !ok {
+		return fmt.Errorf("field previous in StorageDtoJsonDiffsElem: required")
+	}
+	if _, ok := raw["previousBlockNumber"]; raw != nil && !ok {
+		return fmt.Errorf("field previousBlockNumber in StorageDtoJsonDiffsElem: required")
+	}
+	if _, ok := raw["slot"]; raw != nil && !ok {
+		return fmt.Errorf("field slot in StorageDtoJsonDiffsElem: required")
+	}
+	type Plain StorageDtoJsonDiffsElem
+	var plain Plain
+	if err := json.Unmarshal(b, &plain); err != nil {
+		return err
+	}
+	*j = StorageDtoJsonDiffsElem(plain)
+	return nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (j *StorageDtoJson) UnmarshalJSON(b []byte) error {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	if _, ok := raw["blockHash"]; raw != nil && !ok {
+		return fmt.Errorf("field blockHash in StorageDtoJson: required")
+	}
+	if _, ok := raw["blockNumber"]; raw != nil && !ok {
+		return fmt.Errorf("field blockNumber in StorageDtoJson: required")
+	}
+	if _, ok := raw["diffs"]; raw != nil && !ok {
+		return fmt.Errorf("field diffs in StorageDtoJson: required")
+	}
+	if _, ok := raw["timestamp"]; raw != nil && !ok {
+		return fmt.Errorf("field timestamp in StorageDtoJson: required")
+	}
+	type Plain StorageDtoJson
+	var plain Plain
+	if err := json.Unmarshal(b, &plain); err != nil {
+		return err
+	}
+	*j = StorageDtoJson(plain)
+	return nil
+}
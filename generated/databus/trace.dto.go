@@ -0,0 +1,124 @@
+// Code generated by github.com/atombender/go-jsonschema, DO NOT EDIT.
+
+package databus
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+type TraceDtoJson struct {
+	// Every call of the block, depth-first in execution order.
+	Calls []TraceDtoJsonCallsElem `json:"calls" yaml:"calls" mapstructure:"calls"`
+
+	// Hash corresponds to the JSON schema field "hash".
+	Hash string `json:"hash" yaml:"hash" mapstructure:"hash"`
+
+	// Number corresponds to the JSON schema field "number".
+	Number int `json:"number" yaml:"number" mapstructure:"number"`
+}
+
+type TraceDtoJsonCallsElem struct {
+	// Set when the call reverted.
+	Error *string `json:"error,omitempty" yaml:"error,omitempty" mapstructure:"error,omitempty"`
+
+	// From corresponds to the JSON schema field "from".
+	From string `json:"from" yaml:"from" mapstructure:"from"`
+
+	// Gas corresponds to the JSON schema field "gas".
+	Gas int `json:"gas" yaml:"gas" mapstructure:"gas"`
+
+	// GasUsed corresponds to the JSON schema field "gasUsed".
+	GasUsed int `json:"gasUsed" yaml:"gasUsed" mapstructure:"gasUsed"`
+
+	// Input corresponds to the JSON schema field "input".
+	Input string `json:"input" yaml:"input" mapstructure:"input"`
+
+	// Output corresponds to the JSON schema field "output".
+	Output *string `json:"output,omitempty" yaml:"output,omitempty" mapstructure:"output,omitempty"`
+
+	// The created contract for CREATE, the beneficiary for SELFDESTRUCT.
+	To *string `json:"to,omitempty" yaml:"to,omitempty" mapstructure:"to,omitempty"`
+
+	// Path from the top-level call: empty for the transaction itself, [0, 1] for the
+	// second call of its first call.
+	TraceAddress []int `json:"traceAddress" yaml:"traceAddress" mapstructure:"traceAddress"`
+
+	// TransactionHash corresponds to the JSON schema field "transactionHash".
+	TransactionHash string `json:"transactionHash" yaml:"transactionHash" mapstructure:"transactionHash"`
+
+	// TransactionIndex corresponds to the JSON schema field "transactionIndex".
+	TransactionIndex int `json:"transactionIndex" yaml:"transactionIndex" mapstructure:"transactionIndex"`
+
+	// CALL, DELEGATECALL, STATICCALL, CALLCODE, CREATE, CREATE2 or SELFDESTRUCT.
+	Type string `json:"type" yaml:"type" mapstructure:"type"`
+
+	// Wei as a decimal string.
+	Value string `json:"value" yaml:"value" mapstructure:"value"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (j *TraceDtoJsonCallsElem) UnmarshalJSON(b []byte) error {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	if _, ok := raw["from"]; raw != nil && !ok {
+		return fmt.Errorf("field from in TraceDtoJsonCallsElem: required")
+	}
+	if _, ok := raw["gas"]; raw != nil && !ok {
+		return fmt.Errorf("field gas in TraceDtoJsonCallsElem: required")
+	}
+	if _, ok := raw["gasUsed"]; raw != nil && !ok {
+		return fmt.Errorf("field gasUsed in TraceDtoJsonCallsElem: required")
+	}
+	if _, ok := raw["input"]; raw != nil && !ok {
+		return fmt.Errorf("field input in TraceDtoJsonCallsElem: required")
+	}
+	if _, ok := raw["traceAddress"]; raw != nil && !ok {
+		return fmt.Errorf("field traceAddress in TraceDtoJsonCallsElem: required")
+	}
+	if _, ok := raw["transactionHash"]; raw != nil && !ok {
+		return fmt.Errorf("field transactionHash in TraceDtoJsonCallsElem: required")
+	}
+	if _, ok := raw["transactionIndex"]; raw != nil && !ok {
+		return fmt.Errorf("field transactionIndex in TraceDtoJsonCallsElem: required")
+	}
+	if _, ok := raw["type"]; raw != nil && !ok {
+		return fmt.Errorf("field type in TraceDtoJsonCallsElem: required")
+	}
+	if _, ok := raw["value"]; raw != nil && !ok {
+		return fmt.Errorf("field value in TraceDtoJsonCallsElem: required")
+	}
+	type Plain TraceDtoJsonCallsElem
+	var plain Plain
+	if err := json.Unmarshal(b, &plain); err != nil {
+		return err
+	}
+	*j = TraceDtoJsonCallsElem(plain)
+	return nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (j *TraceDtoJson) UnmarshalJSON(b []byte) error {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	if _, ok := raw["calls"]; raw != nil && !ok {
+		return fmt.Errorf("field calls in TraceDtoJson: required")
+	}
+	if _, ok := raw["hash"]; raw != nil && !ok {
+		return fmt.Errorf("field hash in TraceDtoJson: required")
+	}
+	if _, ok := raw["number"]; raw != nil && !ok {
+		return fmt.Errorf("field number in TraceDtoJson: required")
+	}
+	type Plain TraceDtoJson
+	var plain Plain
+	if err := json.Unmarshal(b, &plain); err != nil {
+		return err
+	}
+	*j = TraceDtoJson(plain)
+	return nil
+}
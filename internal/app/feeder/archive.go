@@ -0,0 +1,33 @@
+package feeder
+
+import (
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/lidofinance/onchain-mon/generated/databus"
+	"github.com/lidofinance/onchain-mon/internal/connectors/metrics"
+)
+
+// BlockArchive keeps published blocks as they went out; archive.Writer is the
+// real one.
+type BlockArchive interface {
+	Append(number int64, hash string, dictID uint32, payload []byte) error
+}
+
+// archiveBlock keeps the compressed JSON payload of a published block. The
+// archive is best effort: a block that cannot be written is counted and
+// logged, and publishing goes on.
+func (w *Feeder) archiveBlock(dto *databus.BlockDtoJson, cPayload []byte) {
+	if w.archive == nil {
+		return
+	}
+
+	if err := w.archive.Append(int64(dto.Number), dto.Hash, w.compressor.DictID(), cPayload); err != nil {
+		w.metricsStore.ArchivedBlocks.With(prometheus.Labels{metrics.Status: metrics.StatusFail}).Inc()
+		w.log.Error(fmt.Sprintf("Could not archive block %d: %v", dto.Number, err))
+		return
+	}
+
+	w.metricsStore.ArchivedBlocks.With(prometheus.Labels{metrics.Status: metrics.StatusOk}).Inc()
+}
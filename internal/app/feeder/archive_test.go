@@ -0,0 +1,38 @@
+package feeder
+
+import (
+	"testing"
+
+	"github.com/lidofinance/onchain-mon/generated/databus"
+	"github.com/lidofinance/onchain-mon/pkg/envelope"
+)
+
+type recordingArchive struct {
+	numbers []int64
+}
+
+func (a *recordingArchive) Append(number int64, _ string, _ uint32, _ []byte) error {
+	a.numbers = append(a.numbers, number)
+	return nil
+}
+
+func Test_only_head_blocks_are_archived_once(t *testing.T) {
+	a := &recordingArchive{}
+
+	f := newTestFeeder(&canonicalChain{})
+	f.js = &recordingJetStream{}
+	f.topic = "blocks.test"
+	f.encodings = []string{envelope.JSON, envelope.MsgPack}
+	f.archive = a
+
+	if err := f.publishBlock(f.topic, "0x100", databus.BlockDtoJson{Number: 100, Hash: "0x100"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.publishBlock(f.topic+".finalized", streamMsgID("0x90", "finalized"), databus.BlockDtoJson{Number: 90, Hash: "0x90"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(a.numbers) != 1 || a.numbers[0] != 100 {
+		t.Errorf("archived %v, want the head block once, in JSON only", a.numbers)
+	}
+}
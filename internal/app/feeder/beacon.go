@@ -0,0 +1,266 @@
+package feeder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/lidofinance/onchain-mon/generated/databus"
+	"github.com/lidofinance/onchain-mon/internal/connectors/metrics"
+	"github.com/lidofinance/onchain-mon/internal/pkg/beacon"
+)
+
+// BeaconSubjectSuffix is appended to the block topic: blocks.mainnet.l1.beacon
+// carries one beacon block per slot, blocks.mainnet.l1.beacon.finalized one
+// checkpoint per finalized epoch.
+const BeaconSubjectSuffix = `.beacon`
+const BeaconFinalizedSuffix = `.finalized`
+
+// BeaconPollInterval is the slot time. Without events the feeder still looks
+// once a slot.
+const BeaconPollInterval = 12 * time.Second
+
+// MaxBeaconBackfill is how many slots the beacon feeder catches up after an
+// outage: two epochs. Older slots are left out, the way MAX_BACKFILL_BLOCKS
+// leaves out old blocks.
+const MaxBeaconBackfill = 2 * beacon.SlotsPerEpoch
+
+// BeaconSource reads the consensus layer; beacon.Client is the real one.
+type BeaconSource interface {
+	GetHeader(ctx context.Context, blockID string) (*beacon.Header, error)
+	GetBlock(ctx context.Context, blockID string) (*beacon.SignedBlock, error)
+	GetFinalityCheckpoints(ctx context.Context, stateID string) (*beacon.FinalityCheckpoints, error)
+}
+
+// BeaconEvents tells when the consensus layer moved, like HeadSource does for
+// the execution layer.
+type BeaconEvents interface {
+	Wakeups(ctx context.Context) <-chan struct{}
+}
+
+// RunBeacon follows the consensus layer next to the blocks: every beacon block
+// to <topic>.beacon with the slashings, voluntary exits and withdrawals it
+// carries, and every newly finalized checkpoint to <topic>.beacon.finalized.
+// With a non-nil events it looks as soon as a head or a checkpoint is
+// announced, otherwise once a slot.
+func (w *Feeder) RunBeacon(ctx context.Context, g *errgroup.Group, src BeaconSource, events BeaconEvents) {
+	subject := w.topic + BeaconSubjectSuffix
+
+	g.Go(func() error {
+		ticker := time.NewTicker(BeaconPollInterval)
+		defer ticker.Stop()
+
+		var wakeups <-chan struct{}
+		if events != nil {
+			wakeups = events.Wakeups(ctx)
+		}
+
+		lastSlot := int64(-1)
+		lastEpoch := int64(-1)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case _, ok := <-wakeups:
+				if !ok {
+					wakeups = nil
+					continue
+				}
+			case <-ticker.C:
+			}
+
+			var err error
+			if lastSlot, err = w.followBeacon(ctx, src, subject, lastSlot); err != nil {
+				w.metricsStore.BeaconSlotsPublished.With(prometheus.Labels{metrics.Status: metrics.StatusFail}).Inc()
+				w.log.Error(fmt.Sprintf("beacon: %v", err))
+			}
+
+			if lastEpoch, err = w.followBeaconFinality(ctx, src, subject+BeaconFinalizedSuffix, lastEpoch); err != nil {
+				w.log.Error(fmt.Sprintf("beacon finality: %v", err))
+			}
+		}
+	})
+}
+
+// followBeacon publishes the blocks of the slots after lastSlot up to the head
+// and returns the last slot it got through. The first call starts at the head.
+func (w *Feeder) followBeacon(ctx context.Context, src BeaconSource, subject string, lastSlot int64) (int64, error) {
+	// Standby instances get the blocks through the mirror. A new leader
+	// starts at the head, like a fresh start.
+	if !w.isLeader() {
+		return -1, nil
+	}
+
+	head, err := src.GetHeader(ctx, beacon.BlockHead)
+	if err != nil {
+		return lastSlot, fmt.Errorf("get head: %w", err)
+	}
+
+	headSlot := int64(head.Header.Message.Slot)
+	if headSlot <= lastSlot {
+		return lastSlot, nil
+	}
+
+	from := lastSlot + 1
+	if lastSlot == -1 {
+		from = headSlot
+	}
+
+	if skipped := headSlot - from + 1 - MaxBeaconBackfill; skipped > 0 {
+		w.log.Warn("Too far behind the beacon head, skipping slots",
+			slog.Int64("from", from),
+			slog.Int64("skipped", skipped),
+		)
+		from += skipped
+	}
+
+	for slot := from; slot <= headSlot; slot++ {
+		root := head.Root
+		if slot != headSlot {
+			header, headerErr := src.GetHeader(ctx, strconv.FormatInt(slot, 10))
+			if errors.Is(headerErr, beacon.ErrNoBlock) {
+				w.metricsStore.BeaconMissedSlots.Inc()
+				lastSlot = slot
+				continue
+			}
+
+			if headerErr != nil {
+				return lastSlot, fmt.Errorf("get header of slot %d: %w", slot, headerErr)
+			}
+
+			root = header.Root
+		}
+
+		block, blockErr := src.GetBlock(ctx, root)
+		if blockErr != nil {
+			return lastSlot, fmt.Errorf("get block %s of slot %d: %w", root, slot, blockErr)
+		}
+
+		dto := buildBeaconBlockDto(root, &block.Message)
+
+		if publishErr := w.publishCompressed(subject, "beacon-"+root, dto); publishErr != nil {
+			if !isUnpublishable(publishErr) {
+				return lastSlot, publishErr
+			}
+
+			w.metricsStore.BeaconSlotsPublished.With(prometheus.Labels{metrics.Status: metrics.StatusFail}).Inc()
+			w.log.Error("Skipping unpublishable beacon block",
+				slog.Int64("slot", slot),
+				slog.String("reason", metrics.ReasonMaxPayload),
+				slog.String("error", publishErr.Error()),
+			)
+
+			lastSlot = slot
+			continue
+		}
+
+		w.metricsStore.BeaconSlotsPublished.With(prometheus.Labels{metrics.Status: metrics.StatusOk}).Inc()
+		w.metricsStore.BeaconLastSlot.Set(float64(slot))
+
+		lastSlot = slot
+	}
+
+	return lastSlot, nil
+}
+
+// followBeaconFinality publishes the finalized checkpoint once its epoch moves
+// past lastEpoch and returns the epoch it is at.
+func (w *Feeder) followBeaconFinality(ctx context.Context, src BeaconSource, subject string, lastEpoch int64) (int64, error) {
+	if !w.isLeader() {
+		return -1, nil
+	}
+
+	checkpoints, err := src.GetFinalityCheckpoints(ctx, beacon.StateHead)
+	if err != nil {
+		return lastEpoch, fmt.Errorf("get finality checkpoints: %w", err)
+	}
+
+	finalized := checkpoints.Finalized
+	if int64(finalized.Epoch) <= lastEpoch {
+		return lastEpoch, nil
+	}
+
+	dto := databus.BeaconCheckpointDtoJson{
+		Epoch: int(finalized.Epoch),
+		Root:  finalized.Root,
+		Slot:  int(finalized.Epoch) * beacon.SlotsPerEpoch,
+	}
+
+	// By epoch, not root: after empty epochs two checkpoints share a root.
+	if publishErr := w.publishCompressed(subject, fmt.Sprintf("beacon-finalized-%d", dto.Epoch), dto); publishErr != nil {
+		return lastEpoch, publishErr
+	}
+
+	w.metricsStore.BeaconFinalizedEpoch.Set(float64(dto.Epoch))
+	w.log.Info(fmt.Sprintf("beacon: epoch %d is finalized", dto.Epoch))
+
+	return int64(finalized.Epoch), nil
+}
+
+func buildBeaconBlockDto(root string, block *beacon.Block) databus.BeaconBlockDtoJson {
+	dto := databus.BeaconBlockDtoJson{
+		Slot:              int(block.Slot),
+		Epoch:             int(block.Slot) / beacon.SlotsPerEpoch,
+		Root:              root,
+		ParentRoot:        block.ParentRoot,
+		StateRoot:         block.StateRoot,
+		ProposerIndex:     int(block.ProposerIndex),
+		ProposerSlashings: make([]databus.BeaconBlockDtoJsonProposerSlashingsElem, 0, len(block.Body.ProposerSlashings)),
+		AttesterSlashings: make([]databus.BeaconBlockDtoJsonAttesterSlashingsElem, 0, len(block.Body.AttesterSlashings)),
+		VoluntaryExits:    make([]databus.BeaconBlockDtoJsonVoluntaryExitsElem, 0, len(block.Body.VoluntaryExits)),
+		Withdrawals:       []databus.BeaconBlockDtoJsonWithdrawalsElem{},
+	}
+
+	if payload := block.Body.ExecutionPayload; payload != nil {
+		dto.ExecutionBlockNumber = int(payload.BlockNumber)
+		dto.ExecutionBlockHash = payload.BlockHash
+
+		dto.Withdrawals = make([]databus.BeaconBlockDtoJsonWithdrawalsElem, 0, len(payload.Withdrawals))
+		for _, wd := range payload.Withdrawals {
+			dto.Withdrawals = append(dto.Withdrawals, databus.BeaconBlockDtoJsonWithdrawalsElem{
+				Index:          int(wd.Index),
+				ValidatorIndex: int(wd.ValidatorIndex),
+				Address:        wd.Address,
+				Amount:         int(wd.Amount),
+			})
+		}
+	}
+
+	for _, s := range block.Body.ProposerSlashings {
+		dto.ProposerSlashings = append(dto.ProposerSlashings, databus.BeaconBlockDtoJsonProposerSlashingsElem{
+			ProposerIndex: int(s.SignedHeader1.Message.ProposerIndex),
+			Slot:          int(s.SignedHeader1.Message.Slot),
+		})
+	}
+
+	for i := range block.Body.AttesterSlashings {
+		s := &block.Body.AttesterSlashings[i]
+
+		slashed := s.SlashedIndices()
+		indices := make([]int, 0, len(slashed))
+		for _, v := range slashed {
+			indices = append(indices, int(v))
+		}
+
+		dto.AttesterSlashings = append(dto.AttesterSlashings, databus.BeaconBlockDtoJsonAttesterSlashingsElem{
+			ValidatorIndices: indices,
+			TargetEpoch:      int(s.Attestation1.Data.Target.Epoch),
+		})
+	}
+
+	for _, e := range block.Body.VoluntaryExits {
+		dto.VoluntaryExits = append(dto.VoluntaryExits, databus.BeaconBlockDtoJsonVoluntaryExitsElem{
+			ValidatorIndex: int(e.Message.ValidatorIndex),
+			Epoch:          int(e.Message.Epoch),
+		})
+	}
+
+	return dto
+}
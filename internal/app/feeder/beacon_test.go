@@ -0,0 +1,115 @@
+package feeder
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/lidofinance/onchain-mon/generated/databus"
+	"github.com/lidofinance/onchain-mon/internal/pkg/beacon"
+)
+
+// A beacon chain with a block at every slot up to head but the missed ones.
+type fakeBeacon struct {
+	head      int64
+	missed    map[int64]bool
+	finalized int64
+}
+
+func (b *fakeBeacon) GetHeader(_ context.Context, blockID string) (*beacon.Header, error) {
+	slot := b.head
+	if blockID != beacon.BlockHead {
+		slot, _ = strconv.ParseInt(blockID, 10, 64)
+	}
+
+	if b.missed[slot] {
+		return nil, beacon.ErrNoBlock
+	}
+
+	h := &beacon.Header{Root: "0xroot" + strconv.FormatInt(slot, 10)}
+	h.Header.Message.Slot = beacon.Quantity(slot)
+
+	return h, nil
+}
+
+func (b *fakeBeacon) GetBlock(_ context.Context, blockID string) (*beacon.SignedBlock, error) {
+	slot, _ := strconv.ParseInt(blockID[len("0xroot"):], 10, 64)
+
+	block := &beacon.SignedBlock{}
+	block.Message.Slot = beacon.Quantity(slot)
+	block.Message.Body.VoluntaryExits = make([]beacon.SignedExit, 1)
+	block.Message.Body.VoluntaryExits[0].Message.ValidatorIndex = 11
+
+	return block, nil
+}
+
+func (b *fakeBeacon) GetFinalityCheckpoints(context.Context, string) (*beacon.FinalityCheckpoints, error) {
+	return &beacon.FinalityCheckpoints{Finalized: beacon.Checkpoint{Epoch: beacon.Quantity(b.finalized), Root: "0xcp"}}, nil
+}
+
+func Test_beacon_blocks_follow_the_head_past_missed_slots(t *testing.T) {
+	src := &fakeBeacon{head: 100, missed: map[int64]bool{102: true}}
+
+	js := &recordingJetStream{}
+	f := newTestFeeder(&canonicalChain{})
+	f.js = js
+
+	// The first poll starts at the head.
+	last, err := f.followBeacon(context.Background(), src, "blocks.test.beacon", -1)
+	if err != nil || last != 100 {
+		t.Fatalf("got %d, %v; want 100", last, err)
+	}
+
+	src.head = 104
+	if last, err = f.followBeacon(context.Background(), src, "blocks.test.beacon", last); err != nil || last != 104 {
+		t.Fatalf("got %d, %v; want 104", last, err)
+	}
+
+	var slots []int
+	for _, m := range js.msgs {
+		if m.subject != "blocks.test.beacon" {
+			t.Fatalf("block went to %s", m.subject)
+		}
+
+		var dto databus.BeaconBlockDtoJson
+		if err := json.Unmarshal(m.payload, &dto); err != nil {
+			t.Fatalf("not a beacon block: %v", err)
+		}
+		if len(dto.VoluntaryExits) != 1 || dto.VoluntaryExits[0].ValidatorIndex != 11 {
+			t.Errorf("slot %d lost its exit: %+v", dto.Slot, dto.VoluntaryExits)
+		}
+
+		slots = append(slots, dto.Slot)
+	}
+
+	if want := []int{100, 101, 103, 104}; !slices.Equal(slots, want) {
+		t.Errorf("published slots %v, want %v", slots, want)
+	}
+}
+
+func Test_finalized_checkpoint_is_published_once_per_epoch(t *testing.T) {
+	src := &fakeBeacon{finalized: 10}
+
+	js := &recordingJetStream{}
+	f := newTestFeeder(&canonicalChain{})
+	f.js = js
+
+	epoch := int64(-1)
+	for range 2 {
+		var err error
+		if epoch, err = f.followBeaconFinality(context.Background(), src, "blocks.test.beacon.finalized", epoch); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if epoch != 10 || len(js.msgs) != 1 {
+		t.Fatalf("got epoch %d after %d publishes, want 10 after 1", epoch, len(js.msgs))
+	}
+
+	var dto databus.BeaconCheckpointDtoJson
+	if err := json.Unmarshal(js.msgs[0].payload, &dto); err != nil || dto.Slot != 320 {
+		t.Errorf("got %+v, %v; want slot 320", dto, err)
+	}
+}
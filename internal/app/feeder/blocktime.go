@@ -0,0 +1,88 @@
+package feeder
+
+import (
+	"time"
+)
+
+// BlockTimeSamples is how many recent blocks the interval is learned from.
+// Block timestamps have a one-second resolution, so on chains making several
+// blocks a second only a span of many blocks tells their pace.
+const BlockTimeSamples = 64
+
+// MinBlockTimeSamples is how many blocks have to be seen before the learned
+// interval replaces the configured one.
+const MinBlockTimeSamples = 8
+
+// Bounds of the learned interval, so a few odd timestamps cannot make the
+// feeder hammer the RPC or fall asleep.
+const MinBlockTime = 100 * time.Millisecond
+const MaxBlockTime = time.Minute
+
+// MinPollInterval is the fastest the feeder asks for a block, however fast the
+// chain or however far behind the feeder is.
+const MinPollInterval = 100 * time.Millisecond
+
+type blockSample struct {
+	number    int64
+	timestamp int64
+}
+
+// blockTimeEstimator learns the interval between blocks from the timestamps of
+// the blocks the feeder publishes. The zero value is ready to use.
+type blockTimeEstimator struct {
+	// samples are ordered by number, oldest first.
+	samples []blockSample
+}
+
+func (e *blockTimeEstimator) observe(number, timestamp int64) {
+	// A reorg or a resume goes back: what was seen above that is no longer
+	// the chain.
+	for len(e.samples) > 0 && e.samples[len(e.samples)-1].number >= number {
+		e.samples = e.samples[:len(e.samples)-1]
+	}
+
+	if len(e.samples) == BlockTimeSamples {
+		e.samples = append(e.samples[:0], e.samples[1:]...)
+	}
+
+	e.samples = append(e.samples, blockSample{number: number, timestamp: timestamp})
+}
+
+// estimate returns the mean interval over the recent blocks, missed slots
+// included, or prior while they are too few to tell.
+func (e *blockTimeEstimator) estimate(prior time.Duration) time.Duration {
+	if len(e.samples) < MinBlockTimeSamples {
+		return prior
+	}
+
+	// Measure between the first blocks of two seconds: timestamps are
+	// truncated to the second, so any other pair is off by up to a second.
+	// The oldest block may be the last of its second, so start from the next.
+	first := e.firstOf(e.samples[0].timestamp + 1)
+	last := e.firstOf(e.samples[len(e.samples)-1].timestamp)
+	if first >= last {
+		return prior
+	}
+
+	span := time.Duration(e.samples[last].timestamp-e.samples[first].timestamp) * time.Second
+	interval := span / time.Duration(e.samples[last].number-e.samples[first].number)
+
+	return min(max(interval, MinBlockTime), MaxBlockTime)
+}
+
+// firstOf returns the index of the oldest sample stamped timestamp or later.
+func (e *blockTimeEstimator) firstOf(timestamp int64) int {
+	for i, sample := range e.samples {
+		if sample.timestamp >= timestamp {
+			return i
+		}
+	}
+
+	return len(e.samples)
+}
+
+// pollDelay is a quarter of the block interval, at least MinPollInterval and
+// at most upper.
+func pollDelay(interval, upper time.Duration) time.Duration {
+	return min(max(interval/4, MinPollInterval), upper)
+}
@@ -0,0 +1,94 @@
+package feeder
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/lidofinance/onchain-mon/internal/pkg/chain/entity"
+)
+
+func Test_block_time_is_learned_from_recent_blocks(t *testing.T) {
+	const genesis = int64(1_700_000_000)
+
+	tests := []struct {
+		name   string
+		blocks func(e *blockTimeEstimator)
+		want   time.Duration
+	}{
+		{"too_few_blocks_keep_the_prior", func(e *blockTimeEstimator) {
+			for n := int64(0); n < MinBlockTimeSamples-1; n++ {
+				e.observe(n, genesis+2*n)
+			}
+		}, EtaNextBlock},
+		{"l1_with_a_missed_slot", func(e *blockTimeEstimator) {
+			// Block 8 came a slot late: from block 1 on, 8 intervals over 9 slots.
+			for n := int64(0); n < 10; n++ {
+				ts := genesis + 12*n
+				if n >= 8 {
+					ts += 12
+				}
+				e.observe(n, ts)
+			}
+		}, 108 * time.Second / 8},
+		{"sub_second_blocks_share_timestamps", func(e *blockTimeEstimator) {
+			// Four blocks a second, all four stamped with the same second.
+			for n := int64(0); n < BlockTimeSamples+20; n++ {
+				e.observe(n, genesis+n/4)
+			}
+		}, 250 * time.Millisecond},
+		{"one_timestamp_tells_nothing", func(e *blockTimeEstimator) {
+			for n := int64(0); n < MinBlockTimeSamples; n++ {
+				e.observe(n, genesis)
+			}
+		}, EtaNextBlock},
+		{"going_back_forgets_the_blocks_above", func(e *blockTimeEstimator) {
+			for n := int64(0); n < 20; n++ {
+				e.observe(n, genesis+12*n)
+			}
+			// A reorg republishes from 5 on, two seconds apart this time.
+			for n := int64(5); n < 20; n++ {
+				e.observe(n, genesis+12*4+2*(n-4))
+			}
+		}, (36 + 30) * time.Second / 18},
+		{"stalled_chain_is_capped", func(e *blockTimeEstimator) {
+			for n := int64(0); n < MinBlockTimeSamples; n++ {
+				e.observe(n, genesis+600*n)
+			}
+		}, MaxBlockTime},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var e blockTimeEstimator
+			tt.blocks(&e)
+
+			if got := e.estimate(EtaNextBlock); got != tt.want {
+				t.Errorf("estimate() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_missed_slots_do_not_speed_up_polling(t *testing.T) {
+	f := newTestFeeder(&canonicalChain{})
+	f.blockTime = time.Second
+
+	// The chain makes a block every 12 seconds whatever the config says.
+	start := time.Now().Unix() - 12*20
+	for n := int64(0); n <= 20; n++ {
+		f.blockTimes.observe(n, start+12*n)
+	}
+
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+
+	fresh := &entity.EthBlock{Timestamp: "0x" + strconv.FormatInt(time.Now().Unix(), 16)}
+	if delay := f.updateTickerAfterBlock(timer, fresh); delay < 11*time.Second {
+		t.Errorf("a fresh block waits %s, want the learned 12s slot", delay)
+	}
+
+	if got := f.blockInterval(); got != EtaNextBlock {
+		t.Errorf("blockInterval() = %s, want %s", got, EtaNextBlock)
+	}
+}
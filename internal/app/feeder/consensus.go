@@ -0,0 +1,130 @@
+package feeder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/lidofinance/onchain-mon/internal/connectors/metrics"
+	"github.com/lidofinance/onchain-mon/internal/pkg/chain/entity"
+)
+
+var ErrNoConsensus = errors.New("rpc endpoints do not agree on the block")
+
+// ConsensusStallWarnAttempts is how many attempts in a row at one height go
+// by before the stall is logged as a warning, and again every as many after.
+const ConsensusStallWarnAttempts = 10
+
+// consensusStalls counts, per stream, the attempts in a row that failed to
+// confirm the block at one height. The head and the finality streams confirm
+// blocks each in their own goroutine.
+type consensusStalls struct {
+	mu       sync.Mutex
+	height   map[string]int64
+	attempts map[string]int
+}
+
+// fail counts a failed attempt at height on stream and returns how many there
+// were in a row.
+func (s *consensusStalls) fail(stream string, height int64) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.height == nil {
+		s.height = make(map[string]int64)
+		s.attempts = make(map[string]int)
+	}
+
+	if s.height[stream] != height {
+		s.height[stream] = height
+		s.attempts[stream] = 0
+	}
+
+	s.attempts[stream]++
+
+	return s.attempts[stream]
+}
+
+func (s *consensusStalls) clear(stream string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.height, stream)
+	delete(s.attempts, stream)
+}
+
+// confirmBlock makes sure the block about to be published is the one at least
+// w.consensus endpoints serve, same hash and same number of transactions, and
+// that every transaction came with its receipt. Endpoints that do not answer
+// abstain; the ones answering differently are counted and logged. stream is
+// chain.TagLatest for the head, or the finality tag.
+func (w *Feeder) confirmBlock(ctx context.Context, stream string, block *entity.EthBlock, receipts int) error {
+	if w.consensus == 0 {
+		return nil
+	}
+
+	number := block.GetNumber()
+	txCount := len(block.Transactions)
+
+	if receipts != txCount {
+		return w.consensusFailed(stream, number, fmt.Errorf("%w: block %d has %d transactions but %d receipts", ErrNoConsensus, number, txCount, receipts))
+	}
+
+	agree := 0
+	for _, vote := range w.chainSrv.BlockVotes(ctx, number) {
+		if vote.Err != nil {
+			continue
+		}
+
+		if vote.Hash == block.Hash && vote.TxCount == txCount {
+			agree++
+			continue
+		}
+
+		w.metricsStore.ConsensusDissent.With(prometheus.Labels{metrics.Endpoint: vote.Endpoint}).Inc()
+		w.log.Warn("RPC endpoints disagree on a block",
+			slog.Int64("blockNumber", number),
+			slog.String("endpoint", vote.Endpoint),
+			slog.String("hash", vote.Hash),
+			slog.Int("txCount", vote.TxCount),
+			slog.String("wantHash", block.Hash),
+			slog.Int("wantTxCount", txCount),
+		)
+	}
+
+	if agree < w.consensus {
+		return w.consensusFailed(stream, number, fmt.Errorf("%w: %d endpoints confirmed block %d %s, want %d", ErrNoConsensus, agree, number, block.Hash, w.consensus))
+	}
+
+	w.metricsStore.BlockConsensus.With(prometheus.Labels{metrics.Status: metrics.StatusOk}).Inc()
+	w.metricsStore.ConsensusStallAttempts.With(prometheus.Labels{metrics.Stream: stream}).Set(0)
+	w.stalls.clear(stream)
+
+	return nil
+}
+
+// consensusFailed counts a failed attempt at number on stream and returns
+// err. A stream does not move past a block the endpoints never agree on, so
+// attempts in a row at one height show as a stall, and a long one is logged
+// as a warning.
+func (w *Feeder) consensusFailed(stream string, number int64, err error) error {
+	w.metricsStore.BlockConsensus.With(prometheus.Labels{metrics.Status: metrics.StatusFail}).Inc()
+
+	attempts := w.stalls.fail(stream, number)
+	w.metricsStore.ConsensusStallAttempts.With(prometheus.Labels{metrics.Stream: stream}).Set(float64(attempts))
+
+	if attempts%ConsensusStallWarnAttempts == 0 {
+		w.log.Warn("Stream is stalled: RPC endpoints keep disagreeing on a block",
+			slog.String("stream", stream),
+			slog.Int64("blockNumber", number),
+			slog.Int("attempts", attempts),
+			slog.String("error", err.Error()),
+		)
+	}
+
+	return err
+}
@@ -0,0 +1,113 @@
+package feeder
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/lidofinance/onchain-mon/internal/pkg/chain"
+	"github.com/lidofinance/onchain-mon/internal/pkg/chain/entity"
+)
+
+// Answers every vote with the given ballots.
+type votingChain struct {
+	canonicalChain
+	votes []chain.BlockVote
+}
+
+func (c *votingChain) BlockVotes(_ context.Context, _ int64) []chain.BlockVote {
+	return c.votes
+}
+
+func Test_block_needs_consensus_before_publishing(t *testing.T) {
+	block := &entity.EthBlock{Number: "0x64", Hash: "0xabc", Transactions: []string{"0x1", "0x2"}}
+
+	agree := chain.BlockVote{Endpoint: "a", Hash: "0xabc", TxCount: 2}
+	stale := chain.BlockVote{Endpoint: "b", Hash: "0xold", TxCount: 2}
+	short := chain.BlockVote{Endpoint: "c", Hash: "0xabc", TxCount: 1}
+	lagging := chain.BlockVote{Endpoint: "d", Err: chain.ErrEmptyResponse}
+
+	tests := []struct {
+		name     string
+		votes    []chain.BlockVote
+		receipts int
+		wantErr  bool
+	}{
+		{"two_of_three_agree", []chain.BlockVote{agree, agree, stale}, 2, false},
+		{"lagging_provider_abstains", []chain.BlockVote{agree, lagging, agree}, 2, false},
+		{"wrong_hash_is_not_a_vote", []chain.BlockVote{agree, stale, lagging}, 2, true},
+		{"tx_count_must_match", []chain.BlockVote{agree, short}, 2, true},
+		{"missing_receipts", []chain.BlockVote{agree, agree}, 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newTestFeeder(&votingChain{votes: tt.votes})
+			f.consensus = 2
+
+			err := f.confirmBlock(context.Background(), chain.TagLatest, block, tt.receipts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error: %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrNoConsensus) {
+				t.Errorf("got %v, want ErrNoConsensus", err)
+			}
+		})
+	}
+}
+
+func Test_consensus_stall_is_counted_per_height(t *testing.T) {
+	c := &votingChain{votes: []chain.BlockVote{{Endpoint: "a", Hash: "0xold", TxCount: 0}}}
+	f := newTestFeeder(c)
+	f.consensus = 1
+
+	head := f.metricsStore.ConsensusStallAttempts.WithLabelValues(chain.TagLatest)
+	finalized := f.metricsStore.ConsensusStallAttempts.WithLabelValues(chain.TagFinalized)
+
+	block := &entity.EthBlock{Number: "0x64", Hash: "0xabc"}
+	for range 3 {
+		_ = f.confirmBlock(context.Background(), chain.TagLatest, block, 0)
+	}
+	// Another stream stalling elsewhere does not reset the head's count.
+	_ = f.confirmBlock(context.Background(), chain.TagFinalized, &entity.EthBlock{Number: "0x10", Hash: "0x10"}, 0)
+
+	if got := testutil.ToFloat64(head); got != 3 {
+		t.Errorf("stalled for %v attempts, want 3", got)
+	}
+	if got := testutil.ToFloat64(finalized); got != 1 {
+		t.Errorf("finalized stalled for %v attempts, want 1", got)
+	}
+
+	// The next height starts over.
+	_ = f.confirmBlock(context.Background(), chain.TagLatest, &entity.EthBlock{Number: "0x65", Hash: "0xdef"}, 0)
+	if got := testutil.ToFloat64(head); got != 1 {
+		t.Errorf("stalled for %v attempts at a new height, want 1", got)
+	}
+
+	c.votes[0].Hash = "0xdef"
+	if err := f.confirmBlock(context.Background(), chain.TagLatest, &entity.EthBlock{Number: "0x65", Hash: "0xdef"}, 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := testutil.ToFloat64(head); got != 0 {
+		t.Errorf("a confirmed block must clear the stall, got %v", got)
+	}
+}
+
+func Test_recovery_stops_at_an_unconfirmed_block(t *testing.T) {
+	// Nobody confirms anything: not a single block may go out.
+	c := &votingChain{votes: []chain.BlockVote{{Endpoint: "a", Hash: "0xother"}}}
+
+	js := &recordingJetStream{}
+	f := newTestFeeder(c)
+	f.js = js
+	f.consensus = 1
+
+	if _, err := f.recoverBlockRange(context.Background(), 100, 110); !errors.Is(err, ErrNoConsensus) {
+		t.Fatalf("got %v, want ErrNoConsensus", err)
+	}
+	if n := len(js.subjects()); n != 0 {
+		t.Errorf("published %d unconfirmed blocks", n)
+	}
+}
@@ -0,0 +1,241 @@
+package feeder
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/lidofinance/onchain-mon/generated/databus"
+	"github.com/lidofinance/onchain-mon/internal/pkg/chain"
+)
+
+// CursorSaveTimeout bounds one cursor write. The cursor is saved after every
+// block, so a slow KV must not hold the loop up.
+const CursorSaveTimeout = 2 * time.Second
+
+// Cursor is the last block the feeder got past, published or skipped.
+type Cursor struct {
+	Number int64  `json:"number"`
+	Hash   string `json:"hash"`
+}
+
+type CursorStore interface {
+	// Load returns nil without an error when nothing was saved yet.
+	Load(ctx context.Context) (*Cursor, error)
+	Save(ctx context.Context, c Cursor) error
+}
+
+type kvCursor struct {
+	kv  jetstream.KeyValue
+	key string
+}
+
+// NewKVCursor keeps the cursor in a JetStream KV bucket, one key per block
+// topic, so feeders of different chains can share the bucket.
+func NewKVCursor(ctx context.Context, js jetstream.JetStream, bucket, topic string) (*kvCursor, error) {
+	kv, err := js.CreateOrUpdateKeyValue(ctx, jetstream.KeyValueConfig{
+		Bucket:      bucket,
+		Description: "Last block published by the feeder, per block topic",
+		History:     1,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not open kv bucket %s: %w", bucket, err)
+	}
+
+	return &kvCursor{kv: kv, key: topic}, nil
+}
+
+func (c *kvCursor) Load(ctx context.Context) (*Cursor, error) {
+	entry, err := c.kv.Get(ctx, c.key)
+	if err != nil {
+		if errors.Is(err, jetstream.ErrKeyNotFound) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("could not read cursor %s: %w", c.key, err)
+	}
+
+	var cursor Cursor
+	if err := json.Unmarshal(entry.Value(), &cursor); err != nil {
+		return nil, fmt.Errorf("could not unmarshal cursor %s: %w", c.key, err)
+	}
+
+	return &cursor, nil
+}
+
+func (c *kvCursor) Save(ctx context.Context, cursor Cursor) error {
+	payload, err := json.Marshal(cursor)
+	if err != nil {
+		return fmt.Errorf("could not marshal cursor: %w", err)
+	}
+
+	if _, err := c.kv.Put(ctx, c.key, payload); err != nil {
+		return fmt.Errorf("could not write cursor %s: %w", c.key, err)
+	}
+
+	return nil
+}
+
+type redisCursor struct {
+	rdb *redis.Client
+	key string
+}
+
+// NewRedisCursor keeps the cursor in Redis. Feeders that take turns leading
+// share it, so a new leader resumes where the old one stopped.
+func NewRedisCursor(rdb *redis.Client, key string) *redisCursor {
+	return &redisCursor{rdb: rdb, key: key}
+}
+
+func (c *redisCursor) Load(ctx context.Context) (*Cursor, error) {
+	payload, err := c.rdb.Get(ctx, c.key).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("could not read cursor %s: %w", c.key, err)
+	}
+
+	var cursor Cursor
+	if err := json.Unmarshal(payload, &cursor); err != nil {
+		return nil, fmt.Errorf("could not unmarshal cursor %s: %w", c.key, err)
+	}
+
+	return &cursor, nil
+}
+
+func (c *redisCursor) Save(ctx context.Context, cursor Cursor) error {
+	payload, err := json.Marshal(cursor)
+	if err != nil {
+		return fmt.Errorf("could not marshal cursor: %w", err)
+	}
+
+	if err := c.rdb.Set(ctx, c.key, payload, 0).Err(); err != nil {
+		return fmt.Errorf("could not write cursor %s: %w", c.key, err)
+	}
+
+	return nil
+}
+
+// saveCursor moves the cursor past dto. A failed write is only logged: the
+// worst outcome is re-publishing a few blocks after a restart, and JetStream
+// dedupes those by hash.
+func (w *Feeder) saveCursor(dto *databus.BlockDtoJson) {
+	if w.cursor == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), CursorSaveTimeout)
+	defer cancel()
+
+	if err := w.cursor.Save(ctx, Cursor{Number: int64(dto.Number), Hash: dto.Hash}); err != nil {
+		w.metricsStore.CursorErrors.Inc()
+		w.log.Error("Could not save cursor", slog.Int("blockNumber", dto.Number), slog.String("error", err.Error()))
+	}
+}
+
+// resume picks up where the previous run stopped: it backfills the blocks
+// mined since the saved cursor, at most maxBackfill of them, and returns the
+// height to continue from. -1 means there is nothing to resume and the loop
+// starts at latest as it always did.
+//
+// On error the caller tries again later. Whatever got published meanwhile has
+// already moved the cursor, so the retry does not start over.
+func (w *Feeder) resume(ctx context.Context) (int64, error) {
+	if w.cursor == nil {
+		return -1, nil
+	}
+
+	// A leader taking over again must not compare against blocks from its
+	// previous term.
+	w.window = blockWindow{}
+
+	saved, err := w.cursor.Load(ctx)
+	if err != nil {
+		return -1, fmt.Errorf("load cursor: %w", err)
+	}
+
+	if saved == nil {
+		w.log.Info("No saved cursor, starting from the latest block")
+		return -1, nil
+	}
+
+	latest, err := w.chainSrv.GetLatestBlock(ctx)
+	if err != nil {
+		return -1, fmt.Errorf("get latest block: %w", err)
+	}
+
+	if latest.Result == nil {
+		return -1, fmt.Errorf("get latest block: %w", chain.ErrEmptyResponse)
+	}
+
+	latestNumber := latest.Result.GetNumber()
+	if latestNumber <= saved.Number {
+		w.window.push(publishedBlock{number: saved.Number, hash: saved.Hash})
+		w.log.Info(fmt.Sprintf("Resuming after block %d, nothing to backfill", saved.Number))
+
+		return saved.Number, nil
+	}
+
+	from := saved.Number
+	skipped := int64(0)
+	if gap := latestNumber - saved.Number; gap > w.maxBackfill {
+		from = latestNumber - w.maxBackfill
+		skipped = gap - w.maxBackfill
+
+		w.log.Warn("Feeder was down longer than the backfill depth allows, skipping the oldest blocks",
+			slog.Int64("cursor", saved.Number),
+			slog.Int64("latest", latestNumber),
+			slog.Int64("maxBackfill", w.maxBackfill),
+			slog.Int64("skipped", skipped),
+		)
+	}
+
+	if from == saved.Number {
+		if err := w.checkResumeReorg(ctx, saved); err != nil {
+			return -1, err
+		}
+	}
+
+	w.log.Info(fmt.Sprintf("Resuming after block %d, backfilling %d..%d", saved.Number, from+1, latestNumber))
+
+	recovered, err := w.recoverMissedBlocks(ctx, from)
+	if err != nil {
+		return -1, fmt.Errorf("backfill from %d: %w", from+1, err)
+	}
+
+	// Counted once the backfill went through, so a retried resume does not
+	// count the same blocks twice.
+	w.metricsStore.BackfillSkippedBlocks.Add(float64(skipped))
+
+	return recovered.GetNumber(), nil
+}
+
+// checkResumeReorg catches a reorg that orphaned the last block of the previous
+// run while the feeder was down. The backfill does not compare parent hashes,
+// so the successor of the saved block is checked here once.
+func (w *Feeder) checkResumeReorg(ctx context.Context, saved *Cursor) error {
+	w.window.push(publishedBlock{number: saved.Number, hash: saved.Hash})
+
+	next, err := w.chainSrv.FetchBlockByNumber(ctx, saved.Number+1)
+	if err != nil {
+		return fmt.Errorf("fetch block %d: %w", saved.Number+1, err)
+	}
+
+	if next.Result == nil || !w.isReorg(next.Result) {
+		return nil
+	}
+
+	if _, err := w.handleReorg(ctx, next.Result); err != nil {
+		return fmt.Errorf("reorg while the feeder was down: %w", err)
+	}
+
+	return nil
+}
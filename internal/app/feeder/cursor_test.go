@@ -0,0 +1,117 @@
+package feeder
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/lidofinance/onchain-mon/internal/pkg/chain/entity"
+)
+
+type memCursor struct {
+	saved *Cursor
+}
+
+func (m *memCursor) Load(_ context.Context) (*Cursor, error) {
+	return m.saved, nil
+}
+
+func (m *memCursor) Save(_ context.Context, c Cursor) error {
+	m.saved = &c
+	return nil
+}
+
+// A canonical chain whose head is set by the test.
+type headChain struct {
+	canonicalChain
+	latest int64
+}
+
+func (c *headChain) GetLatestBlock(_ context.Context) (*entity.RpcResponse[entity.EthBlock], error) {
+	b := c.block(c.latest)
+	return &entity.RpcResponse[entity.EthBlock]{Result: &b}, nil
+}
+
+func newResumingFeeder(c ChainSrv, cursor *memCursor, maxBackfill int64) (*Feeder, *recordingJetStream) {
+	js := &recordingJetStream{}
+
+	cfg := testConfig(c)
+	cfg.Cursor = cursor
+	cfg.MaxBackfill = maxBackfill
+	cfg.JS = js
+	cfg.Topic = "blocks.test"
+
+	return New(cfg), js
+}
+
+func Test_resume_backfills_blocks_mined_while_down(t *testing.T) {
+	cursor := &memCursor{saved: &Cursor{Number: 100, Hash: "0x100"}}
+	f, js := newResumingFeeder(&headChain{latest: 105}, cursor, 1000)
+
+	got, err := f.resume(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != 105 {
+		t.Errorf("resume point: got %d, want 105", got)
+	}
+	if n := len(js.subjects()); n != 5 {
+		t.Errorf("published %d blocks, want 101..105", n)
+	}
+	if cursor.saved.Number != 105 || cursor.saved.Hash != "0x105" {
+		t.Errorf("cursor was not moved along: %+v", cursor.saved)
+	}
+}
+
+func Test_resume_is_bounded_by_max_backfill(t *testing.T) {
+	cursor := &memCursor{saved: &Cursor{Number: 100, Hash: "0x100"}}
+	f, js := newResumingFeeder(&headChain{latest: 130}, cursor, 20)
+
+	skippedBefore := testutil.ToFloat64(f.metricsStore.BackfillSkippedBlocks)
+
+	got, err := f.resume(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != 130 {
+		t.Errorf("resume point: got %d, want 130", got)
+	}
+	if n := len(js.subjects()); n != 20 {
+		t.Errorf("published %d blocks, want the last 20", n)
+	}
+	if v := testutil.ToFloat64(f.metricsStore.BackfillSkippedBlocks) - skippedBefore; v != 10 {
+		t.Errorf("skipped counter grew by %v, want 10", v)
+	}
+}
+
+func Test_resume_without_cursor_starts_at_latest(t *testing.T) {
+	f, js := newResumingFeeder(&headChain{latest: 100}, &memCursor{}, 1000)
+
+	got, err := f.resume(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != -1 || len(js.subjects()) != 0 {
+		t.Errorf("got %d after %d publishes, want -1 and none", got, len(js.subjects()))
+	}
+}
+
+func Test_resume_announces_a_reorg_that_happened_while_down(t *testing.T) {
+	// The saved block 100 lost to the fork whose hashes end in "b".
+	cursor := &memCursor{saved: &Cursor{Number: 100, Hash: "0x100"}}
+	f, js := newResumingFeeder(&headChain{canonicalChain: canonicalChain{suffix: "b"}, latest: 102}, cursor, 1000)
+
+	if _, err := f.resume(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{"blocks.test.reorg", "blocks.test", "blocks.test", "blocks.test"}
+	got := js.subjects()
+	if len(got) != len(want) || got[0] != want[0] {
+		t.Fatalf("published %v, want %v", got, want)
+	}
+	if cursor.saved.Hash != "0x102b" {
+		t.Errorf("cursor should follow the canonical chain, got %+v", cursor.saved)
+	}
+}
@@ -1,18 +1,16 @@
 package feeder
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
 	"strconv"
+	"strings"
 	"time"
 
 	"golang.org/x/sync/errgroup"
 
-	"github.com/klauspost/compress/zstd"
 	"github.com/nats-io/nats.go"
 	"github.com/nats-io/nats.go/jetstream"
 	"github.com/prometheus/client_golang/prometheus"
@@ -21,50 +19,221 @@ import (
 	"github.com/lidofinance/onchain-mon/internal/connectors/metrics"
 	"github.com/lidofinance/onchain-mon/internal/pkg/chain"
 	"github.com/lidofinance/onchain-mon/internal/pkg/chain/entity"
+	"github.com/lidofinance/onchain-mon/internal/pkg/codec"
+	"github.com/lidofinance/onchain-mon/pkg/envelope"
 )
 
 type ChainSrv interface {
 	GetLatestBlock(ctx context.Context) (*entity.RpcResponse[entity.EthBlock], error)
+	GetBlockByTag(ctx context.Context, tag string) (*entity.RpcResponse[entity.EthBlock], error)
 	GetBlockReceipts(ctx context.Context, blockHash string) (*entity.RpcResponse[[]entity.BlockReceipt], error)
+	GetVerifiedReceipts(ctx context.Context, block *entity.EthBlock) (*entity.RpcResponse[[]entity.BlockReceipt], error)
 	FetchReceipts(ctx context.Context, blockHashes []string) (*entity.RpcResponse[[]entity.BlockReceipt], error)
 	FetchBlockByNumber(ctx context.Context, blockNumber int64) (*entity.RpcResponse[entity.EthBlock], error)
 	FetchBlocksInRange(ctx context.Context, blockNumber int64, latestNumber int64) (*entity.RpcResponse[[]entity.EthBlock], error)
+	GetBlockTransactions(ctx context.Context, block *entity.EthBlock) (*entity.RpcResponse[entity.EthFullBlock], error)
+	TraceBlockCalls(ctx context.Context, block *entity.EthBlock) (*entity.RpcResponse[[]entity.TxCallTrace], error)
+	TraceBlock(ctx context.Context, block *entity.EthBlock) (*entity.RpcResponse[[]entity.ParityTrace], error)
+	BlockVotes(ctx context.Context, number int64) []chain.BlockVote
+}
+
+// HeadSource pushes new heads as the node sees them. The feeder uses them
+// only as a wake-up: the block itself is fetched the usual way.
+type HeadSource interface {
+	Heads(ctx context.Context) <-chan entity.EthBlock
 }
 
 type Feeder struct {
-	log          *slog.Logger
-	chainSrv     ChainSrv
+	log         *slog.Logger
+	chainSrv    ChainSrv
+	heads       HeadSource
+	cursor      CursorStore
+	maxBackfill int64
+	leader      Leadership
+	mirror      Mirror
+	// mirrorQueue holds the publishes RunMirror appends to mirror; nil
+	// without a mirror.
+	mirrorQueue chan MirroredMsg
+	consensus   int
+	// stalls counts the failed attempts to confirm the block at one height.
+	stalls consensusStalls
+	// verifyReceipts checks the receipts of every block against it before
+	// publishing, see chain.VerifyReceipts.
+	verifyReceipts bool
+	// fullTransactions adds every transaction with its calldata to the DTO.
+	fullTransactions bool
+	// traceAPI is chain.TraceAPIDebug or chain.TraceAPIParity; empty
+	// publishes no traces.
+	traceAPI string
+	// logSubjects are the LogsBy* partitions every block's logs are also
+	// published by.
+	logSubjects []string
+	// encodings are the codec encodings every payload is published in.
+	encodings []string
+	// compressor zstd-compresses every payload, with a trained dictionary
+	// when it has one.
+	compressor *codec.Compressor
+	// chunkSize is the most a chunk of a payload NATS refuses carries.
+	chunkSize int
+	// archive keeps every block published to topic on disk; nil keeps none.
+	archive      BlockArchive
 	js           jetstream.JetStream
 	metricsStore *metrics.Store
 	topic        string
+	// blockTime is how often the chain is expected to make a block, until
+	// blockTimes has seen enough blocks to tell.
+	blockTime  time.Duration
+	blockTimes blockTimeEstimator
+	window     blockWindow
+	// traces, state and storage hand published blocks over to runTraces,
+	// RunState and RunStorage; nil without traces, state watchers or storage
+	// slots.
+	traces  *blockHandoff
+	state   *blockHandoff
+	storage *blockHandoff
 }
 
-func New(log *slog.Logger, chainSrv ChainSrv, js jetstream.JetStream, metricsStore *metrics.Store, topic string) *Feeder {
+// Config is what New builds a feeder from. Log, ChainSrv, Compressor, JS,
+// Metrics and Topic are required; every other field left at its zero value
+// turns its feature off.
+type Config struct {
+	Log      *slog.Logger
+	ChainSrv ChainSrv
+	// Heads wakes the feeder as soon as a head is announced; it falls back to
+	// the timer whenever the source goes quiet. Nil polls only.
+	Heads HeadSource
+	// Cursor resumes after the last block of the previous run, backfilling at
+	// most MaxBackfill blocks. Nil starts at the head.
+	Cursor      CursorStore
+	MaxBackfill int64
+	// With a Leader only the elected instance talks to the RPC; the rest stand
+	// by and replay its publishes from Mirror.
+	Leader Leadership
+	Mirror Mirror
+	// Consensus holds every block back until that many RPC endpoints serve
+	// the same one.
+	Consensus int
+	// VerifyReceipts checks the receipts of every block against its
+	// transactions and logs bloom, failing over to the next endpoint on a
+	// mismatch. Off publishes the receipts as the endpoint served them, for
+	// chains whose receipts legitimately differ from the block.
+	VerifyReceipts bool
+	// FullTransactions adds every transaction, calldata included, next to the
+	// receipts.
+	FullTransactions bool
+	// TraceAPI, chain.TraceAPIDebug or chain.TraceAPIParity, publishes the
+	// internal calls of every block to <Topic>.traces.
+	TraceAPI string
+	// LogSubjects (LogsByAddress, LogsByTopic0) also publish the logs of every
+	// block in batches per contract or per topic0.
+	LogSubjects []string
+	// Every payload is published zstd-compressed by Compressor once per
+	// encoding: envelope.JSON to the subject itself, the others right after
+	// Topic, see encodedSubject. No Encodings means JSON only. Compressor may carry
+	// a trained zstd dictionary; its ID goes along in the headers.
+	Encodings  []string
+	Compressor *codec.Compressor
+	// Archive also keeps every block of the head on disk as it was published.
+	Archive BlockArchive
+	JS      jetstream.JetStream
+	// MaxPayload is the max_payload of the NATS server, nats.Conn.MaxPayload.
+	// Payloads it refuses go out in chunks that fit under it; zero falls back
+	// to DefaultChunkSize.
+	MaxPayload int64
+	Metrics    *metrics.Store
+	Topic      string
+	// BlockTime is the expected interval between blocks of the chain,
+	// EtaNextBlock when zero. It paces the polling only until the feeder has
+	// learned the actual interval from the timestamps of the blocks it
+	// publishes.
+	BlockTime time.Duration
+}
+
+// New builds a feeder that polls for the next block on a timer, configured
+// by cfg.
+func New(cfg Config) *Feeder {
+	blockTime := cfg.BlockTime
+	if blockTime <= 0 {
+		blockTime = EtaNextBlock
+	}
+
+	encodings := cfg.Encodings
+	if len(encodings) == 0 {
+		encodings = []string{envelope.JSON}
+	}
+
+	var mirrorQueue chan MirroredMsg
+	if cfg.Mirror != nil {
+		mirrorQueue = make(chan MirroredMsg, MirrorQueueSize)
+	}
+
+	chunkSize := DefaultChunkSize
+	if cfg.MaxPayload > ChunkHeadroom {
+		chunkSize = int(cfg.MaxPayload - ChunkHeadroom)
+	}
+
 	return &Feeder{
-		log:          log,
-		chainSrv:     chainSrv,
-		js:           js,
-		metricsStore: metricsStore,
-		topic:        topic,
+		log:              cfg.Log,
+		chainSrv:         cfg.ChainSrv,
+		heads:            cfg.Heads,
+		cursor:           cfg.Cursor,
+		maxBackfill:      cfg.MaxBackfill,
+		leader:           cfg.Leader,
+		mirror:           cfg.Mirror,
+		mirrorQueue:      mirrorQueue,
+		consensus:        cfg.Consensus,
+		verifyReceipts:   cfg.VerifyReceipts,
+		fullTransactions: cfg.FullTransactions,
+		traceAPI:         cfg.TraceAPI,
+		logSubjects:      cfg.LogSubjects,
+		encodings:        encodings,
+		compressor:       cfg.Compressor,
+		chunkSize:        chunkSize,
+		archive:          cfg.Archive,
+		js:               cfg.JS,
+		metricsStore:     cfg.Metrics,
+		topic:            cfg.Topic,
+		blockTime:        blockTime,
 	}
 }
 
 const Per6Sec = 6 * time.Second
 const JetStreamRetryWrite = 250 * time.Millisecond
 const JetStreamAttemptsWrite = 5
+
+// ChunkHeadroom is what a chunk leaves of the server's max_payload for its
+// headers and the subject.
+const ChunkHeadroom = 16 << 10
+
+// DefaultChunkSize is the most a chunk carries when the server's max_payload
+// is not known: under the 1 MB NATS ships with, the least any server allows.
+const DefaultChunkSize = 768 << 10
+
+// EtaNextBlock is the Ethereum L1 slot, the block time unless told otherwise.
 const EtaNextBlock = 12 * time.Second
 const DelayNextBlock = 500 * time.Millisecond
+const RetryDelay = 2 * time.Second
 
 // How many blocks one recovery batch asks for. Keep it well under the RPC
 // client timeout: each block also drags its receipts along.
 const RecoverChunkSize = 50
 
 func (w *Feeder) Run(ctx context.Context, g *errgroup.Group) {
+	w.runTraces(ctx, g)
+
 	g.Go(func() error {
 		timer := time.NewTimer(Per6Sec)
 		defer timer.Stop()
 
 		prevBlockNumber := int64(-1)
+		resumed := false
+		// The highest head announced by the head source, -1 without one.
+		announced := int64(-1)
+
+		var heads <-chan entity.EthBlock
+		if w.heads != nil {
+			heads = w.heads.Heads(ctx)
+		}
 
 		var (
 			block          *entity.RpcResponse[entity.EthBlock]
@@ -76,7 +245,39 @@ func (w *Feeder) Run(ctx context.Context, g *errgroup.Group) {
 			select {
 			case <-ctx.Done():
 				return ctx.Err()
+			case head, ok := <-heads:
+				if !ok {
+					heads = nil
+					continue
+				}
+
+				announced = head.GetNumber()
+				if announced > prevBlockNumber {
+					timer.Reset(0)
+				}
 			case <-timer.C:
+				// Standby: the leader polls and this cell gets its blocks
+				// through the mirror. Once elected, resume from the shared
+				// cursor instead of from where this instance last stopped.
+				if !w.isLeader() {
+					resumed = false
+					w.resetTimer(timer)
+					continue
+				}
+
+				if !resumed {
+					resumeFrom, resumeErr := w.resume(ctx)
+					if resumeErr != nil {
+						w.metricsStore.PublishedBlocks.With(prometheus.Labels{metrics.Status: metrics.StatusFail}).Inc()
+						w.log.Error(fmt.Sprintf("Could not resume from the saved cursor: %v", resumeErr))
+						w.resetTimer(timer)
+						continue
+					}
+
+					resumed = true
+					prevBlockNumber = resumeFrom
+				}
+
 				if prevBlockNumber == -1 {
 					block, err = w.chainSrv.GetLatestBlock(ctx)
 					if err != nil {
@@ -94,6 +295,7 @@ func (w *Feeder) Run(ctx context.Context, g *errgroup.Group) {
 						}
 
 						if errors.Is(err, chain.ErrEmptyResponse) {
+							w.metricsStore.PollMisses.Inc()
 							w.log.Info(fmt.Sprintf("Block %d is not available", nextBlockNumber))
 							w.resetTimer(timer)
 							continue
@@ -135,25 +337,52 @@ func (w *Feeder) Run(ctx context.Context, g *errgroup.Group) {
 				}
 
 				if block.Result.GetNumber() == prevBlockNumber {
+					w.metricsStore.PollMisses.Inc()
 					w.resetTimer(timer)
 					w.log.Warn(fmt.Sprintf(`got the same block %d as before, skipping`, block.Result.GetNumber()))
 					continue
 				}
 
-				blockReceipts, getReceiptsErr := w.chainSrv.GetBlockReceipts(ctx, block.Result.Hash)
+				if w.isReorg(block.Result) {
+					resumeFrom, reorgErr := w.handleReorg(ctx, block.Result)
+					if reorgErr != nil {
+						w.metricsStore.PublishedBlocks.With(prometheus.Labels{metrics.Status: metrics.StatusFail}).Inc()
+						w.log.Error(fmt.Sprintf("Could not handle reorg at block %d: %v", block.Result.GetNumber(), reorgErr))
+						prevBlockNumber = resumeFrom
+						w.resetTimer(timer)
+						continue
+					}
+				}
+
+				blockReceipts, getReceiptsErr := w.getReceipts(ctx, block.Result)
 				if getReceiptsErr != nil {
 					w.metricsStore.PublishedBlocks.With(prometheus.Labels{metrics.Status: metrics.StatusFail}).Inc()
-					w.log.Error(fmt.Sprintf("GetBlockReceipts error: %v", getReceiptsErr))
+					w.log.Error(fmt.Sprintf("Could not get receipts of block %d: %v", block.Result.GetNumber(), getReceiptsErr))
 					w.resetTimer(timer)
 					continue
 				}
 
-				blockDto := buildBlockDto(block.Result, *blockReceipts.Result)
-				if publishErr := w.publishBlock(blockDto); publishErr != nil {
+				if confirmErr := w.confirmBlock(ctx, chain.TagLatest, block.Result, len(*blockReceipts.Result)); confirmErr != nil {
+					w.metricsStore.PublishedBlocks.With(prometheus.Labels{metrics.Status: metrics.StatusFail}).Inc()
+					w.log.Error(fmt.Sprintf("Block %d is not confirmed: %v", block.Result.GetNumber(), confirmErr))
+					w.resetTimer(timer)
+					continue
+				}
+
+				blockDto, dtoErr := w.buildDto(ctx, block.Result, *blockReceipts.Result)
+				if dtoErr != nil {
+					w.metricsStore.PublishedBlocks.With(prometheus.Labels{metrics.Status: metrics.StatusFail}).Inc()
+					w.log.Error(fmt.Sprintf("Could not build block %d: %v", block.Result.GetNumber(), dtoErr))
+					w.resetTimer(timer)
+					continue
+				}
+
+				if publishErr := w.publishBlock(w.topic, blockDto.Hash, blockDto); publishErr != nil {
 					w.metricsStore.PublishedBlocks.With(prometheus.Labels{metrics.Status: metrics.StatusFail}).Inc()
 
-					// An oversized block never becomes publishable, so retrying it
-					// wedges the feeder on this height forever. Skip past it.
+					// Oversized blocks go out in chunks; one NATS refuses even
+					// then never becomes publishable, so retrying it wedges the
+					// feeder on this height forever. Skip past it.
 					if isUnpublishable(publishErr) {
 						w.metricsStore.UnpublishedBlock(metrics.ReasonMaxPayload, block.Result.GetNumber())
 						w.log.Error("Skipping unpublishable block",
@@ -162,6 +391,7 @@ func (w *Feeder) Run(ctx context.Context, g *errgroup.Group) {
 							slog.String("error", publishErr.Error()),
 						)
 
+						w.remember(&blockDto, false)
 						prevBlockNumber = block.Result.GetNumber()
 						w.updateTickerAfterBlock(timer, block.Result)
 
@@ -185,9 +415,21 @@ func (w *Feeder) Run(ctx context.Context, g *errgroup.Group) {
 				// staleness alert compares this against time().
 				w.metricsStore.LastPublishedBlockTimestamp.Set(float64(time.Now().Unix()))
 
+				w.remember(&blockDto, true)
+				w.publishLogs(&blockDto)
+				w.traces.offer(block.Result)
+				w.state.offer(block.Result)
+				w.storage.offer(block.Result)
 				prevBlockNumber = block.Result.GetNumber()
 				delay := w.updateTickerAfterBlock(timer, block.Result)
 
+				// Already announced further: catch up now instead of waiting
+				// for the next block's ETA.
+				if announced > prevBlockNumber {
+					timer.Reset(0)
+					delay = 0
+				}
+
 				w.log.Info(fmt.Sprintf("%d is published. next block delay %s", blockDto.Number, slog.Duration("delay", delay)))
 			}
 		}
@@ -247,16 +489,16 @@ func (w *Feeder) recoverMissedBlocks(ctx context.Context, fromBlock int64) (*ent
 	return latestPubBlock, nil
 }
 
-// recoverBlockRange fetches and publishes a single chunk, returning its last
-// published block.
-func (w *Feeder) recoverBlockRange(ctx context.Context, from, to int64) (*entity.EthBlock, error) {
+// fetchBlockRange fetches a chunk of blocks with their receipts grouped by
+// block hash.
+func (w *Feeder) fetchBlockRange(ctx context.Context, from, to int64) ([]entity.EthBlock, map[string][]entity.BlockReceipt, error) {
 	blocksResp, err := w.chainSrv.FetchBlocksInRange(ctx, from, to)
 	if err != nil {
-		return nil, fmt.Errorf("fetch blocks in range %d..%d: %w", from, to, err)
+		return nil, nil, fmt.Errorf("fetch blocks in range %d..%d: %w", from, to, err)
 	}
 
 	if blocksResp.Result == nil || len(*blocksResp.Result) == 0 {
-		return nil, fmt.Errorf("no blocks returned for range %d..%d: %w", from, to, chain.ErrEmptyResponse)
+		return nil, nil, fmt.Errorf("no blocks returned for range %d..%d: %w", from, to, chain.ErrEmptyResponse)
 	}
 
 	blocks := *blocksResp.Result
@@ -268,7 +510,7 @@ func (w *Feeder) recoverBlockRange(ctx context.Context, from, to int64) (*entity
 
 	receiptsResp, err := w.chainSrv.FetchReceipts(ctx, blockHashes)
 	if err != nil {
-		return nil, fmt.Errorf("could not fetch receipts: %w", err)
+		return nil, nil, fmt.Errorf("could not fetch receipts: %w", err)
 	}
 
 	receiptsByBlock := make(map[string][]entity.BlockReceipt)
@@ -279,12 +521,76 @@ func (w *Feeder) recoverBlockRange(ctx context.Context, from, to int64) (*entity
 		}
 	}
 
+	if !w.verifyReceipts {
+		return blocks, receiptsByBlock, nil
+	}
+
+	// The batch goes to a single endpoint, so one truncated answer would
+	// spoil the whole chunk. Check every block and refetch the odd one out
+	// through the pool, which fails over.
+	for i := range blocks {
+		verifyErr := chain.VerifyReceipts(&blocks[i], receiptsByBlock[blocks[i].Hash])
+		if verifyErr == nil {
+			continue
+		}
+
+		var mismatch *chain.ReceiptsMismatchError
+		if errors.As(verifyErr, &mismatch) {
+			w.metricsStore.ReceiptsMismatches.With(prometheus.Labels{metrics.Reason: mismatch.Reason}).Inc()
+		}
+		w.log.Warn("Refetching receipts", slog.Int64("blockNumber", blocks[i].GetNumber()), slog.String("error", verifyErr.Error()))
+
+		refetched, refetchErr := w.chainSrv.GetVerifiedReceipts(ctx, &blocks[i])
+		if refetchErr != nil {
+			return nil, nil, fmt.Errorf("could not refetch receipts of block %d: %w", blocks[i].GetNumber(), refetchErr)
+		}
+
+		receiptsByBlock[blocks[i].Hash] = *refetched.Result
+	}
+
+	return blocks, receiptsByBlock, nil
+}
+
+// getReceipts fetches the receipts of block, verified against it unless the
+// chain turned that off.
+func (w *Feeder) getReceipts(ctx context.Context, block *entity.EthBlock) (*entity.RpcResponse[[]entity.BlockReceipt], error) {
+	if !w.verifyReceipts {
+		return w.chainSrv.GetBlockReceipts(ctx, block.Hash)
+	}
+
+	return w.chainSrv.GetVerifiedReceipts(ctx, block)
+}
+
+// recoverBlockRange fetches and publishes a single chunk, returning its last
+// published block.
+func (w *Feeder) recoverBlockRange(ctx context.Context, from, to int64) (*entity.EthBlock, error) {
+	blocks, receiptsByBlock, err := w.fetchBlockRange(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
 	var latestPubBlock *entity.EthBlock
 	for i := range blocks {
 		block := blocks[i]
 
-		dto := buildBlockDto(&block, receiptsByBlock[block.Hash])
-		if publishErr := w.publishBlock(dto); publishErr != nil {
+		if confirmErr := w.confirmBlock(ctx, chain.TagLatest, &block, len(receiptsByBlock[block.Hash])); confirmErr != nil {
+			if latestPubBlock != nil {
+				return latestPubBlock, nil
+			}
+
+			return nil, confirmErr
+		}
+
+		dto, dtoErr := w.buildDto(ctx, &block, receiptsByBlock[block.Hash])
+		if dtoErr != nil {
+			if latestPubBlock != nil {
+				return latestPubBlock, nil
+			}
+
+			return nil, dtoErr
+		}
+
+		if publishErr := w.publishBlock(w.topic, dto.Hash, dto); publishErr != nil {
 			// Blocks published before this one stay published — report how far
 			// we got so the caller can resume from there.
 			if latestPubBlock != nil {
@@ -294,6 +600,11 @@ func (w *Feeder) recoverBlockRange(ctx context.Context, from, to int64) (*entity
 			return nil, fmt.Errorf("could not publish block: %w", publishErr)
 		}
 		latestPubBlock = &block
+		w.remember(&dto, true)
+		w.publishLogs(&dto)
+		w.traces.offer(&block)
+		w.state.offer(&block)
+		w.storage.offer(&block)
 
 		// Recovered blocks count as published, otherwise a long backfill would
 		// look like a stalled feeder to the staleness alert.
@@ -306,27 +617,10 @@ func (w *Feeder) recoverBlockRange(ctx context.Context, from, to int64) (*entity
 	return latestPubBlock, nil
 }
 
-func compress(payload []byte) (*bytes.Buffer, error) {
-	cPayload := &bytes.Buffer{}
-
-	zstdWriter, err := zstd.NewWriter(cPayload)
-	if err != nil {
-		return nil, fmt.Errorf("could not create zstd writer: %w", err)
-	}
-
-	if _, zstdErr := zstdWriter.Write(payload); zstdErr != nil {
-		zstdWriter.Close()
-		return nil, zstdErr
-	}
-
-	// Close flushes the frame footer, so it has to happen before the buffer is
-	// handed over — a deferred Close would run after the return.
-	if closeErr := zstdWriter.Close(); closeErr != nil {
-		return nil, fmt.Errorf("could not finish zstd frame: %w", closeErr)
-	}
-
-	return cPayload, nil
-}
+// BlockSchemaVersion is the schemaVersion of the BlockDto the feeder
+// publishes. Fields only ever get added, so it is not Databus-Version: a bot
+// checks it before relying on a field of a later version.
+const BlockSchemaVersion = 2
 
 func buildBlockDto(block *entity.EthBlock, blockReceipts []entity.BlockReceipt) databus.BlockDtoJson {
 	receipts := make([]databus.BlockDtoJsonReceiptsElem, 0, len(blockReceipts))
@@ -353,58 +647,222 @@ func buildBlockDto(block *entity.EthBlock, blockReceipts []entity.BlockReceipt)
 		}
 
 		receipts = append(receipts, databus.BlockDtoJsonReceiptsElem{
-			Logs:            logs,
-			To:              &receipt.To,
-			From:            receipt.From,
-			TransactionHash: receipt.TransactionHash,
+			Logs:              logs,
+			To:                &receipt.To,
+			From:              receipt.From,
+			TransactionHash:   receipt.TransactionHash,
+			Status:            optionalQuantity(receipt.Status),
+			Type:              optionalQuantity(receipt.Type),
+			GasUsed:           optionalQuantity(receipt.GasUsed),
+			EffectiveGasPrice: optionalWei(receipt.EffectiveGasPrice),
+			ContractAddress:   optional(receipt.ContractAddress),
 		})
 	}
 
+	schemaVersion := BlockSchemaVersion
+
 	return databus.BlockDtoJson{
-		Hash:       block.Hash,
-		Number:     int(block.GetNumber()),
-		ParentHash: block.ParentHash,
-		Receipts:   receipts,
-		Timestamp:  int(block.GetTimestamp()),
+		SchemaVersion: &schemaVersion,
+		Hash:          block.Hash,
+		Number:        int(block.GetNumber()),
+		ParentHash:    block.ParentHash,
+		Receipts:      receipts,
+		Timestamp:     int(block.GetTimestamp()),
+		Miner:         optional(block.Miner),
+		GasUsed:       optionalQuantity(block.GasUsed),
+		GasLimit:      optionalQuantity(block.GasLimit),
+		BaseFeePerGas: optionalWei(block.BaseFeePerGas),
+		BlobGasUsed:   optionalQuantity(block.BlobGasUsed),
+		ExcessBlobGas: optionalQuantity(block.ExcessBlobGas),
+		Withdrawals:   buildWithdrawals(block.Withdrawals),
+	}
+}
+
+func buildWithdrawals(withdrawals []entity.Withdrawal) []databus.BlockDtoJsonWithdrawalsElem {
+	if len(withdrawals) == 0 {
+		return nil
+	}
+
+	out := make([]databus.BlockDtoJsonWithdrawalsElem, 0, len(withdrawals))
+	for _, wd := range withdrawals {
+		out = append(out, databus.BlockDtoJsonWithdrawalsElem{
+			Index:          quantity(wd.Index),
+			ValidatorIndex: quantity(wd.ValidatorIndex),
+			Address:        wd.Address,
+			Amount:         quantity(wd.Amount),
+		})
 	}
+
+	return out
 }
 
-func (w *Feeder) publishBlock(blockDto databus.BlockDtoJson) error {
-	payload, marshalErr := json.Marshal(blockDto)
+// publishBlock publishes blockDto to subject with msgID. Head subjects use the
+// block hash; a stream the same block goes out on again needs its own, see
+// streamMsgID.
+func (w *Feeder) publishBlock(subject, msgID string, blockDto databus.BlockDtoJson) error {
+	for _, encoding := range w.encodings {
+		payload, cPayload, encodeErr := w.encode(encoding, blockDto)
+		if encodeErr != nil {
+			return fmt.Errorf("could not encode blockDto: %w", encodeErr)
+		}
+
+		// Recorded before the publish so an oversized block is measured too — that
+		// is exactly the case worth seeing on the chart.
+		w.metricsStore.BlockPayloadSize.With(prometheus.Labels{metrics.Stage: metrics.StageRaw, metrics.Encoding: encoding}).Set(float64(len(payload)))
+		w.metricsStore.BlockPayloadSize.With(prometheus.Labels{metrics.Stage: metrics.StageCompressed, metrics.Encoding: encoding}).Set(float64(len(cPayload)))
+
+		// What the dictionary gains costs one more compression, so only
+		// blocks are measured, and only with a dictionary.
+		if w.compressor.DictID() != 0 {
+			w.metricsStore.BlockPayloadSize.With(prometheus.Labels{metrics.Stage: metrics.StageCompressedNoDict, metrics.Encoding: encoding}).
+				Set(float64(w.compressor.SizeWithoutDictionary(payload)))
+		}
+
+		if publishErr := w.publish(w.encodedSubject(subject, encoding), encodedMsgID(msgID, encoding), encoding, cPayload); publishErr != nil {
+			cPayloadSize := slog.String("cPayloadSize", fmt.Sprintf(`%.6f mb`, float64(len(cPayload))/(1024*1024)))
+			return fmt.Errorf("could not publish block %d(%s, cPayload: %s) to JetStream: %w ", blockDto.Number, encoding, cPayloadSize, publishErr)
+		}
+
+		if encoding == envelope.JSON && subject == w.topic {
+			w.archiveBlock(&blockDto, cPayload)
+		}
+	}
+
+	return nil
+}
+
+// publishCompressed publishes v in every encoding of the feeder, compressed
+// the same way blocks are, so bots decode every feeder subject alike.
+func (w *Feeder) publishCompressed(subject, msgID string, v any) error {
+	for _, encoding := range w.encodings {
+		_, cPayload, encodeErr := w.encode(encoding, v)
+		if encodeErr != nil {
+			return fmt.Errorf("could not encode %s payload: %w", subject, encodeErr)
+		}
+
+		encodedSubject := w.encodedSubject(subject, encoding)
+		if publishErr := w.publish(encodedSubject, encodedMsgID(msgID, encoding), encoding, cPayload); publishErr != nil {
+			return fmt.Errorf("could not publish %s to JetStream: %w", encodedSubject, publishErr)
+		}
+	}
+
+	return nil
+}
+
+// encodedSubject is where subject goes in encoding. Subjects under the topic
+// take the encoding right after it, before their partition:
+// <topic>.msgpack.logs.<address>.
+func (w *Feeder) encodedSubject(subject, encoding string) string {
+	if partition, ok := strings.CutPrefix(subject, w.topic); ok && w.topic != "" && (partition == "" || partition[0] == '.') {
+		return codec.Subject(w.topic, partition, encoding)
+	}
+
+	return codec.Subject(subject, "", encoding)
+}
+
+// encode marshals v in encoding and compresses it by zstd, returning both.
+func (w *Feeder) encode(encoding string, v any) ([]byte, []byte, error) {
+	payload, marshalErr := codec.Marshal(encoding, v)
 	if marshalErr != nil {
-		return fmt.Errorf("could not marshal blockDto: %w", marshalErr)
+		return nil, nil, fmt.Errorf("could not marshal to %s: %w", encoding, marshalErr)
+	}
+
+	return payload, w.compressor.Compress(payload), nil
+}
+
+// encodedMsgID keeps the message IDs of the encodings apart: JetStream dedupes
+// by msgID across the whole stream, and every encoding lives in the same one.
+func encodedMsgID(msgID, encoding string) string {
+	if encoding == envelope.JSON {
+		return msgID
+	}
+
+	return msgID + "." + encoding
+}
+
+// publish sends an already encoded payload to JetStream in its envelope.
+func (w *Feeder) publish(subject, msgID, encoding string, data []byte) error {
+	return w.publishEnvelope(subject, msgID, envelope.Header(encoding, w.compressor.DictID()), data)
+}
+
+// publishEnvelope sends data with header as one message. A payload NATS
+// refuses for its size goes out in chunks of chunkSize instead, so heavy blocks
+// reach the bots too.
+func (w *Feeder) publishEnvelope(subject, msgID string, header nats.Header, data []byte) error {
+	err := w.publishMsg(subject, msgID, header, data)
+	if !isUnpublishable(err) {
+		return err
 	}
 
-	cPayload, compressErr := compress(payload)
-	if compressErr != nil {
-		return fmt.Errorf("could not compress blockDto by zstd: %w", compressErr)
+	return w.publishChunks(subject, msgID, header, data)
+}
+
+// publishChunks splits data into ordered chunks on the subject of the whole
+// payload, each with its envelope and its place among them. Every chunk has
+// its own msgID, so a retry after a partial failure only adds the missing
+// ones.
+func (w *Feeder) publishChunks(subject, msgID string, header nats.Header, data []byte) error {
+	count := (len(data) + w.chunkSize - 1) / w.chunkSize
+
+	for i := range count {
+		chunk := data[i*w.chunkSize : min((i+1)*w.chunkSize, len(data))]
+
+		if err := w.publishMsg(subject, fmt.Sprintf("%s.chunk-%d", msgID, i), envelope.ChunkHeader(header, msgID, i, count), chunk); err != nil {
+			return fmt.Errorf("could not publish chunk %d of %d: %w", i+1, count, err)
+		}
 	}
 
-	// Recorded before the publish so an oversized block is measured too — that
-	// is exactly the case worth seeing on the chart.
-	w.metricsStore.BlockPayloadSize.With(prometheus.Labels{metrics.Stage: metrics.StageRaw}).Set(float64(len(payload)))
-	w.metricsStore.BlockPayloadSize.With(prometheus.Labels{metrics.Stage: metrics.StageCompressed}).Set(float64(cPayload.Len()))
+	w.metricsStore.ChunkedPayloads.Inc()
+	w.log.Info("Published oversized payload in chunks",
+		slog.String("subject", subject),
+		slog.String("msgID", msgID),
+		slog.Int("bytes", len(data)),
+		slog.Int("chunks", count),
+	)
 
-	if _, publishErr := w.js.PublishAsync(w.topic, cPayload.Bytes(),
-		jetstream.WithMsgID(blockDto.Hash),
+	return nil
+}
+
+// publishMsg sends one message to JetStream and queues it for the mirror of
+// the other cells.
+func (w *Feeder) publishMsg(subject, msgID string, header nats.Header, data []byte) error {
+	msg := &nats.Msg{Subject: subject, Header: header, Data: data}
+
+	if _, err := w.js.PublishMsgAsync(msg,
+		jetstream.WithMsgID(msgID),
 		jetstream.WithRetryAttempts(JetStreamAttemptsWrite),
 		jetstream.WithRetryWait(JetStreamRetryWrite),
-	); publishErr != nil {
-		cPayloadSize := slog.String("cPayloadSize", fmt.Sprintf(`%.6f mb`, float64(cPayload.Len())/(1024*1024)))
-		return fmt.Errorf("could not publish block %d(cPayload: %s) to JetStream: %w ", blockDto.Number, cPayloadSize, publishErr)
+	); err != nil {
+		return err
+	}
+
+	if w.mirrorQueue != nil {
+		w.offerMirror(MirroredMsg{Subject: subject, MsgID: msgID, Header: header, Data: data})
 	}
 
 	return nil
 }
 
 func (w *Feeder) updateTickerAfterBlock(timer *time.Timer, block *entity.EthBlock) time.Duration {
-	expectedNextBlockTime := time.Unix(block.GetTimestamp(), 0).Add(EtaNextBlock)
-	delay := max(time.Until(expectedNextBlockTime.Add(DelayNextBlock)), time.Second)
+	// On chains faster than L1 the fixed margins would skip whole blocks, so
+	// neither may exceed a fraction of the block time.
+	interval := w.blockInterval()
+	expectedNextBlockTime := time.Unix(block.GetTimestamp(), 0).Add(interval)
+	delay := max(time.Until(expectedNextBlockTime.Add(min(DelayNextBlock, interval/2))), pollDelay(interval, time.Second))
 
 	timer.Reset(delay)
 	return delay
 }
 
 func (w *Feeder) resetTimer(timer *time.Timer) {
-	timer.Reset(2 * time.Second)
+	timer.Reset(pollDelay(w.blockInterval(), RetryDelay))
+}
+
+// blockInterval is the interval learned from the recent blocks, w.blockTime
+// until there are enough of them.
+func (w *Feeder) blockInterval() time.Duration {
+	interval := w.blockTimes.estimate(w.blockTime)
+	w.metricsStore.EstimatedBlockTime.Set(interval.Seconds())
+
+	return interval
 }
@@ -1,10 +1,14 @@
 package feeder
 
 import (
+	"bytes"
 	"context"
+	"crypto/rand"
+	"encoding/json"
 	"errors"
 	"log/slog"
 	"os"
+	"slices"
 	"strconv"
 	"sync"
 	"testing"
@@ -15,14 +19,17 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"golang.org/x/sync/errgroup"
 
+	"github.com/lidofinance/onchain-mon/generated/databus"
 	"github.com/lidofinance/onchain-mon/internal/connectors/metrics"
 	"github.com/lidofinance/onchain-mon/internal/pkg/chain/entity"
+	"github.com/lidofinance/onchain-mon/internal/pkg/codec"
+	"github.com/lidofinance/onchain-mon/pkg/envelope"
 )
 
 // Swallows publishes so recovery can be exercised without a NATS server.
 type noopJetStream struct{ jetstream.JetStream }
 
-func (n *noopJetStream) PublishAsync(_ string, _ []byte, _ ...jetstream.PublishOpt) (jetstream.PubAckFuture, error) {
+func (n *noopJetStream) PublishMsgAsync(_ *nats.Msg, _ ...jetstream.PublishOpt) (jetstream.PubAckFuture, error) {
 	return nil, nil
 }
 
@@ -48,17 +55,31 @@ func (n *nilResultChain) GetLatestBlock(_ context.Context) (*entity.RpcResponse[
 
 var testMetricsOnce sync.Once
 var testMetrics *metrics.Store
+var testCompressor *codec.Compressor
 
 func newTestFeeder(c ChainSrv) *Feeder {
+	return New(testConfig(c))
+}
+
+// testConfig is the least New needs, for tests that set more before building
+// the feeder.
+func testConfig(c ChainSrv) Config {
 	// metrics.New registers collectors, so build the store once per binary.
 	testMetricsOnce.Do(func() {
 		testMetrics = metrics.New(prometheus.NewRegistry(), "feeder_test", "t", "t")
+
+		var err error
+		if testCompressor, err = codec.NewCompressor(nil); err != nil {
+			panic(err)
+		}
 	})
 
-	return &Feeder{
-		log:          slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError + 1})),
-		chainSrv:     c,
-		metricsStore: testMetrics,
+	return Config{
+		Log:            slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError + 1})),
+		ChainSrv:       c,
+		VerifyReceipts: true,
+		Compressor:     testCompressor,
+		Metrics:        testMetrics,
 	}
 }
 
@@ -211,7 +232,7 @@ func Test_recover_keeps_progress_when_a_later_chunk_fails(t *testing.T) {
 // Fails every publish so nothing can be salvaged.
 type failPublishFeeder struct{ jetstream.JetStream }
 
-func (f *failPublishFeeder) PublishAsync(_ string, _ []byte, _ ...jetstream.PublishOpt) (jetstream.PubAckFuture, error) {
+func (f *failPublishFeeder) PublishMsgAsync(_ *nats.Msg, _ ...jetstream.PublishOpt) (jetstream.PubAckFuture, error) {
 	return nil, errors.New("nats rejected the message")
 }
 
@@ -239,27 +260,36 @@ type oversizedChain struct {
 }
 
 func (c *oversizedChain) GetLatestBlock(_ context.Context) (*entity.RpcResponse[entity.EthBlock], error) {
-	b := entity.EthBlock{Number: "0x" + strconv.FormatInt(c.latest, 16), Hash: "0xh"}
+	b := oversizedBlock(c.latest)
 	return &entity.RpcResponse[entity.EthBlock]{Result: &b}, nil
 }
 
+// Blocks link up through their parent hashes, so reorg detection stays quiet.
+func oversizedBlock(n int64) entity.EthBlock {
+	return entity.EthBlock{
+		Number:     "0x" + strconv.FormatInt(n, 16),
+		Hash:       "0xh" + strconv.FormatInt(n, 10),
+		ParentHash: "0xh" + strconv.FormatInt(n-1, 10),
+	}
+}
+
 func (c *oversizedChain) FetchBlockByNumber(_ context.Context, n int64) (*entity.RpcResponse[entity.EthBlock], error) {
 	c.mu.Lock()
 	c.fetched = append(c.fetched, n)
 	c.mu.Unlock()
 
-	b := entity.EthBlock{Number: "0x" + strconv.FormatInt(n, 16), Hash: "0xh" + strconv.FormatInt(n, 10)}
+	b := oversizedBlock(n)
 	return &entity.RpcResponse[entity.EthBlock]{Result: &b}, nil
 }
 
-func (c *oversizedChain) GetBlockReceipts(_ context.Context, _ string) (*entity.RpcResponse[[]entity.BlockReceipt], error) {
+func (c *oversizedChain) GetVerifiedReceipts(_ context.Context, _ *entity.EthBlock) (*entity.RpcResponse[[]entity.BlockReceipt], error) {
 	empty := []entity.BlockReceipt{}
 	return &entity.RpcResponse[[]entity.BlockReceipt]{Result: &empty}, nil
 }
 
 type maxPayloadJetStream struct{ jetstream.JetStream }
 
-func (m *maxPayloadJetStream) PublishAsync(_ string, _ []byte, _ ...jetstream.PublishOpt) (jetstream.PubAckFuture, error) {
+func (m *maxPayloadJetStream) PublishMsgAsync(_ *nats.Msg, _ ...jetstream.PublishOpt) (jetstream.PubAckFuture, error) {
 	return nil, nats.ErrMaxPayload
 }
 
@@ -296,3 +326,389 @@ func Test_unpublishable_block_does_not_wedge_the_loop(t *testing.T) {
 	}
 	t.Logf("heights fetched in order: %v", fetched)
 }
+
+// A head source the test pushes to by hand.
+type chanHeads chan entity.EthBlock
+
+func (c chanHeads) Heads(_ context.Context) <-chan entity.EthBlock { return c }
+
+// Canonical blocks stamped with the current time, so the polling timer waits a
+// full EtaNextBlock and only a head announcement can wake the loop early.
+type freshChain struct{ canonicalChain }
+
+func (c *freshChain) fresh(n int64) *entity.RpcResponse[entity.EthBlock] {
+	b := c.block(n)
+	b.Timestamp = "0x" + strconv.FormatInt(time.Now().Unix(), 16)
+	return &entity.RpcResponse[entity.EthBlock]{Result: &b}
+}
+
+func (c *freshChain) GetLatestBlock(_ context.Context) (*entity.RpcResponse[entity.EthBlock], error) {
+	return c.fresh(100), nil
+}
+
+func (c *freshChain) FetchBlockByNumber(_ context.Context, n int64) (*entity.RpcResponse[entity.EthBlock], error) {
+	return c.fresh(n), nil
+}
+
+func (c *freshChain) GetVerifiedReceipts(_ context.Context, _ *entity.EthBlock) (*entity.RpcResponse[[]entity.BlockReceipt], error) {
+	empty := []entity.BlockReceipt{}
+	return &entity.RpcResponse[[]entity.BlockReceipt]{Result: &empty}, nil
+}
+
+func Test_announced_heads_wake_the_loop_before_the_timer(t *testing.T) {
+	heads := make(chanHeads, 1)
+	js := &recordingJetStream{}
+
+	f := newTestFeeder(&freshChain{})
+	f.js = js
+	f.heads = heads
+	f.topic = "blocks.test"
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	g, gCtx := errgroup.WithContext(ctx)
+	f.Run(gCtx, g)
+
+	waitPublished := func(want int) {
+		t.Helper()
+
+		// Well under Per6Sec and EtaNextBlock: only a head can explain it.
+		deadline := time.Now().Add(2 * time.Second)
+		for len(js.subjects()) < want {
+			if time.Now().After(deadline) {
+				t.Fatalf("published %d blocks, want %d", len(js.subjects()), want)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	heads <- entity.EthBlock{Number: "0x64"} // 100
+	waitPublished(1)
+
+	// The source skipped 101: the loop catches up without waiting for a head
+	// or a tick.
+	heads <- entity.EthBlock{Number: "0x66"} // 102
+	waitPublished(3)
+
+	// The source going away leaves the timer in charge, not a dead loop.
+	close(heads)
+
+	cancel()
+	if err := g.Wait(); !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want context.Canceled", err)
+	}
+}
+
+// The batch drops the receipt of block 101; asked on its own the pool answers
+// in full.
+type truncatingChain struct {
+	canonicalChain
+	refetched []int64
+}
+
+func (c *truncatingChain) FetchBlocksInRange(_ context.Context, from, to int64) (*entity.RpcResponse[[]entity.EthBlock], error) {
+	blocks := make([]entity.EthBlock, 0, to-from+1)
+	for n := from; n <= to; n++ {
+		b := c.block(n)
+		b.Transactions = []string{"0xtx" + strconv.FormatInt(n, 10)}
+		blocks = append(blocks, b)
+	}
+
+	return &entity.RpcResponse[[]entity.EthBlock]{Result: &blocks}, nil
+}
+
+func (c *truncatingChain) receipt(block *entity.EthBlock) entity.BlockReceipt {
+	return entity.BlockReceipt{BlockHash: block.Hash, TransactionHash: block.Transactions[0]}
+}
+
+func (c *truncatingChain) FetchReceipts(_ context.Context, hashes []string) (*entity.RpcResponse[[]entity.BlockReceipt], error) {
+	out := []entity.BlockReceipt{}
+	for _, hash := range hashes {
+		if hash == "0x101" {
+			continue
+		}
+
+		n, _ := strconv.ParseInt(hash[2:], 10, 64)
+		b := c.block(n)
+		b.Transactions = []string{"0xtx" + strconv.FormatInt(n, 10)}
+		out = append(out, c.receipt(&b))
+	}
+
+	return &entity.RpcResponse[[]entity.BlockReceipt]{Result: &out}, nil
+}
+
+func (c *truncatingChain) GetVerifiedReceipts(_ context.Context, block *entity.EthBlock) (*entity.RpcResponse[[]entity.BlockReceipt], error) {
+	c.refetched = append(c.refetched, block.GetNumber())
+
+	out := []entity.BlockReceipt{c.receipt(block)}
+	return &entity.RpcResponse[[]entity.BlockReceipt]{Result: &out}, nil
+}
+
+func Test_truncated_batch_receipts_are_refetched(t *testing.T) {
+	c := &truncatingChain{}
+
+	_, receiptsByBlock, err := newTestFeeder(c).fetchBlockRange(context.Background(), 100, 102)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(c.refetched) != 1 || c.refetched[0] != 101 {
+		t.Errorf("refetched %v, want only block 101", c.refetched)
+	}
+	if got := receiptsByBlock["0x101"]; len(got) != 1 || got[0].TransactionHash != "0xtx101" {
+		t.Errorf("block 101 still lacks its receipt: %+v", got)
+	}
+}
+
+func Test_receipts_are_taken_as_served_when_verification_is_off(t *testing.T) {
+	c := &truncatingChain{}
+
+	f := newTestFeeder(c)
+	f.verifyReceipts = false
+
+	_, receiptsByBlock, err := f.fetchBlockRange(context.Background(), 100, 102)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(c.refetched) != 0 {
+		t.Errorf("refetched %v, want nothing", c.refetched)
+	}
+	if got := receiptsByBlock["0x101"]; len(got) != 0 {
+		t.Errorf("block 101 got receipts it was not served: %+v", got)
+	}
+}
+
+// The L1 margins (a 1s floor, 500ms after the ETA) used to hold an L2 feeder
+// back by several blocks on every tick.
+func Test_fast_chain_is_polled_at_its_own_pace(t *testing.T) {
+	f := newTestFeeder(&canonicalChain{})
+	f.blockTime = 250 * time.Millisecond
+
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+
+	// Mined a while ago: the next one is due already, so only the poll rate
+	// bound holds the feeder back.
+	late := &entity.EthBlock{Timestamp: "0x" + strconv.FormatInt(time.Now().Add(-time.Minute).Unix(), 16)}
+	if delay := f.updateTickerAfterBlock(timer, late); delay != MinPollInterval {
+		t.Errorf("a late block waits %s, want %s", delay, MinPollInterval)
+	}
+
+	// Mined this second: due one block time plus the margin after it.
+	fresh := &entity.EthBlock{Timestamp: "0x" + strconv.FormatInt(time.Now().Unix(), 16)}
+	if delay := f.updateTickerAfterBlock(timer, fresh); delay > 250*time.Millisecond+125*time.Millisecond {
+		t.Errorf("a fresh block waits %s, want at most a block time and half", delay)
+	}
+}
+
+func Test_every_encoding_gets_its_subject_and_envelope(t *testing.T) {
+	js := &recordingJetStream{}
+	f := newTestFeeder(&canonicalChain{})
+	f.js = js
+	f.encodings = []string{envelope.JSON, envelope.MsgPack}
+
+	block := databus.BlockDtoJson{Number: 100, Hash: "0xabc", ParentHash: "0xparent", Receipts: []databus.BlockDtoJsonReceiptsElem{}}
+	if err := f.publishBlock("blocks.test", block.Hash, block); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := js.subjects(); len(got) != 2 || got[0] != "blocks.test" || got[1] != "blocks.test.msgpack" {
+		t.Fatalf("published to %v, want json on the topic and msgpack next to it", got)
+	}
+
+	for i, encoding := range f.encodings {
+		msg := js.msgs[i]
+		if got, err := envelope.EncodingOf(msg.header); err != nil || got != encoding {
+			t.Errorf("%s carries encoding %q (%v), want %s", msg.subject, got, err, encoding)
+		}
+		if got := msg.header.Get(envelope.HeaderVersion); got != envelope.Version {
+			t.Errorf("%s carries version %q", msg.subject, got)
+		}
+
+		var got databus.BlockDtoJson
+		if err := codec.Unmarshal(encoding, msg.payload, &got); err != nil {
+			t.Fatal(err)
+		}
+		if got.Number != 100 || got.Hash != "0xabc" || got.ParentHash != "0xparent" {
+			t.Errorf("%s decodes to %+v", msg.subject, got)
+		}
+	}
+}
+
+// A bot on blocks.test.logs.> must not get the MessagePack batches too.
+func Test_encoding_comes_before_the_partition(t *testing.T) {
+	f := newTestFeeder(&canonicalChain{})
+	f.topic = "blocks.test"
+
+	tests := []struct {
+		subject string
+		want    string
+	}{
+		{"blocks.test", "blocks.test.msgpack"},
+		{"blocks.test.logs.0xabc", "blocks.test.msgpack.logs.0xabc"},
+		{"blocks.test.traces", "blocks.test.msgpack.traces"},
+		{"state.test", "state.test.msgpack"},
+		{"blocks.testnet", "blocks.testnet.msgpack"},
+	}
+
+	for _, tt := range tests {
+		if got := f.encodedSubject(tt.subject, envelope.MsgPack); got != tt.want {
+			t.Errorf("%s goes to %s, want %s", tt.subject, got, tt.want)
+		}
+		if got := f.encodedSubject(tt.subject, envelope.JSON); got != tt.subject {
+			t.Errorf("json %s goes to %s", tt.subject, got)
+		}
+	}
+}
+
+// Refuses what NATS would: anything above limit bytes.
+type limitedJetStream struct {
+	jetstream.JetStream
+	limit int
+	raw   [][]byte
+	hdrs  []nats.Header
+}
+
+func (l *limitedJetStream) PublishMsgAsync(msg *nats.Msg, _ ...jetstream.PublishOpt) (jetstream.PubAckFuture, error) {
+	if len(msg.Data) > l.limit {
+		return nil, nats.ErrMaxPayload
+	}
+
+	l.raw = append(l.raw, msg.Data)
+	l.hdrs = append(l.hdrs, msg.Header)
+
+	return nil, nil
+}
+
+// Oversized blocks used to be skipped, so bots never saw the heavy ones.
+func Test_oversized_block_is_published_in_chunks(t *testing.T) {
+	js := &limitedJetStream{limit: DefaultChunkSize}
+	f := newTestFeeder(&canonicalChain{})
+	f.js = js
+
+	// Random bytes do not compress, so the payload stays above the limit.
+	data := make([]byte, 2*DefaultChunkSize+100)
+	if _, err := rand.Read(data); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := f.publish("blocks.test", "0xabc", envelope.JSON, data); err != nil {
+		t.Fatalf("an oversized payload must go out in chunks: %v", err)
+	}
+
+	if len(js.raw) != 3 {
+		t.Fatalf("published %d messages, want 3 chunks", len(js.raw))
+	}
+
+	var joined []byte
+	for i, h := range js.hdrs {
+		chunk, ok, err := envelope.ChunkOf(h)
+		if err != nil || !ok || chunk.ID != "0xabc" || chunk.Index != i || chunk.Count != 3 {
+			t.Errorf("chunk %d carries %+v, %v, %v", i, chunk, ok, err)
+		}
+		if got, _ := envelope.EncodingOf(h); got != envelope.JSON {
+			t.Errorf("chunk %d lost its envelope: %v", i, h)
+		}
+
+		joined = append(joined, js.raw[i]...)
+	}
+
+	if !bytes.Equal(joined, data) {
+		t.Error("the chunks do not add up to the payload")
+	}
+}
+
+func Test_block_dto_carries_the_execution_header(t *testing.T) {
+	// A post-Cancun header as eth_getBlockByNumber returns it.
+	var block entity.EthBlock
+	if err := json.Unmarshal([]byte(`{
+		"number":"0x1312d00","hash":"0xb10c","parentHash":"0xpa","timestamp":"0x6650f8e3",
+		"miner":"0x95222290dd7278aa3ddd389cc1e1d165cc4bafe5",
+		"gasUsed":"0xe4e1c0","gasLimit":"0x1c9c380","baseFeePerGas":"0x2540be400",
+		"blobGasUsed":"0x60000","excessBlobGas":"0x0",
+		"withdrawals":[{"index":"0x3039","validatorIndex":"0x5ba","address":"0xb9d7934878b5fb9610b3fe8a5e441e8fad7e293f","amount":"0x11e1a300"}],
+		"transactions":[]
+	}`), &block); err != nil {
+		t.Fatal(err)
+	}
+
+	dto := buildBlockDto(&block, nil)
+
+	if dto.Miner == nil || *dto.Miner != "0x95222290dd7278aa3ddd389cc1e1d165cc4bafe5" {
+		t.Errorf("miner: got %v", dto.Miner)
+	}
+	if dto.GasUsed == nil || *dto.GasUsed != 15_000_000 || dto.GasLimit == nil || *dto.GasLimit != 30_000_000 {
+		t.Errorf("gas: got %v of %v", dto.GasUsed, dto.GasLimit)
+	}
+	if dto.BaseFeePerGas == nil || *dto.BaseFeePerGas != "10000000000" {
+		t.Errorf("base fee: got %v", dto.BaseFeePerGas)
+	}
+	// A zero excess is a value, not an absent field.
+	if dto.BlobGasUsed == nil || *dto.BlobGasUsed != 393216 || dto.ExcessBlobGas == nil || *dto.ExcessBlobGas != 0 {
+		t.Errorf("blob gas: got %v, excess %v", dto.BlobGasUsed, dto.ExcessBlobGas)
+	}
+
+	want := []databus.BlockDtoJsonWithdrawalsElem{{Index: 12345, ValidatorIndex: 1466, Address: "0xb9d7934878b5fb9610b3fe8a5e441e8fad7e293f", Amount: 300_000_000}}
+	if !slices.Equal(dto.Withdrawals, want) {
+		t.Errorf("withdrawals: got %+v", dto.Withdrawals)
+	}
+
+	// Before London none of it exists, and none of it is sent.
+	old := buildBlockDto(&entity.EthBlock{Number: "0x1", Hash: "0x1", Miner: "0x05a5"}, nil)
+	payload, _ := json.Marshal(old)
+	for _, field := range []string{"baseFeePerGas", "blobGasUsed", "excessBlobGas", "withdrawals"} {
+		if bytes.Contains(payload, []byte(`"`+field+`"`)) {
+			t.Errorf("%s sent for a block without it: %s", field, payload)
+		}
+	}
+}
+
+func Test_block_dto_carries_receipt_status_and_gas(t *testing.T) {
+	// A reverted call and a deployment, as eth_getBlockReceipts returns them.
+	var receipts []entity.BlockReceipt
+	if err := json.Unmarshal([]byte(`[
+		{"transactionHash":"0xf1","from":"0xa1","to":"0xc0","status":"0x0","type":"0x2",
+		 "gasUsed":"0x5208","effectiveGasPrice":"0x2540be400","contractAddress":null,"logs":[]},
+		{"transactionHash":"0xd1","from":"0xa1","to":null,"status":"0x1","type":"0x0",
+		 "gasUsed":"0x1e8480","effectiveGasPrice":"0x3b9aca00","contractAddress":"0xdead","logs":[]}
+	]`), &receipts); err != nil {
+		t.Fatal(err)
+	}
+
+	dto := buildBlockDto(&entity.EthBlock{Number: "0x1", Hash: "0x1"}, receipts)
+
+	if dto.SchemaVersion == nil || *dto.SchemaVersion != BlockSchemaVersion {
+		t.Errorf("schemaVersion: got %v", dto.SchemaVersion)
+	}
+
+	failed, deployed := dto.Receipts[0], dto.Receipts[1]
+
+	// A zero status is the whole point: it must not read as absent.
+	if failed.Status == nil || *failed.Status != 0 || deployed.Status == nil || *deployed.Status != 1 {
+		t.Errorf("status: got %v and %v", failed.Status, deployed.Status)
+	}
+	if failed.Type == nil || *failed.Type != 2 || failed.GasUsed == nil || *failed.GasUsed != 21000 {
+		t.Errorf("type %v, gas used %v", failed.Type, failed.GasUsed)
+	}
+	if failed.EffectiveGasPrice == nil || *failed.EffectiveGasPrice != "10000000000" {
+		t.Errorf("effective gas price: got %v", failed.EffectiveGasPrice)
+	}
+	if failed.ContractAddress != nil {
+		t.Errorf("contract address sent for a call: %v", *failed.ContractAddress)
+	}
+	if deployed.ContractAddress == nil || *deployed.ContractAddress != "0xdead" {
+		t.Errorf("contract address: got %v", deployed.ContractAddress)
+	}
+
+	// Payloads of schemaVersion 1, without any of it, still decode.
+	var v1 databus.BlockDtoJson
+	if err := json.Unmarshal([]byte(`{"number":1,"timestamp":1,"hash":"0x1","parentHash":"0x0",
+		"receipts":[{"from":"0xa1","transactionHash":"0xf1","logs":[]}]}`), &v1); err != nil {
+		t.Fatal(err)
+	}
+	if v1.SchemaVersion != nil || v1.Receipts[0].Status != nil {
+		t.Errorf("got %+v", v1)
+	}
+}
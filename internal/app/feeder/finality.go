@@ -0,0 +1,148 @@
+package feeder
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/lidofinance/onchain-mon/internal/connectors/metrics"
+	"github.com/lidofinance/onchain-mon/internal/pkg/chain"
+)
+
+// FinalityPollInterval is how often a finality stream asks where its tag is.
+// safe and finalized move once per epoch, so polling every slot is plenty.
+const FinalityPollInterval = 12 * time.Second
+
+// IsFinalityTag reports whether the feeder can follow tag as a separate stream.
+func IsFinalityTag(tag string) bool {
+	return tag == chain.TagSafe || tag == chain.TagFinalized
+}
+
+// RunFinalityStream follows a block tag that lags the head (safe or finalized)
+// and publishes every block it passes to <topic>.<tag>, e.g.
+// blocks.mainnet.l1.finalized. Bots that must only alert on irreversible state
+// subscribe there instead of delaying themselves.
+func (w *Feeder) RunFinalityStream(ctx context.Context, g *errgroup.Group, tag string) error {
+	if !IsFinalityTag(tag) {
+		return fmt.Errorf("unknown block stream %q: want %s or %s", tag, chain.TagSafe, chain.TagFinalized)
+	}
+
+	subject := w.topic + "." + tag
+
+	g.Go(func() error {
+		ticker := time.NewTicker(FinalityPollInterval)
+		defer ticker.Stop()
+
+		lastNumber := int64(-1)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-ticker.C:
+				var err error
+				if lastNumber, err = w.followTag(ctx, tag, subject, lastNumber); err != nil {
+					w.metricsStore.StreamPublishedBlocks.With(prometheus.Labels{metrics.Stream: tag, metrics.Status: metrics.StatusFail}).Inc()
+					w.log.Error(fmt.Sprintf("%s stream: %v", tag, err))
+				}
+			}
+		}
+	})
+
+	return nil
+}
+
+// streamMsgID keeps a block on a finality stream apart from the same block on
+// the head subject and the other stream: JetStream dedupes by msgID across
+// the whole stream, and all of them live in the same one.
+func streamMsgID(hash, tag string) string {
+	return hash + "." + tag
+}
+
+// followTag publishes the blocks between lastNumber and the block currently
+// behind tag, and returns the highest height it got through. The first call
+// starts at the tagged block itself, the way the head loop starts at latest.
+func (w *Feeder) followTag(ctx context.Context, tag, subject string, lastNumber int64) (int64, error) {
+	// Standby instances get the stream through the mirror. A new leader starts
+	// at the tagged block, like a fresh start.
+	if !w.isLeader() {
+		return -1, nil
+	}
+
+	tagged, err := w.chainSrv.GetBlockByTag(ctx, tag)
+	if err != nil {
+		return lastNumber, fmt.Errorf("get %s block: %w", tag, err)
+	}
+
+	if tagged.Result == nil {
+		return lastNumber, fmt.Errorf("get %s block: %w", tag, chain.ErrEmptyResponse)
+	}
+
+	head := tagged.Result.GetNumber()
+	if head <= lastNumber {
+		return lastNumber, nil
+	}
+
+	from := lastNumber + 1
+	if lastNumber == -1 {
+		from = head
+	}
+
+	for ; from <= head; from += RecoverChunkSize {
+		to := min(from+RecoverChunkSize-1, head)
+
+		blocks, receiptsByBlock, fetchErr := w.fetchBlockRange(ctx, from, to)
+		if fetchErr != nil {
+			return lastNumber, fetchErr
+		}
+
+		for i := range blocks {
+			block := blocks[i]
+
+			if confirmErr := w.confirmBlock(ctx, tag, &block, len(receiptsByBlock[block.Hash])); confirmErr != nil {
+				return lastNumber, confirmErr
+			}
+
+			dto, dtoErr := w.buildDto(ctx, &block, receiptsByBlock[block.Hash])
+			if dtoErr != nil {
+				return lastNumber, dtoErr
+			}
+
+			if publishErr := w.publishBlock(subject, streamMsgID(dto.Hash, tag), dto); publishErr != nil {
+				if !isUnpublishable(publishErr) {
+					return lastNumber, publishErr
+				}
+
+				w.metricsStore.UnpublishedBlock(metrics.ReasonMaxPayload, block.GetNumber())
+				w.log.Error("Skipping unpublishable block",
+					slog.String("stream", tag),
+					slog.Int("blockNumber", dto.Number),
+					slog.String("reason", metrics.ReasonMaxPayload),
+					slog.String("error", publishErr.Error()),
+				)
+
+				lastNumber = block.GetNumber()
+				continue
+			}
+
+			w.metricsStore.StreamPublishedBlocks.With(prometheus.Labels{metrics.Stream: tag, metrics.Status: metrics.StatusOk}).Inc()
+			w.metricsStore.StreamLastPublishedBlockTimestamp.With(prometheus.Labels{metrics.Stream: tag}).Set(float64(time.Now().Unix()))
+			w.metricsStore.StreamLastPublishedBlockNumber.With(prometheus.Labels{metrics.Stream: tag}).Set(float64(dto.Number))
+
+			lastNumber = block.GetNumber()
+		}
+
+		// A short answer would silently skip heights on the next chunk.
+		if lastNumber < to {
+			return lastNumber, fmt.Errorf("range %d..%d published only up to %d", from, to, lastNumber)
+		}
+	}
+
+	w.log.Info(fmt.Sprintf("%s stream: published up to %d", tag, lastNumber))
+
+	return lastNumber, nil
+}
@@ -0,0 +1,114 @@
+package feeder
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lidofinance/onchain-mon/internal/pkg/chain/entity"
+)
+
+// A canonical chain whose tagged block is set by the test.
+type taggedChain struct {
+	canonicalChain
+	tagged int64
+	tags   []string
+}
+
+func (c *taggedChain) GetBlockByTag(_ context.Context, tag string) (*entity.RpcResponse[entity.EthBlock], error) {
+	c.tags = append(c.tags, tag)
+
+	b := c.block(c.tagged)
+	return &entity.RpcResponse[entity.EthBlock]{Result: &b}, nil
+}
+
+func Test_finality_stream_publishes_every_block_it_passes(t *testing.T) {
+	c := &taggedChain{tagged: 1000}
+
+	js := &recordingJetStream{}
+	f := newTestFeeder(c)
+	f.js = js
+	f.topic = "blocks.test"
+
+	// The first poll starts at the tagged block, no backfill.
+	last, err := f.followTag(context.Background(), "finalized", "blocks.test.finalized", -1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if last != 1000 || len(js.subjects()) != 1 {
+		t.Fatalf("got last %d after %d publishes, want 1000 after 1", last, len(js.subjects()))
+	}
+
+	// An epoch later finalized jumps 64 blocks: every one of them goes out,
+	// in two chunks.
+	c.tagged = 1064
+	if last, err = f.followTag(context.Background(), "finalized", "blocks.test.finalized", last); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if last != 1064 {
+		t.Errorf("got last %d, want 1064", last)
+	}
+
+	subjects := js.subjects()
+	if len(subjects) != 65 {
+		t.Fatalf("published %d blocks, want 65", len(subjects))
+	}
+	for _, s := range subjects {
+		if s != "blocks.test.finalized" {
+			t.Fatalf("block went to %s instead of the finalized subject", s)
+		}
+	}
+
+	// The tag did not move: nothing to do.
+	if last, err = f.followTag(context.Background(), "finalized", "blocks.test.finalized", last); err != nil || last != 1064 {
+		t.Errorf("got %d, %v; want 1064 and no error", last, err)
+	}
+	if len(js.subjects()) != 65 {
+		t.Errorf("republished blocks although the tag did not move")
+	}
+	for _, tag := range c.tags {
+		if tag != "finalized" {
+			t.Errorf("asked for tag %q", tag)
+		}
+	}
+}
+
+func Test_unknown_finality_tag_is_rejected(t *testing.T) {
+	f := newTestFeeder(&taggedChain{})
+	if err := f.RunFinalityStream(context.Background(), nil, "pending"); err == nil {
+		t.Fatal("expected an error for a tag the feeder cannot follow")
+	}
+}
+
+func Test_finality_streams_do_not_share_the_head_msg_id(t *testing.T) {
+	c := &taggedChain{tagged: 1000}
+
+	cfg := testConfig(c)
+	cfg.Mirror = &memMirror{}
+
+	f := New(cfg)
+	f.js = &recordingJetStream{}
+	f.topic = "blocks.test"
+
+	if _, err := f.recoverBlockRange(context.Background(), 1000, 1000); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, tag := range []string{"safe", "finalized"} {
+		if _, err := f.followTag(context.Background(), tag, "blocks.test."+tag, -1); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	// The mirror gets every publish with the msgID it went to JetStream with.
+	seen := make(map[string]string)
+	for len(f.mirrorQueue) > 0 {
+		msg := <-f.mirrorQueue
+		if other, ok := seen[msg.MsgID]; ok {
+			t.Errorf("%s and %s share msgID %s: JetStream would drop the second", other, msg.Subject, msg.MsgID)
+		}
+		seen[msg.MsgID] = msg.Subject
+	}
+
+	if len(seen) != 3 {
+		t.Errorf("got msgIDs %v, want one per subject", seen)
+	}
+}
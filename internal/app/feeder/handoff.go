@@ -0,0 +1,47 @@
+package feeder
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/lidofinance/onchain-mon/internal/pkg/chain/entity"
+)
+
+// blockHandoff hands published blocks over to a reader running next to the
+// block stream, holding on to the newest size of them: skipped counts the
+// blocks dropped before the reader got to them. Traces, state watchers and
+// storage slots are read this way.
+//
+// A reader asks isLeader again before it publishes: leadership may have moved
+// while it read. What it compares the next block against only moves once the
+// comparison went out, or was the leader's to send, so a change lost to a
+// failed publish still shows up in the next one.
+type blockHandoff struct {
+	blocks  chan entity.EthBlock
+	skipped prometheus.Counter
+}
+
+func newBlockHandoff(size int, skipped prometheus.Counter) *blockHandoff {
+	return &blockHandoff{blocks: make(chan entity.EthBlock, size), skipped: skipped}
+}
+
+// offer hands block over, dropping the oldest one still waiting if the reader
+// is behind. A nil handoff, one nobody reads, takes nothing.
+func (h *blockHandoff) offer(block *entity.EthBlock) {
+	if h == nil {
+		return
+	}
+
+	for {
+		select {
+		case h.blocks <- *block:
+			return
+		default:
+		}
+
+		select {
+		case <-h.blocks:
+			h.skipped.Inc()
+		default:
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package feeder
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/lidofinance/onchain-mon/internal/pkg/chain/entity"
+)
+
+func Test_handoff_falls_behind_to_the_newest_block(t *testing.T) {
+	skipped := prometheus.NewCounter(prometheus.CounterOpts{Name: "skipped"})
+	h := newBlockHandoff(1, skipped)
+
+	for n := 1; n <= 3; n++ {
+		h.offer(&entity.EthBlock{Number: word(n), Hash: "0xb" + strconv.Itoa(n)})
+	}
+
+	if got := <-h.blocks; got.Hash != "0xb3" {
+		t.Errorf("the reader got block %s, want the newest one", got.Hash)
+	}
+	if got := testutil.ToFloat64(skipped); got != 2 {
+		t.Errorf("skipped %v blocks, want 2", got)
+	}
+
+	// Without a reader nothing waits for the block.
+	newTestFeeder(&canonicalChain{}).state.offer(&entity.EthBlock{Hash: "0xb4"})
+}
@@ -0,0 +1,110 @@
+package feeder
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/lidofinance/onchain-mon/generated/databus"
+	"github.com/lidofinance/onchain-mon/internal/connectors/metrics"
+)
+
+// Ways to split the logs of a block into per-subject batches.
+const (
+	// LogsByAddress publishes to <topic>.logs.<address>.
+	LogsByAddress = `address`
+	// LogsByTopic0 publishes to <topic>.topics.<topic0>.
+	LogsByTopic0 = `topic0`
+)
+
+const LogsSubjectInfix = `.logs.`
+const TopicsSubjectInfix = `.topics.`
+
+// IsLogPartition reports whether the feeder can split logs by partition.
+func IsLogPartition(partition string) bool {
+	return partition == LogsByAddress || partition == LogsByTopic0
+}
+
+type logBatch struct {
+	subject string
+	dto     databus.LogsDtoJson
+}
+
+// publishLogs publishes the logs of a block that has just been published, one
+// batch per emitter or topic0, so bots can subscribe to just their contracts.
+// Like traces, batches are best effort: the block on the main subject already
+// has every log.
+func (w *Feeder) publishLogs(dto *databus.BlockDtoJson) {
+	for _, batch := range buildLogBatches(w.topic, dto, w.logSubjects) {
+		// JetStream dedupes by msgID across the whole stream, and every batch
+		// of the block shares its hash: 0xblock.logs.0xaddress.
+		msgID := dto.Hash + strings.TrimPrefix(batch.subject, w.topic)
+
+		if err := w.publishCompressed(batch.subject, msgID, batch.dto); err != nil {
+			w.metricsStore.LogBatchesPublished.With(prometheus.Labels{metrics.Status: metrics.StatusFail}).Inc()
+
+			if isUnpublishable(err) {
+				w.metricsStore.UnpublishedBlock(metrics.ReasonLogsMaxPayload, int64(dto.Number))
+				w.log.Error("Skipping unpublishable logs",
+					slog.Int("blockNumber", dto.Number),
+					slog.String("subject", batch.subject),
+					slog.Int("logs", len(batch.dto.Logs)),
+					slog.String("reason", metrics.ReasonLogsMaxPayload),
+					slog.String("error", err.Error()),
+				)
+
+				continue
+			}
+
+			w.log.Error(fmt.Sprintf("Could not publish logs of block %d to %s: %v", dto.Number, batch.subject, err))
+			continue
+		}
+
+		w.metricsStore.LogBatchesPublished.With(prometheus.Labels{metrics.Status: metrics.StatusOk}).Inc()
+	}
+}
+
+// buildLogBatches groups the logs of a block by every partition, keeping the
+// block order within a batch and the order of first appearance across them.
+// Addresses and topics are lowercased: NATS subjects are case-sensitive and
+// RPC nodes are not consistent about checksums.
+func buildLogBatches(topic string, dto *databus.BlockDtoJson, partitions []string) []logBatch {
+	var batches []logBatch
+	index := make(map[string]int)
+
+	add := func(subject string, l databus.BlockDtoJsonReceiptsElemLogsElem) {
+		i, ok := index[subject]
+		if !ok {
+			i = len(batches)
+			index[subject] = i
+			batches = append(batches, logBatch{subject: subject, dto: databus.LogsDtoJson{
+				Number:     dto.Number,
+				Timestamp:  dto.Timestamp,
+				ParentHash: dto.ParentHash,
+				Hash:       dto.Hash,
+			}})
+		}
+
+		batches[i].dto.Logs = append(batches[i].dto.Logs, databus.LogsDtoJsonLogsElem(l))
+	}
+
+	for _, partition := range partitions {
+		for _, receipt := range dto.Receipts {
+			for _, l := range receipt.Logs {
+				switch partition {
+				case LogsByAddress:
+					add(topic+LogsSubjectInfix+strings.ToLower(l.Address), l)
+				case LogsByTopic0:
+					// Anonymous events have no topic0 to file them under.
+					if len(l.Topics) > 0 {
+						add(topic+TopicsSubjectInfix+strings.ToLower(l.Topics[0]), l)
+					}
+				}
+			}
+		}
+	}
+
+	return batches
+}
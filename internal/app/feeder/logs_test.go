@@ -0,0 +1,104 @@
+package feeder
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/lidofinance/onchain-mon/generated/databus"
+)
+
+const (
+	stETH    = "0xae7ab96520DE3A18E5e111B5EaAb095312D7fE84"
+	wstETH   = "0x7f39C581F595B53c5cb19bD0b3f8dA6c935E2Ca0"
+	transfer = "0xDDF252AD1BE2C89B69C2B068FC378DAA952BA7F163C4A11628F55A4DF523B3EF"
+)
+
+func logsBlock() *databus.BlockDtoJson {
+	return &databus.BlockDtoJson{
+		Number:     100,
+		Timestamp:  1_700_000_000,
+		ParentHash: "0xparent",
+		Hash:       "0xabc",
+		Receipts: []databus.BlockDtoJsonReceiptsElem{
+			{TransactionHash: "0xt1", Logs: []databus.BlockDtoJsonReceiptsElemLogsElem{
+				{Address: stETH, Topics: []string{transfer}, LogIndex: 0},
+				{Address: wstETH, Topics: []string{transfer}, LogIndex: 1},
+			}},
+			{TransactionHash: "0xt2", Logs: []databus.BlockDtoJsonReceiptsElemLogsElem{
+				// An anonymous event: no topic0 to file it under.
+				{Address: stETH, Topics: []string{}, LogIndex: 2},
+			}},
+		},
+	}
+}
+
+func Test_logs_are_batched_per_address_and_topic0(t *testing.T) {
+	batches := buildLogBatches("blocks.test", logsBlock(), []string{LogsByAddress, LogsByTopic0})
+
+	got := make(map[string][]int, len(batches))
+	subjects := make([]string, 0, len(batches))
+	for _, b := range batches {
+		subjects = append(subjects, b.subject)
+		for _, l := range b.dto.Logs {
+			got[b.subject] = append(got[b.subject], l.LogIndex)
+		}
+
+		if b.dto.Number != 100 || b.dto.Hash != "0xabc" || b.dto.ParentHash != "0xparent" {
+			t.Errorf("%s does not carry its block: %+v", b.subject, b.dto)
+		}
+	}
+
+	wantSubjects := []string{
+		"blocks.test.logs.0xae7ab96520de3a18e5e111b5eaab095312d7fe84",
+		"blocks.test.logs.0x7f39c581f595b53c5cb19bd0b3f8da6c935e2ca0",
+		"blocks.test.topics.0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef",
+	}
+	if !reflect.DeepEqual(subjects, wantSubjects) {
+		t.Fatalf("subjects = %v, want %v", subjects, wantSubjects)
+	}
+
+	want := map[string][]int{
+		wantSubjects[0]: {0, 2},
+		wantSubjects[1]: {1},
+		wantSubjects[2]: {0, 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("log indexes per subject = %v, want %v", got, want)
+	}
+}
+
+func Test_log_batches_follow_the_block(t *testing.T) {
+	js := &recordingJetStream{}
+	f := newTestFeeder(&canonicalChain{})
+	f.js = js
+	f.topic = "blocks.test"
+	f.logSubjects = []string{LogsByAddress}
+
+	f.publishLogs(logsBlock())
+
+	if got := js.subjects(); len(got) != 2 {
+		t.Fatalf("published to %v, want one subject per contract", got)
+	}
+
+	var batch databus.LogsDtoJson
+	if err := json.Unmarshal(js.msgs[0].payload, &batch); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(batch.Logs) != 2 || batch.Logs[1].Address != stETH || batch.Logs[1].LogIndex != 2 {
+		t.Errorf("unexpected stETH batch: %+v", batch)
+	}
+}
+
+func Test_no_log_subjects_publish_nothing(t *testing.T) {
+	js := &recordingJetStream{}
+	f := newTestFeeder(&canonicalChain{})
+	f.js = js
+
+	f.publishLogs(logsBlock())
+
+	if got := js.subjects(); len(got) != 0 {
+		t.Errorf("published to %v without log subjects", got)
+	}
+}
@@ -0,0 +1,203 @@
+package feeder
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/golang-lru/v2/expirable"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/lidofinance/onchain-mon/generated/databus"
+	"github.com/lidofinance/onchain-mon/internal/connectors/metrics"
+	"github.com/lidofinance/onchain-mon/internal/pkg/chain/entity"
+)
+
+// Where pending transactions come from: eth_subscribe over the chain's
+// WebSocket, or txpool_content polled over HTTP.
+const (
+	MempoolSubscribe = `subscribe`
+	MempoolTxPool    = `txpool`
+)
+
+// MempoolSubjectPrefix starts the subject of every chain's mempool:
+// mempool.mainnet, mempool.arbitrum.
+const MempoolSubjectPrefix = `mempool.`
+
+// MempoolPollInterval is how often txpool_content is read. The whole pool
+// comes back every time, so this is about the latency bots can live with.
+const MempoolPollInterval = time.Second
+
+// A transaction stays pending for a while, and a reconnected subscription
+// announces the pool again: each one is published once per MempoolSeenTTL.
+const MempoolSeenSize = 1 << 16
+const MempoolSeenTTL = 10 * time.Minute
+
+func IsMempoolSource(source string) bool {
+	switch source {
+	case MempoolSubscribe, MempoolTxPool:
+		return true
+	}
+
+	return false
+}
+
+// MempoolSubject is where the pending transactions of chain go.
+func MempoolSubject(chain string) string {
+	return MempoolSubjectPrefix + chain
+}
+
+// PendingSource pushes transactions as they enter the mempool;
+// chain.PendingSubscriber and TxPoolPoller are the real ones.
+type PendingSource interface {
+	Pending(ctx context.Context) <-chan entity.Transaction
+}
+
+// RunMempool publishes every pending transaction src announces whose to is
+// one of watch to subject, once per transaction. Pending transactions are a
+// head start, not a record: one that fails to publish is counted and let go.
+func (w *Feeder) RunMempool(ctx context.Context, g *errgroup.Group, src PendingSource, subject string, watch []string) {
+	watched := make(map[string]bool, len(watch))
+	for _, address := range watch {
+		watched[strings.ToLower(address)] = true
+	}
+
+	seen := expirable.NewLRU[string, struct{}](MempoolSeenSize, nil, MempoolSeenTTL)
+
+	g.Go(func() error {
+		pending := src.Pending(ctx)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case tx, ok := <-pending:
+				if !ok {
+					return ctx.Err()
+				}
+
+				w.metricsStore.MempoolSeen.Inc()
+
+				to := strings.ToLower(tx.To)
+				if !watched[to] || seen.Contains(tx.Hash) {
+					continue
+				}
+
+				// Standby instances get the transactions through the mirror.
+				if !w.isLeader() {
+					continue
+				}
+
+				seen.Add(tx.Hash, struct{}{})
+
+				dto := buildPendingTransactionDto(&tx, to, time.Now())
+				if err := w.publishCompressed(subject, "pending-"+tx.Hash, dto); err != nil {
+					w.metricsStore.MempoolTransactions.With(prometheus.Labels{metrics.Status: metrics.StatusFail}).Inc()
+					w.log.Error(fmt.Sprintf("mempool: %v", err), slog.String("hash", tx.Hash))
+
+					continue
+				}
+
+				w.metricsStore.MempoolTransactions.With(prometheus.Labels{metrics.Status: metrics.StatusOk}).Inc()
+			}
+		}
+	})
+}
+
+func buildPendingTransactionDto(tx *entity.Transaction, to string, seenAt time.Time) databus.PendingTransactionDtoJson {
+	nonce, _ := strconv.ParseInt(tx.Nonce, 0, 64)
+	txType, _ := strconv.ParseInt(tx.Type, 0, 64)
+	gas, _ := strconv.ParseInt(tx.Gas, 0, 64)
+
+	return databus.PendingTransactionDtoJson{
+		Hash:                 tx.Hash,
+		From:                 tx.From,
+		To:                   to,
+		Input:                tx.Input,
+		Value:                weiString(tx.Value),
+		Nonce:                int(nonce),
+		Type:                 int(txType),
+		Gas:                  int(gas),
+		GasPrice:             optionalWei(tx.GasPrice),
+		MaxFeePerGas:         optionalWei(tx.MaxFeePerGas),
+		MaxPriorityFeePerGas: optionalWei(tx.MaxPriorityFeePerGas),
+		SeenAt:               int(seenAt.UnixMilli()),
+	}
+}
+
+// TxPool reads a node's whole mempool; chain.NewChain is the real one.
+type TxPool interface {
+	TxPoolContent(ctx context.Context) (*entity.RpcResponse[entity.TxPoolContent], error)
+}
+
+// TxPoolPoller is a PendingSource for nodes without a pending subscription:
+// it reads txpool_content every MempoolPollInterval and announces the
+// transactions that were not there the time before.
+type TxPoolPoller struct {
+	pool     TxPool
+	log      *slog.Logger
+	interval time.Duration
+}
+
+func NewTxPoolPoller(pool TxPool, log *slog.Logger) *TxPoolPoller {
+	return &TxPoolPoller{pool: pool, log: log, interval: MempoolPollInterval}
+}
+
+func (p *TxPoolPoller) Pending(ctx context.Context) <-chan entity.Transaction {
+	out := make(chan entity.Transaction)
+
+	go func() {
+		defer close(out)
+
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+
+		var known map[string]bool
+		for {
+			content, err := p.pool.TxPoolContent(ctx)
+			if err != nil {
+				p.log.Warn("Could not read txpool_content", slog.String("error", err.Error()))
+			} else if known, err = p.announce(ctx, content.Result, known, out); err != nil {
+				return
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return out
+}
+
+// announce sends the pending transactions of content missing from known and
+// returns the hashes of all of them, for the next poll to compare against.
+func (p *TxPoolPoller) announce(ctx context.Context, content *entity.TxPoolContent, known map[string]bool, out chan<- entity.Transaction) (map[string]bool, error) {
+	if content == nil {
+		return known, nil
+	}
+
+	current := make(map[string]bool, len(known))
+	for _, byNonce := range content.Pending {
+		for _, tx := range byNonce {
+			current[tx.Hash] = true
+			if known[tx.Hash] {
+				continue
+			}
+
+			select {
+			case <-ctx.Done():
+				return current, ctx.Err()
+			case out <- tx:
+			}
+		}
+	}
+
+	return current, nil
+}
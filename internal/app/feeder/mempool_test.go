@@ -0,0 +1,134 @@
+package feeder
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"slices"
+	"testing"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/lidofinance/onchain-mon/generated/databus"
+	"github.com/lidofinance/onchain-mon/internal/pkg/chain/entity"
+)
+
+type fakePending []entity.Transaction
+
+func (p fakePending) Pending(context.Context) <-chan entity.Transaction {
+	out := make(chan entity.Transaction, len(p))
+	for _, tx := range p {
+		out <- tx
+	}
+
+	return out
+}
+
+func Test_mempool_publishes_watched_transactions_once(t *testing.T) {
+	src := fakePending{
+		{Hash: "0xt1", To: "0xAE7AB96520DE3A18E5E111B5EAAB095312D7FE84", Value: "0xde0b6b3a7640000", Nonce: "0x7", Gas: "0x5208", Type: "0x2", MaxFeePerGas: "0x3b9aca00"},
+		{Hash: "0xt2", To: "0x00000000000000000000000000000000000000ff"},
+		{Hash: "0xt3", Input: "0x60806040"},
+		// Announced again after a reconnect.
+		{Hash: "0xt1", To: "0xae7ab96520de3a18e5e111b5eaab095312d7fe84"},
+	}
+
+	js := &recordingJetStream{}
+	f := newTestFeeder(&canonicalChain{})
+	f.js = js
+
+	ctx, cancel := context.WithCancel(context.Background())
+	g, gCtx := errgroup.WithContext(ctx)
+
+	f.RunMempool(gCtx, g, src, MempoolSubject("test"), []string{"0xae7ab96520de3a18e5e111b5eaab095312d7fe84"})
+
+	deadline := time.Now().Add(time.Second)
+	for len(js.subjects()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	_ = g.Wait()
+
+	if got := js.subjects(); !slices.Equal(got, []string{"mempool.test"}) {
+		t.Fatalf("published to %v, want one message to mempool.test", got)
+	}
+
+	var dto databus.PendingTransactionDtoJson
+	if err := json.Unmarshal(js.msgs[0].payload, &dto); err != nil {
+		t.Fatal(err)
+	}
+
+	if dto.Hash != "0xt1" || dto.To != "0xae7ab96520de3a18e5e111b5eaab095312d7fe84" {
+		t.Errorf("got %s to %s", dto.Hash, dto.To)
+	}
+	if dto.Value != "1000000000000000000" || dto.Nonce != 7 || dto.Gas != 21000 || dto.Type != 2 {
+		t.Errorf("fields not decoded: %+v", dto)
+	}
+	if dto.MaxFeePerGas == nil || *dto.MaxFeePerGas != "1000000000" || dto.GasPrice != nil {
+		t.Errorf("fees not decoded: %v %v", dto.MaxFeePerGas, dto.GasPrice)
+	}
+	if dto.SeenAt == 0 {
+		t.Error("seenAt is not set")
+	}
+}
+
+type fakeTxPool struct{ polls []*entity.TxPoolContent }
+
+func (p *fakeTxPool) TxPoolContent(context.Context) (*entity.RpcResponse[entity.TxPoolContent], error) {
+	content := p.polls[0]
+	if len(p.polls) > 1 {
+		p.polls = p.polls[1:]
+	}
+
+	return &entity.RpcResponse[entity.TxPoolContent]{Result: content}, nil
+}
+
+func pool(hashes ...string) *entity.TxPoolContent {
+	byNonce := map[string]entity.Transaction{}
+	for i, hash := range hashes {
+		byNonce[string(rune('0'+i))] = entity.Transaction{Hash: hash}
+	}
+
+	return &entity.TxPoolContent{Pending: map[string]map[string]entity.Transaction{"0xf1": byNonce}}
+}
+
+func Test_txpool_poller_announces_only_new_transactions(t *testing.T) {
+	p := NewTxPoolPoller(&fakeTxPool{polls: []*entity.TxPoolContent{
+		pool("0xt1", "0xt2"),
+		// t1 got included, t3 arrived.
+		pool("0xt2", "0xt3"),
+		// t1 is back, after a reorg.
+		pool("0xt1", "0xt2", "0xt3"),
+	}}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	p.interval = time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	pending := p.Pending(ctx)
+
+	var got []string
+	for len(got) < 4 {
+		select {
+		case tx := <-pending:
+			got = append(got, tx.Hash)
+		case <-time.After(time.Second):
+			t.Fatalf("got only %v", got)
+		}
+	}
+
+	slices.Sort(got[:2])
+	if !slices.Equal(got, []string{"0xt1", "0xt2", "0xt3", "0xt1"}) {
+		t.Errorf("got %v", got)
+	}
+
+	// Nothing new after that.
+	select {
+	case tx := <-pending:
+		t.Errorf("announced %s again", tx.Hash)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
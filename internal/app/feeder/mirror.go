@@ -0,0 +1,265 @@
+package feeder
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/lidofinance/onchain-mon/internal/connectors/metrics"
+)
+
+// Leadership tells whether this instance is the one talking to the RPC.
+type Leadership interface {
+	IsLeader() bool
+}
+
+// MirroredMsg is one publish of the leader, as it went to its own JetStream.
+type MirroredMsg struct {
+	Subject string
+	MsgID   string
+	// Header is the envelope, and for a chunk its place among the others.
+	Header nats.Header
+	Data   []byte
+}
+
+// Mirror carries the leader's publishes to the feeders of the other cells,
+// which replay them to their own JetStream.
+type Mirror interface {
+	Append(ctx context.Context, msg MirroredMsg) error
+	// Next blocks until other instances append something, or for a while.
+	Next(ctx context.Context) ([]MirroredMsg, error)
+}
+
+// MirrorRetention is how long the Redis stream keeps a message. Followers
+// read it live, so it only has to outlast a slow replay, not a follower
+// restart; by age rather than count, since every encoding and every chunk is
+// a message of its own.
+const MirrorRetention = 2 * time.Minute
+
+// MirrorBlock is how long one read waits for new messages.
+const MirrorBlock = 5 * time.Second
+
+// MirrorQueueSize is how many publishes wait for Redis at most. Appends run
+// next to the publishing, so a slow Redis drops the oldest of them instead of
+// holding the blocks back.
+const MirrorQueueSize = 256
+
+// MirrorAppendTimeout bounds one append.
+const MirrorAppendTimeout = 2 * time.Second
+
+// MirrorRetryDelay is the pause after a failed read.
+const MirrorRetryDelay = time.Second
+
+type redisMirror struct {
+	rdb    *redis.Client
+	stream string
+	origin string
+	lastID string
+}
+
+// NewRedisMirror fans publishes out through a Redis stream. origin tells this
+// instance's messages apart, so it never replays its own.
+func NewRedisMirror(rdb *redis.Client, stream, origin string) *redisMirror {
+	return &redisMirror{
+		rdb:    rdb,
+		stream: stream,
+		origin: origin,
+	}
+}
+
+func (m *redisMirror) Append(ctx context.Context, msg MirroredMsg) error {
+	header, err := json.Marshal(msg.Header)
+	if err != nil {
+		return fmt.Errorf("could not marshal the header of %s: %w", msg.Subject, err)
+	}
+
+	err = m.rdb.XAdd(ctx, &redis.XAddArgs{
+		Stream: m.stream,
+		MinID:  strconv.FormatInt(time.Now().Add(-MirrorRetention).UnixMilli(), 10),
+		Approx: true,
+		Values: map[string]any{
+			"origin":  m.origin,
+			"subject": msg.Subject,
+			"msgID":   msg.MsgID,
+			"header":  header,
+			"data":    msg.Data,
+		},
+	}).Err()
+	if err != nil {
+		return fmt.Errorf("could not append %s to %s: %w", msg.Subject, m.stream, err)
+	}
+
+	return nil
+}
+
+func (m *redisMirror) Next(ctx context.Context) ([]MirroredMsg, error) {
+	// Start after whatever is in the stream already: it was published before
+	// this instance came up. "$" would do the same but only per call, losing
+	// whatever arrives between two reads.
+	if m.lastID == "" {
+		last, err := m.rdb.XRevRangeN(ctx, m.stream, "+", "-", 1).Result()
+		if err != nil {
+			return nil, fmt.Errorf("could not read the tail of %s: %w", m.stream, err)
+		}
+
+		m.lastID = "0-0"
+		if len(last) != 0 {
+			m.lastID = last[0].ID
+		}
+	}
+
+	streams, err := m.rdb.XRead(ctx, &redis.XReadArgs{
+		Streams: []string{m.stream, m.lastID},
+		Block:   MirrorBlock,
+	}).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("could not read %s: %w", m.stream, err)
+	}
+
+	var out []MirroredMsg
+	for _, stream := range streams {
+		for _, entry := range stream.Messages {
+			m.lastID = entry.ID
+
+			if entry.Values["origin"] == m.origin {
+				continue
+			}
+
+			subject, _ := entry.Values["subject"].(string)
+			msgID, _ := entry.Values["msgID"].(string)
+			data, _ := entry.Values["data"].(string)
+
+			header, ok := mirroredHeader(entry.Values)
+			if !ok {
+				continue
+			}
+
+			out = append(out, MirroredMsg{Subject: subject, MsgID: msgID, Header: header, Data: []byte(data)})
+		}
+	}
+
+	return out, nil
+}
+
+// mirroredHeader is the header the leader published with. A record without
+// one was not appended by a feeder and has no envelope to replay.
+func mirroredHeader(values map[string]any) (nats.Header, bool) {
+	raw, ok := values["header"].(string)
+	if !ok {
+		return nil, false
+	}
+
+	var header nats.Header
+	if err := json.Unmarshal([]byte(raw), &header); err != nil {
+		return nil, false
+	}
+
+	return header, true
+}
+
+func (w *Feeder) isLeader() bool {
+	return w.leader == nil || w.leader.IsLeader()
+}
+
+// RunMirror hands this instance's publishes to the other cells, and replays
+// what the leader published to this cell's JetStream. The replay keeps
+// running on the leader too: messages appended by the previous leader right
+// before a takeover still have to land here.
+func (w *Feeder) RunMirror(ctx context.Context, g *errgroup.Group) {
+	if w.mirror == nil {
+		return
+	}
+
+	g.Go(func() error {
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case msg := <-w.mirrorQueue:
+				w.appendMirror(ctx, msg)
+			}
+		}
+	})
+
+	g.Go(func() error {
+		for {
+			msgs, err := w.mirror.Next(ctx)
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			if err != nil {
+				w.log.Error(fmt.Sprintf("Mirror read error: %v", err))
+
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-time.After(MirrorRetryDelay):
+				}
+
+				continue
+			}
+
+			for _, msg := range msgs {
+				// The leader's msgID goes along, so JetStream drops what a new
+				// leader already republished here while resuming.
+				_, publishErr := w.js.PublishMsgAsync(&nats.Msg{Subject: msg.Subject, Header: msg.Header, Data: msg.Data},
+					jetstream.WithMsgID(msg.MsgID),
+					jetstream.WithRetryAttempts(JetStreamAttemptsWrite),
+					jetstream.WithRetryWait(JetStreamRetryWrite),
+				)
+				if publishErr != nil {
+					w.metricsStore.MirroredMessages.With(prometheus.Labels{metrics.Status: metrics.StatusFail}).Inc()
+					w.log.Error(fmt.Sprintf("Could not replay %s from the leader: %v", msg.Subject, publishErr))
+
+					continue
+				}
+
+				w.metricsStore.MirroredMessages.With(prometheus.Labels{metrics.Status: metrics.StatusOk}).Inc()
+			}
+		}
+	})
+}
+
+// offerMirror queues msg for the other cells, dropping the oldest message
+// still waiting if Redis is behind. A mirror failure is not the publish's
+// failure: this cell has the message, and the others catch up after a
+// takeover.
+func (w *Feeder) offerMirror(msg MirroredMsg) {
+	for {
+		select {
+		case w.mirrorQueue <- msg:
+			return
+		default:
+		}
+
+		select {
+		case <-w.mirrorQueue:
+			w.metricsStore.MirrorDroppedMessages.Inc()
+		default:
+		}
+	}
+}
+
+func (w *Feeder) appendMirror(ctx context.Context, msg MirroredMsg) {
+	ctx, cancel := context.WithTimeout(ctx, MirrorAppendTimeout)
+	defer cancel()
+
+	if err := w.mirror.Append(ctx, msg); err != nil {
+		w.metricsStore.MirrorAppendErrors.Inc()
+		w.log.Error(fmt.Sprintf("Could not mirror %s: %v", msg.Subject, err))
+	}
+}
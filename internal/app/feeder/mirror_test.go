@@ -0,0 +1,223 @@
+package feeder
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/lidofinance/onchain-mon/internal/pkg/chain/entity"
+	"github.com/lidofinance/onchain-mon/pkg/envelope"
+)
+
+type switchLeader struct{ leader atomic.Bool }
+
+func (s *switchLeader) IsLeader() bool { return s.leader.Load() }
+
+// Keeps what the leader appended and hands out what the test queued.
+type memMirror struct {
+	mu       sync.Mutex
+	appended []MirroredMsg
+	incoming chan []MirroredMsg
+}
+
+func (m *memMirror) Append(_ context.Context, msg MirroredMsg) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.appended = append(m.appended, msg)
+	return nil
+}
+
+func (m *memMirror) len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return len(m.appended)
+}
+
+func (m *memMirror) Next(ctx context.Context) ([]MirroredMsg, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case msgs := <-m.incoming:
+		return msgs, nil
+	}
+}
+
+// Counts every RPC call the feeder loop makes.
+type countingChain struct {
+	headChain
+	calls atomic.Int32
+}
+
+func (c *countingChain) GetLatestBlock(ctx context.Context) (*entity.RpcResponse[entity.EthBlock], error) {
+	c.calls.Add(1)
+	return c.headChain.GetLatestBlock(ctx)
+}
+
+func (c *countingChain) GetVerifiedReceipts(_ context.Context, _ *entity.EthBlock) (*entity.RpcResponse[[]entity.BlockReceipt], error) {
+	c.calls.Add(1)
+	empty := []entity.BlockReceipt{}
+	return &entity.RpcResponse[[]entity.BlockReceipt]{Result: &empty}, nil
+}
+
+func Test_standby_does_not_poll_until_elected(t *testing.T) {
+	c := &countingChain{headChain: headChain{latest: 100}}
+	lead := &switchLeader{}
+	mirror := &memMirror{}
+	heads := make(chanHeads, 1)
+
+	cfg := testConfig(c)
+	cfg.Mirror = mirror
+
+	js := &recordingJetStream{}
+	f := New(cfg)
+	f.js = js
+	f.topic = "blocks.test"
+	f.heads = heads
+	f.leader = lead
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	g, gCtx := errgroup.WithContext(ctx)
+	f.Run(gCtx, g)
+	f.RunMirror(gCtx, g)
+
+	// A head wakes the loop right away, and a standby must leave it at that.
+	heads <- entity.EthBlock{Number: "0x64"}
+	time.Sleep(100 * time.Millisecond)
+	if n := c.calls.Load(); n != 0 {
+		t.Fatalf("a standby made %d RPC calls", n)
+	}
+
+	lead.leader.Store(true)
+
+	// The standby loop re-checks every couple of seconds.
+	deadline := time.Now().Add(5 * time.Second)
+	for len(js.subjects()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("the elected feeder did not publish")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	// Appends run next to the publishing.
+	deadline = time.Now().Add(time.Second)
+	for mirror.len() != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("the leader handed %d messages to the mirror, want 1", mirror.len())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// Blocks until released, like a Redis that stopped answering.
+type stuckMirror struct {
+	memMirror
+	release chan struct{}
+}
+
+func (m *stuckMirror) Append(ctx context.Context, msg MirroredMsg) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-m.release:
+	}
+
+	return m.memMirror.Append(ctx, msg)
+}
+
+func Test_slow_mirror_does_not_hold_publishing_back(t *testing.T) {
+	mirror := &stuckMirror{release: make(chan struct{})}
+
+	cfg := testConfig(&canonicalChain{})
+	cfg.Mirror = mirror
+
+	f := New(cfg)
+	f.js = &recordingJetStream{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	g, gCtx := errgroup.WithContext(ctx)
+	f.RunMirror(gCtx, g)
+
+	before := testutil.ToFloat64(f.metricsStore.MirrorDroppedMessages)
+	payload := f.compressor.Compress([]byte("{}"))
+
+	publish := func(i int) {
+		_ = f.publishMsg("blocks.test", fmt.Sprintf("0x%d", i), envelope.Header(envelope.JSON, 0), payload)
+	}
+
+	// The first message gets stuck in the append.
+	publish(0)
+	for len(f.mirrorQueue) != 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		for i := range MirrorQueueSize + 9 {
+			publish(i + 1)
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("publishing waited for the mirror")
+	}
+
+	if dropped := testutil.ToFloat64(f.metricsStore.MirrorDroppedMessages) - before; dropped != 9 {
+		t.Errorf("dropped %v messages, want the 9 oldest beyond the queue", dropped)
+	}
+
+	close(mirror.release)
+}
+
+func Test_mirror_replays_the_leader_publishes(t *testing.T) {
+	mirror := &memMirror{incoming: make(chan []MirroredMsg, 1)}
+
+	js := &recordingJetStream{}
+	f := newTestFeeder(&canonicalChain{})
+	f.js = js
+	f.mirror = mirror
+
+	payload := f.compressor.Compress([]byte(`{"number":100}`))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	g, gCtx := errgroup.WithContext(ctx)
+	f.RunMirror(gCtx, g)
+
+	mirror.incoming <- []MirroredMsg{{Subject: "blocks.test", MsgID: "0x100", Header: envelope.Header(envelope.JSON, 0), Data: payload}}
+
+	deadline := time.Now().Add(time.Second)
+	for len(js.subjects()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("the mirrored message was not replayed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if got := js.msgs[0]; got.subject != "blocks.test" || string(got.payload) != `{"number":100}` {
+		t.Errorf("replayed %s %q, want the leader's message as is", got.subject, got.payload)
+	}
+
+	mirror.mu.Lock()
+	defer mirror.mu.Unlock()
+
+	if len(mirror.appended) != 0 {
+		t.Error("a replayed message must not be mirrored again")
+	}
+}
@@ -0,0 +1,52 @@
+package feeder
+
+import (
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// quantity parses an RPC quantity, 0x hex or decimal.
+func quantity(value string) int {
+	parsed, _ := strconv.ParseInt(value, 0, 64)
+	return int(parsed)
+}
+
+// optionalQuantity parses an RPC quantity the node may leave out, like the
+// gas fields of blocks before the fork that brought them.
+func optionalQuantity(value string) *int {
+	if value == "" {
+		return nil
+	}
+
+	parsed := quantity(value)
+	return &parsed
+}
+
+func optional(value string) *string {
+	if value == "" {
+		return nil
+	}
+
+	return &value
+}
+
+// weiString turns an RPC quantity into a decimal string. Amounts of wei
+// overflow int64, so they stay strings in the DTO.
+func weiString(quantity string) string {
+	value, ok := new(big.Int).SetString(strings.TrimPrefix(quantity, "0x"), 16)
+	if !ok {
+		return "0"
+	}
+
+	return value.String()
+}
+
+func optionalWei(quantity string) *string {
+	if quantity == "" {
+		return nil
+	}
+
+	value := weiString(quantity)
+	return &value
+}
@@ -0,0 +1,202 @@
+package feeder
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/lidofinance/onchain-mon/generated/databus"
+	"github.com/lidofinance/onchain-mon/internal/pkg/chain"
+	"github.com/lidofinance/onchain-mon/internal/pkg/chain/entity"
+)
+
+// ReorgWindow is how many published blocks the feeder remembers. Post-merge
+// mainnet reorgs are a block or two deep; anything past the window is still
+// republished, but its orphans can no longer be named.
+const ReorgWindow = 64
+
+// ReorgSubjectSuffix is appended to the block topic: blocks.mainnet.l1.reorg.
+const ReorgSubjectSuffix = `.reorg`
+
+type publishedBlock struct {
+	number int64
+	hash   string
+	// receipts are kept so the logs of an orphaned block can be handed back to
+	// bots as removed. Nil for blocks that never reached NATS.
+	receipts []databus.BlockDtoJsonReceiptsElem
+}
+
+// blockWindow holds the last ReorgWindow published blocks in ascending order.
+// The zero value is ready to use.
+type blockWindow struct {
+	blocks []publishedBlock
+}
+
+func (bw *blockWindow) push(b publishedBlock) {
+	// Republishing a height replaces everything from it onwards.
+	bw.truncateAfter(b.number - 1)
+
+	bw.blocks = append(bw.blocks, b)
+	if len(bw.blocks) > ReorgWindow {
+		bw.blocks = bw.blocks[len(bw.blocks)-ReorgWindow:]
+	}
+}
+
+func (bw *blockWindow) last() (publishedBlock, bool) {
+	if len(bw.blocks) == 0 {
+		return publishedBlock{}, false
+	}
+
+	return bw.blocks[len(bw.blocks)-1], true
+}
+
+func (bw *blockWindow) get(number int64) (publishedBlock, bool) {
+	if len(bw.blocks) == 0 {
+		return publishedBlock{}, false
+	}
+
+	idx := number - bw.blocks[0].number
+	if idx < 0 || idx >= int64(len(bw.blocks)) {
+		return publishedBlock{}, false
+	}
+
+	return bw.blocks[idx], true
+}
+
+// truncateAfter forgets every block above number.
+func (bw *blockWindow) truncateAfter(number int64) {
+	for len(bw.blocks) > 0 && bw.blocks[len(bw.blocks)-1].number > number {
+		bw.blocks = bw.blocks[:len(bw.blocks)-1]
+	}
+}
+
+func (w *Feeder) remember(dto *databus.BlockDtoJson, published bool) {
+	b := publishedBlock{number: int64(dto.Number), hash: dto.Hash}
+	if published {
+		b.receipts = dto.Receipts
+	}
+
+	w.window.push(b)
+	w.blockTimes.observe(b.number, int64(dto.Timestamp))
+	w.saveCursor(dto)
+}
+
+// isReorg reports whether head does not extend the last published block.
+// Only a direct successor can be checked: after a gap there is nothing to
+// compare its parent with.
+func (w *Feeder) isReorg(head *entity.EthBlock) bool {
+	last, ok := w.window.last()
+	if !ok || head.GetNumber() != last.number+1 {
+		return false
+	}
+
+	return head.ParentHash != last.hash
+}
+
+// handleReorg walks back from head until the canonical chain meets a block the
+// feeder published, announces the orphaned blocks on the reorg subject and
+// republishes the canonical ones in between. head itself is left to the
+// caller, which publishes it the usual way.
+//
+// The returned height is how far the published chain is consistent. On error
+// the caller resumes from there, so a half-done republish is finished by the
+// regular loop instead of leaving a gap.
+func (w *Feeder) handleReorg(ctx context.Context, head *entity.EthBlock) (int64, error) {
+	var orphans []publishedBlock
+
+	resumeFrom := head.GetNumber() - 1
+	number := head.GetNumber() - 1
+	canonicalHash := head.ParentHash
+	beyondWindow := false
+
+	for {
+		published, ok := w.window.get(number)
+		if !ok {
+			beyondWindow = true
+			break
+		}
+
+		if published.hash == canonicalHash {
+			break
+		}
+
+		orphans = append(orphans, published)
+
+		canonical, err := w.chainSrv.FetchBlockByNumber(ctx, number)
+		if err != nil {
+			return resumeFrom, fmt.Errorf("fetch canonical block %d: %w", number, err)
+		}
+		if canonical.Result == nil {
+			return resumeFrom, fmt.Errorf("fetch canonical block %d: %w", number, chain.ErrEmptyResponse)
+		}
+
+		canonicalHash = canonical.Result.ParentHash
+		number--
+	}
+
+	slices.Reverse(orphans)
+	reorg := buildReorgDto(number, canonicalHash, beyondWindow, orphans)
+	if err := w.publishCompressed(w.topic+ReorgSubjectSuffix, "reorg-"+head.ParentHash, reorg); err != nil {
+		return resumeFrom, fmt.Errorf("could not publish reorg: %w", err)
+	}
+
+	w.metricsStore.Reorgs.Inc()
+	w.metricsStore.LastReorgDepth.Set(float64(reorg.Depth))
+	w.log.Warn("Chain reorganization",
+		slog.Int("depth", reorg.Depth),
+		slog.Int64("commonAncestor", number),
+		slog.Bool("beyondWindow", beyondWindow),
+		slog.Int64("newHead", head.GetNumber()),
+	)
+
+	w.window.truncateAfter(number)
+	resumeFrom = number
+
+	// Everything between the ancestor and head is canonical now and has to be
+	// published again — bots saw the orphaned versions only.
+	for from := number + 1; from < head.GetNumber(); from += RecoverChunkSize {
+		to := min(from+RecoverChunkSize-1, head.GetNumber()-1)
+
+		last, err := w.recoverBlockRange(ctx, from, to)
+		if last != nil {
+			resumeFrom = last.GetNumber()
+		}
+		if err != nil {
+			return resumeFrom, fmt.Errorf("republish canonical range %d..%d: %w", from, to, err)
+		}
+		if resumeFrom != to {
+			return resumeFrom, fmt.Errorf("republished canonical range %d..%d only up to %d", from, to, resumeFrom)
+		}
+	}
+
+	return resumeFrom, nil
+}
+
+func buildReorgDto(ancestorNumber int64, ancestorHash string, beyondWindow bool, orphans []publishedBlock) databus.ReorgDtoJson {
+	orphaned := make([]databus.ReorgDtoJsonOrphanedElem, 0, len(orphans))
+	removedLogs := make([]databus.ReorgDtoJsonRemovedLogsElem, 0)
+
+	for i := range orphans {
+		orphaned = append(orphaned, databus.ReorgDtoJsonOrphanedElem{
+			Number: int(orphans[i].number),
+			Hash:   orphans[i].hash,
+		})
+
+		for j := range orphans[i].receipts {
+			for _, l := range orphans[i].receipts[j].Logs {
+				l.Removed = true
+				removedLogs = append(removedLogs, databus.ReorgDtoJsonRemovedLogsElem(l))
+			}
+		}
+	}
+
+	return databus.ReorgDtoJson{
+		Depth:                len(orphans),
+		CommonAncestorNumber: int(ancestorNumber),
+		CommonAncestorHash:   ancestorHash,
+		BeyondWindow:         beyondWindow,
+		Orphaned:             orphaned,
+		RemovedLogs:          removedLogs,
+	}
+}
@@ -0,0 +1,203 @@
+package feeder
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/lidofinance/onchain-mon/generated/databus"
+	"github.com/lidofinance/onchain-mon/internal/pkg/chain/entity"
+)
+
+type publishedMsg struct {
+	subject string
+	header  nats.Header
+	payload []byte
+}
+
+// Keeps every publish, decompressed, in order.
+type recordingJetStream struct {
+	jetstream.JetStream
+	mu   sync.Mutex
+	msgs []publishedMsg
+}
+
+func (r *recordingJetStream) PublishMsgAsync(msg *nats.Msg, _ ...jetstream.PublishOpt) (jetstream.PubAckFuture, error) {
+	dec, err := zstd.NewReader(nil)
+	if err != nil {
+		return nil, err
+	}
+	defer dec.Close()
+
+	payload, err := dec.DecodeAll(msg.Data, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	r.msgs = append(r.msgs, publishedMsg{subject: msg.Subject, header: msg.Header, payload: payload})
+	r.mu.Unlock()
+
+	return nil, nil
+}
+
+func (r *recordingJetStream) subjects() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	out := make([]string, 0, len(r.msgs))
+	for _, m := range r.msgs {
+		out = append(out, m.subject)
+	}
+
+	return out
+}
+
+// Serves a canonical chain where every block's parent is the previous one.
+type canonicalChain struct {
+	ChainSrv
+	suffix string
+}
+
+func (c *canonicalChain) block(n int64) entity.EthBlock {
+	return entity.EthBlock{
+		Number:     "0x" + strconv.FormatInt(n, 16),
+		Hash:       "0x" + strconv.FormatInt(n, 10) + c.suffix,
+		ParentHash: "0x" + strconv.FormatInt(n-1, 10) + c.suffix,
+	}
+}
+
+func (c *canonicalChain) FetchBlockByNumber(_ context.Context, n int64) (*entity.RpcResponse[entity.EthBlock], error) {
+	b := c.block(n)
+	return &entity.RpcResponse[entity.EthBlock]{Result: &b}, nil
+}
+
+func (c *canonicalChain) FetchBlocksInRange(_ context.Context, from, to int64) (*entity.RpcResponse[[]entity.EthBlock], error) {
+	blocks := make([]entity.EthBlock, 0, to-from+1)
+	for n := from; n <= to; n++ {
+		blocks = append(blocks, c.block(n))
+	}
+
+	return &entity.RpcResponse[[]entity.EthBlock]{Result: &blocks}, nil
+}
+
+func (c *canonicalChain) FetchReceipts(_ context.Context, _ []string) (*entity.RpcResponse[[]entity.BlockReceipt], error) {
+	empty := []entity.BlockReceipt{}
+	return &entity.RpcResponse[[]entity.BlockReceipt]{Result: &empty}, nil
+}
+
+func Test_reorg_is_announced_and_canonical_blocks_republished(t *testing.T) {
+	// The canonical chain hashes end in "b". The feeder published 100..103,
+	// but its 102 and 103 came from a fork that has since been orphaned.
+	canonical := &canonicalChain{suffix: "b"}
+
+	js := &recordingJetStream{}
+	f := newTestFeeder(canonical)
+	f.js = js
+	f.topic = "blocks.test"
+
+	for n := 100; n <= 103; n++ {
+		dto := databus.BlockDtoJson{Number: n, Hash: "0x" + strconv.Itoa(n) + "b"}
+		if n >= 102 {
+			dto.Hash = "0x" + strconv.Itoa(n)
+			dto.Receipts = []databus.BlockDtoJsonReceiptsElem{{
+				Logs: []databus.BlockDtoJsonReceiptsElemLogsElem{{Address: "0xlido", BlockNumber: n, BlockHash: dto.Hash}},
+			}}
+		}
+		f.remember(&dto, true)
+	}
+
+	head := canonical.block(104)
+	if !f.isReorg(&head) {
+		t.Fatal("parent hash mismatch was not detected")
+	}
+
+	resumeFrom, err := f.handleReorg(context.Background(), &head)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resumeFrom != 103 {
+		t.Errorf("resume point: got %d, want 103", resumeFrom)
+	}
+
+	want := []string{"blocks.test.reorg", "blocks.test", "blocks.test"}
+	got := js.subjects()
+	if len(got) != len(want) {
+		t.Fatalf("published %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("published %v, want %v", got, want)
+		}
+	}
+
+	var reorg databus.ReorgDtoJson
+	if err := json.Unmarshal(js.msgs[0].payload, &reorg); err != nil {
+		t.Fatalf("reorg payload does not match the schema: %v", err)
+	}
+	if reorg.Depth != 2 || reorg.CommonAncestorNumber != 101 || reorg.CommonAncestorHash != "0x101b" {
+		t.Errorf("got depth %d ancestor %d/%s, want 2 and 101/0x101b",
+			reorg.Depth, reorg.CommonAncestorNumber, reorg.CommonAncestorHash)
+	}
+	if reorg.Orphaned[0].Hash != "0x102" || reorg.Orphaned[1].Hash != "0x103" {
+		t.Errorf("orphans must be listed oldest first: %+v", reorg.Orphaned)
+	}
+	if len(reorg.RemovedLogs) != 2 {
+		t.Fatalf("expected the logs of both orphans, got %d", len(reorg.RemovedLogs))
+	}
+	for _, l := range reorg.RemovedLogs {
+		if !l.Removed {
+			t.Errorf("log of orphaned block %s is not marked removed", l.BlockHash)
+		}
+	}
+
+	// The window now follows the canonical chain, so the head extends it.
+	if f.isReorg(&head) {
+		t.Error("head still looks like a reorg after the canonical blocks were republished")
+	}
+}
+
+func Test_reorg_check_skips_non_successors(t *testing.T) {
+	f := newTestFeeder(&canonicalChain{})
+	f.remember(&databus.BlockDtoJson{Number: 100, Hash: "0x100"}, true)
+
+	// After a gap there is no parent to compare with.
+	gap := entity.EthBlock{Number: "0x66", ParentHash: "0xunknown"} // 102
+	if f.isReorg(&gap) {
+		t.Error("a block after a gap must not be treated as a reorg")
+	}
+
+	next := entity.EthBlock{Number: "0x65", ParentHash: "0x100"} // 101
+	if f.isReorg(&next) {
+		t.Error("a block extending the last published one is not a reorg")
+	}
+}
+
+func Test_block_window_keeps_only_the_last_blocks(t *testing.T) {
+	var bw blockWindow
+	for n := range int64(ReorgWindow + 10) {
+		bw.push(publishedBlock{number: n})
+	}
+
+	if len(bw.blocks) != ReorgWindow {
+		t.Fatalf("window holds %d blocks, want %d", len(bw.blocks), ReorgWindow)
+	}
+	if _, ok := bw.get(9); ok {
+		t.Error("block 9 should have been evicted")
+	}
+	if b, ok := bw.get(10); !ok || b.number != 10 {
+		t.Errorf("block 10 should be the oldest one kept, got %+v", b)
+	}
+
+	// Pushing an earlier height replaces everything above it.
+	bw.push(publishedBlock{number: 50, hash: "0xnew"})
+	if last, _ := bw.last(); last.number != 50 || last.hash != "0xnew" {
+		t.Errorf("got last %+v, want the replacement at 50", last)
+	}
+}
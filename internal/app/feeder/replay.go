@@ -0,0 +1,166 @@
+package feeder
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/lidofinance/onchain-mon/internal/pkg/archive"
+	"github.com/lidofinance/onchain-mon/pkg/envelope"
+)
+
+// ReplaySubjectPrefix starts every replay subject, so a replay can never
+// publish to a topic live bots follow.
+const ReplaySubjectPrefix = `replay.`
+
+// ReplaySubject is where replay id goes: replay.<id>.blocks, with its logs,
+// traces and chunks under it like a live topic.
+func ReplaySubject(id string) string {
+	return ReplaySubjectPrefix + id + ".blocks"
+}
+
+// IsReplaySubject reports whether subject is isolated from the live topics.
+func IsReplaySubject(subject string) bool {
+	return strings.HasPrefix(subject, ReplaySubjectPrefix) && len(subject) > len(ReplaySubjectPrefix)
+}
+
+// Replay publishes the blocks from..to to the feeder's topic the way recovery
+// does, logs and traces included, at most rate blocks a second (0 means as
+// fast as the RPC answers). It returns the last block it got through, so an
+// interrupted replay can go on from there.
+//
+// A replay keeps no cursor and remembers no blocks: it runs next to the live
+// feeder without touching its state.
+func (w *Feeder) Replay(ctx context.Context, from, to int64, rate float64) (int64, error) {
+	if !IsReplaySubject(w.topic) {
+		return from - 1, fmt.Errorf("replay subject %q must start with %s", w.topic, ReplaySubjectPrefix)
+	}
+
+	if from > to {
+		return from - 1, fmt.Errorf("empty range %d..%d", from, to)
+	}
+
+	pace, stop := newPace(rate)
+	defer stop()
+
+	last := from - 1
+	for chunkFrom := from; chunkFrom <= to; chunkFrom += RecoverChunkSize {
+		chunkTo := min(chunkFrom+RecoverChunkSize-1, to)
+
+		blocks, receiptsByBlock, err := w.fetchBlockRange(ctx, chunkFrom, chunkTo)
+		if err != nil {
+			return last, err
+		}
+
+		for i := range blocks {
+			block := blocks[i]
+
+			if err := waitPace(ctx, pace); err != nil {
+				return last, err
+			}
+
+			dto, dtoErr := w.buildDto(ctx, &block, receiptsByBlock[block.Hash])
+			if dtoErr != nil {
+				return last, dtoErr
+			}
+
+			if publishErr := w.publishBlock(w.topic, dto.Hash, dto); publishErr != nil {
+				if !isUnpublishable(publishErr) {
+					return last, publishErr
+				}
+
+				w.log.Error("Skipping unpublishable block",
+					slog.Int("blockNumber", dto.Number),
+					slog.String("error", publishErr.Error()),
+				)
+
+				last = block.GetNumber()
+				continue
+			}
+
+			w.publishLogs(&dto)
+			w.publishTraces(ctx, &block)
+
+			last = block.GetNumber()
+			w.log.Info(fmt.Sprintf("Replayed block %d", dto.Number))
+		}
+
+		// A short answer would silently skip heights on the next chunk.
+		if last < chunkTo {
+			return last, fmt.Errorf("range %d..%d replayed only up to %d", chunkFrom, chunkTo, last)
+		}
+	}
+
+	return last, nil
+}
+
+// ReplayArchive publishes the archived blocks from..to to the feeder's topic
+// byte for byte as they went out, paced like Replay. Only blocks are archived,
+// so no logs or traces go along. Heights missing from the archive are counted
+// and logged, not fetched. It returns the last block it got through.
+func (w *Feeder) ReplayArchive(ctx context.Context, dir string, from, to int64, rate float64) (int64, error) {
+	if !IsReplaySubject(w.topic) {
+		return from - 1, fmt.Errorf("replay subject %q must start with %s", w.topic, ReplaySubjectPrefix)
+	}
+
+	pace, stop := newPace(rate)
+	defer stop()
+
+	last := from - 1
+	missing := int64(0)
+
+	err := archive.Read(dir, from, to, func(e archive.Entry) error {
+		if err := waitPace(ctx, pace); err != nil {
+			return err
+		}
+
+		// The envelope the block went out with: the same encoding, the same
+		// dictionary, whatever the replaying feeder would use now.
+		if err := w.publishEnvelope(w.topic, e.Hash, envelope.Header(envelope.JSON, e.DictID), e.Payload); err != nil {
+			return fmt.Errorf("could not publish block %d: %w", e.Number, err)
+		}
+
+		missing += e.Number - last - 1
+		last = e.Number
+
+		w.log.Info(fmt.Sprintf("Replayed block %d from the archive", e.Number))
+
+		return nil
+	})
+
+	if err == nil {
+		missing += to - last
+	}
+
+	if missing > 0 {
+		w.log.Warn("Blocks missing from the archive were skipped", slog.Int64("missing", missing))
+	}
+
+	return last, err
+}
+
+// newPace ticks rate times a second; a nil channel with a rate of 0.
+func newPace(rate float64) (<-chan time.Time, func()) {
+	if rate <= 0 {
+		return nil, func() {}
+	}
+
+	ticker := time.NewTicker(time.Duration(float64(time.Second) / rate))
+
+	return ticker.C, ticker.Stop
+}
+
+func waitPace(ctx context.Context, pace <-chan time.Time) error {
+	if pace == nil {
+		return ctx.Err()
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-pace:
+		return nil
+	}
+}
@@ -0,0 +1,84 @@
+package feeder
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/lidofinance/onchain-mon/internal/pkg/archive"
+)
+
+func Test_replay_publishes_the_range_to_its_own_subject(t *testing.T) {
+	js := &recordingJetStream{}
+	f := newTestFeeder(&canonicalChain{})
+	f.js = js
+	f.topic = ReplaySubject("euler")
+
+	// Spans two fetch chunks.
+	last, err := f.Replay(context.Background(), 1000, 1000+RecoverChunkSize, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if last != 1000+RecoverChunkSize {
+		t.Errorf("got last %d, want %d", last, 1000+RecoverChunkSize)
+	}
+
+	subjects := js.subjects()
+	if len(subjects) != RecoverChunkSize+1 {
+		t.Fatalf("published %d blocks, want %d", len(subjects), RecoverChunkSize+1)
+	}
+	for _, s := range subjects {
+		if s != "replay.euler.blocks" {
+			t.Fatalf("block went to %s", s)
+		}
+	}
+
+	if len(f.window.blocks) != 0 {
+		t.Error("a replay must not touch the reorg window of the live feeder")
+	}
+}
+
+func Test_replay_refuses_live_topics(t *testing.T) {
+	f := newTestFeeder(&canonicalChain{})
+	f.js = &recordingJetStream{}
+	f.topic = "blocks.mainnet.l1"
+
+	if _, err := f.Replay(context.Background(), 1, 2, 0); err == nil {
+		t.Fatal("a replay to a live topic must be refused")
+	}
+}
+
+func Test_archived_blocks_replay_byte_for_byte(t *testing.T) {
+	dir := t.TempDir()
+
+	w, err := archive.NewWriter(dir, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hash := "0x" + strings.Repeat("ab", 32)
+	for _, n := range []int64{10, 12} {
+		if err := w.Append(n, hash, 0, testCompressor.Compress([]byte(`{"number":`+strconv.FormatInt(n, 10)+`}`))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_ = w.Close()
+
+	js := &recordingJetStream{}
+	f := newTestFeeder(&canonicalChain{})
+	f.js = js
+	f.topic = ReplaySubject("euler")
+
+	last, err := f.ReplayArchive(context.Background(), dir, 10, 13, 0)
+	if err != nil || last != 12 {
+		t.Fatalf("got %d, %v; want 12", last, err)
+	}
+
+	if len(js.msgs) != 2 || string(js.msgs[1].payload) != `{"number":12}` {
+		t.Fatalf("got %d messages, want blocks 10 and 12 as archived", len(js.msgs))
+	}
+	if js.msgs[0].subject != "replay.euler.blocks" {
+		t.Errorf("block went to %s", js.msgs[0].subject)
+	}
+}
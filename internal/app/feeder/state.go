@@ -0,0 +1,225 @@
+package feeder
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/lidofinance/onchain-mon/generated/databus"
+	"github.com/lidofinance/onchain-mon/internal/connectors/metrics"
+	"github.com/lidofinance/onchain-mon/internal/pkg/abi"
+	"github.com/lidofinance/onchain-mon/internal/pkg/chain"
+	"github.com/lidofinance/onchain-mon/internal/pkg/chain/entity"
+)
+
+// StateSubjectPrefix starts the subject of every chain's watched state:
+// state.mainnet, state.arbitrum.
+const StateSubjectPrefix = `state.`
+
+// StateSubject is where the watched state of chain goes.
+func StateSubject(chain string) string {
+	return StateSubjectPrefix + chain
+}
+
+// StateCaller runs eth_calls in one batch against one block; chain.NewChain
+// is the real one.
+type StateCaller interface {
+	CallBatch(ctx context.Context, calls []chain.EthCall, blockHash string) ([]chain.EthCallResult, error)
+}
+
+// StateWatcher is a view function called at published blocks, and the last
+// value it returned.
+type StateWatcher struct {
+	name     string
+	address  string
+	args     []string
+	fn       *abi.Function
+	call     chain.EthCall
+	interval time.Duration
+
+	// calledAt is the timestamp of the block of the last call; called is
+	// false until there was one.
+	calledAt int64
+	called   bool
+	// value is what the last successful call returned, at valueBlock.
+	value      []string
+	valueBlock int
+}
+
+// NewStateWatcher encodes the call of function (balanceOf(address)) on the
+// contract at address with args, decoding what it returns as returns. It
+// is called at every published block, or with a non-zero interval at the
+// first block at least interval after the last call, by block time.
+func NewStateWatcher(name, address, function string, args, returns []string, interval time.Duration) (*StateWatcher, error) {
+	fn, err := abi.ParseFunction(function, returns)
+	if err != nil {
+		return nil, fmt.Errorf("watcher '%s': %w", name, err)
+	}
+
+	if raw, hexErr := hex.DecodeString(strings.TrimPrefix(address, "0x")); hexErr != nil || len(raw) != 20 {
+		return nil, fmt.Errorf("watcher '%s': %q is not an address", name, address)
+	}
+
+	data, err := fn.Pack(args)
+	if err != nil {
+		return nil, fmt.Errorf("watcher '%s': %w", name, err)
+	}
+
+	if args == nil {
+		args = []string{}
+	}
+
+	return &StateWatcher{
+		name:     name,
+		address:  strings.ToLower(address),
+		args:     args,
+		fn:       fn,
+		call:     chain.EthCall{To: strings.ToLower(address), Data: "0x" + hex.EncodeToString(data)},
+		interval: interval,
+	}, nil
+}
+
+func (s *StateWatcher) due(timestamp int64) bool {
+	return !s.called || timestamp-s.calledAt >= int64(s.interval/time.Second)
+}
+
+// RunState calls watchers at the blocks the feeder publishes and publishes
+// their values to subject. The calls run next to the block stream, never in
+// its way: when they fall behind, the newest published block is the next one
+// called at and the ones in between are skipped. Call it before Run.
+func (w *Feeder) RunState(ctx context.Context, g *errgroup.Group, caller StateCaller, subject string, watchers []*StateWatcher) {
+	w.state = newBlockHandoff(1, w.metricsStore.StateSkippedBlocks)
+
+	g.Go(func() error {
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case block := <-w.state.blocks:
+				w.publishState(ctx, caller, subject, watchers, &block)
+			}
+		}
+	})
+}
+
+// publishState calls the watchers due at block in one batch. State is best
+// effort like traces: a batch that fails or does not publish is counted and
+// logged, and the watchers are called again at the next block.
+func (w *Feeder) publishState(ctx context.Context, caller StateCaller, subject string, watchers []*StateWatcher, block *entity.EthBlock) {
+	due := make([]*StateWatcher, 0, len(watchers))
+	for _, s := range watchers {
+		if s.due(block.GetTimestamp()) {
+			due = append(due, s)
+		}
+	}
+
+	if len(due) == 0 {
+		return
+	}
+
+	calls := make([]chain.EthCall, 0, len(due))
+	for _, s := range due {
+		calls = append(calls, s.call)
+	}
+
+	results, err := caller.CallBatch(ctx, calls, block.Hash)
+	if err != nil {
+		w.metricsStore.StateSnapshots.With(prometheus.Labels{metrics.Status: metrics.StatusFail}).Inc()
+		w.log.Error(fmt.Sprintf("Could not call state watchers at block %d: %v", block.GetNumber(), err))
+		return
+	}
+
+	dto, values := buildStateDto(block, due, results)
+	for _, v := range dto.Values {
+		if v.Error != nil {
+			w.metricsStore.StateCallErrors.With(prometheus.Labels{metrics.Watcher: v.Name}).Inc()
+		}
+	}
+
+	if !w.isLeader() {
+		return
+	}
+
+	if publishErr := w.publishCompressed(subject, "state-"+block.Hash, dto); publishErr != nil {
+		w.metricsStore.StateSnapshots.With(prometheus.Labels{metrics.Status: metrics.StatusFail}).Inc()
+		w.log.Error(fmt.Sprintf("Could not publish state at block %d: %v", block.GetNumber(), publishErr),
+			slog.Int("watchers", len(due)),
+		)
+
+		return
+	}
+
+	for i, s := range due {
+		s.called = true
+		s.calledAt = block.GetTimestamp()
+
+		if values[i] != nil {
+			s.value = values[i]
+			s.valueBlock = dto.BlockNumber
+		}
+	}
+
+	w.metricsStore.StateSnapshots.With(prometheus.Labels{metrics.Status: metrics.StatusOk}).Inc()
+}
+
+// buildStateDto puts what the calls of watchers returned next to what they
+// returned before. It also returns the decoded values, nil for a call that
+// failed, for the watchers to remember once the state is out.
+func buildStateDto(block *entity.EthBlock, watchers []*StateWatcher, results []chain.EthCallResult) (databus.StateDtoJson, [][]string) {
+	elems := make([]databus.StateDtoJsonValuesElem, 0, len(watchers))
+	values := make([][]string, len(watchers))
+
+	for i, s := range watchers {
+		elem := databus.StateDtoJsonValuesElem{
+			Name:     s.name,
+			Address:  s.address,
+			Function: s.fn.Signature,
+			Args:     s.args,
+		}
+
+		if s.value != nil {
+			previousBlock := s.valueBlock
+			elem.Previous = s.value
+			elem.PreviousBlockNumber = &previousBlock
+		}
+
+		current, err := decodeState(s, &results[i])
+		if err != nil {
+			reason := err.Error()
+			elem.Error = &reason
+		} else {
+			elem.Current = current
+			elem.Changed = s.value != nil && !slices.Equal(current, s.value)
+			values[i] = current
+		}
+
+		elems = append(elems, elem)
+	}
+
+	return databus.StateDtoJson{
+		BlockNumber: int(block.GetNumber()),
+		BlockHash:   block.Hash,
+		Timestamp:   int(block.GetTimestamp()),
+		Values:      elems,
+	}, values
+}
+
+func decodeState(s *StateWatcher, result *chain.EthCallResult) ([]string, error) {
+	if result.Err != nil {
+		return nil, result.Err
+	}
+
+	output, err := hex.DecodeString(strings.TrimPrefix(result.Output, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("malformed output %q: %w", result.Output, err)
+	}
+
+	return s.fn.Unpack(output)
+}
@@ -0,0 +1,165 @@
+package feeder
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/lidofinance/onchain-mon/generated/databus"
+	"github.com/lidofinance/onchain-mon/internal/pkg/chain"
+	"github.com/lidofinance/onchain-mon/internal/pkg/chain/entity"
+)
+
+const (
+	lido  = "0xae7ab96520de3a18e5e111b5eaab095312d7fe84"
+	queue = "0x889edc2edab5f40e902b864ad4d7ade8e412f9b1"
+)
+
+// fakeStateCaller answers every call to a contract with its entry in outputs,
+// and reverts calls to contracts without one.
+type fakeStateCaller struct {
+	outputs map[string]string
+	pinned  []string
+}
+
+func (c *fakeStateCaller) CallBatch(_ context.Context, calls []chain.EthCall, blockHash string) ([]chain.EthCallResult, error) {
+	c.pinned = append(c.pinned, blockHash)
+
+	results := make([]chain.EthCallResult, 0, len(calls))
+	for _, call := range calls {
+		output, ok := c.outputs[call.To]
+		if !ok {
+			results = append(results, chain.EthCallResult{Err: &chain.CallError{RpcError: entity.RpcError{Code: chain.RevertedCode, Message: "execution reverted"}}})
+			continue
+		}
+
+		results = append(results, chain.EthCallResult{Output: output})
+	}
+
+	return results, nil
+}
+
+func word(n int) string {
+	return "0x" + strconv.FormatInt(int64(n), 16)
+}
+
+// abiWord is n as the one 32-byte word a view function returns.
+func abiWord(n int) string {
+	return fmt.Sprintf("0x%064x", n)
+}
+
+func mustWatcher(t *testing.T, name, address, function string, returns []string, interval time.Duration) *StateWatcher {
+	t.Helper()
+
+	s, err := NewStateWatcher(name, address, function, nil, returns, interval)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return s
+}
+
+func Test_state_carries_previous_values_and_honours_intervals(t *testing.T) {
+	caller := &fakeStateCaller{outputs: map[string]string{
+		lido:  abiWord(1),
+		queue: abiWord(1),
+	}}
+
+	watchers := []*StateWatcher{
+		mustWatcher(t, "buffered_ether", lido, "getBufferedEther()", []string{"uint256"}, 0),
+		mustWatcher(t, "queue_paused", queue, "isPaused()", []string{"bool"}, time.Minute),
+		mustWatcher(t, "broken", "0x00000000000000000000000000000000000000ff", "totalSupply()", []string{"uint256"}, 0),
+	}
+
+	js := &recordingJetStream{}
+	f := newTestFeeder(&canonicalChain{})
+	f.js = js
+
+	publish := func(number, timestamp int) databus.StateDtoJson {
+		t.Helper()
+
+		before := len(js.msgs)
+		block := entity.EthBlock{Number: word(number), Hash: "0xb" + strconv.Itoa(number), Timestamp: word(timestamp)}
+		f.publishState(context.Background(), caller, StateSubject("test"), watchers, &block)
+
+		if len(js.msgs) != before+1 || js.msgs[before].subject != "state.test" {
+			t.Fatalf("block %d: want one message to state.test, got %v", number, js.subjects())
+		}
+
+		var dto databus.StateDtoJson
+		if err := json.Unmarshal(js.msgs[before].payload, &dto); err != nil {
+			t.Fatal(err)
+		}
+
+		return dto
+	}
+
+	first := publish(100, 1_000)
+	if caller.pinned[0] != "0xb100" {
+		t.Errorf("calls pinned to %s, want the published block", caller.pinned[0])
+	}
+	if len(first.Values) != 3 {
+		t.Fatalf("got %d values, want all 3 watchers", len(first.Values))
+	}
+
+	buffered, paused, broken := first.Values[0], first.Values[1], first.Values[2]
+	if !slices.Equal(buffered.Current, []string{"1"}) || buffered.Previous != nil || buffered.Changed {
+		t.Errorf("buffered_ether: %+v", buffered)
+	}
+	if !slices.Equal(paused.Current, []string{"true"}) {
+		t.Errorf("queue_paused: %+v", paused)
+	}
+	if broken.Current != nil || broken.Error == nil {
+		t.Errorf("a revert must come as an error: %+v", broken)
+	}
+	if buffered.Function != "getBufferedEther()" || buffered.Args == nil {
+		t.Errorf("call not described: %+v", buffered)
+	}
+
+	caller.outputs[lido] = abiWord(2)
+
+	// Twelve seconds later queue_paused is not due.
+	second := publish(101, 1_012)
+	if len(second.Values) != 2 || second.Values[0].Name != "buffered_ether" || second.Values[1].Name != "broken" {
+		t.Fatalf("got %+v, want buffered_ether and broken", second.Values)
+	}
+
+	buffered = second.Values[0]
+	if !slices.Equal(buffered.Current, []string{"2"}) || !slices.Equal(buffered.Previous, []string{"1"}) || !buffered.Changed {
+		t.Errorf("buffered_ether: %+v", buffered)
+	}
+	if buffered.PreviousBlockNumber == nil || *buffered.PreviousBlockNumber != 100 {
+		t.Errorf("previous block: got %v, want 100", buffered.PreviousBlockNumber)
+	}
+
+	third := publish(102, 1_060)
+	if len(third.Values) != 3 || third.Values[0].Changed || third.Values[1].Changed {
+		t.Errorf("a minute later every watcher is due and nothing changed: %+v", third.Values)
+	}
+}
+
+func Test_state_watcher_is_rejected_when_its_call_cannot_be_encoded(t *testing.T) {
+	tests := []struct {
+		name     string
+		address  string
+		function string
+		args     []string
+	}{
+		{"bad_address", "0xlido", "totalSupply()", nil},
+		{"bad_function", lido, "totalSupply", nil},
+		{"missing_arg", lido, "balanceOf(address)", nil},
+		{"bad_arg", lido, "balanceOf(address)", []string{"0x01"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewStateWatcher(tt.name, tt.address, tt.function, tt.args, []string{"uint256"}, 0); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,284 @@
+package feeder
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/lidofinance/onchain-mon/generated/databus"
+	"github.com/lidofinance/onchain-mon/internal/connectors/metrics"
+	"github.com/lidofinance/onchain-mon/internal/pkg/chain"
+	"github.com/lidofinance/onchain-mon/internal/pkg/chain/entity"
+)
+
+// StorageSubjectPrefix starts the subject of every chain's storage slot
+// diffs: storage.mainnet, storage.arbitrum.
+const StorageSubjectPrefix = `storage.`
+
+// StorageSubject is where the storage slot diffs of chain go.
+func StorageSubject(chain string) string {
+	return StorageSubjectPrefix + chain
+}
+
+// EIP-1967 proxy slots: keccak256 of eip1967.proxy.implementation, .admin and
+// .beacon, minus one.
+const (
+	ImplementationSlot = `0x360894a13ba1a3210667c828492db98dca3e2076cc3735a920a3ca505d382bbc`
+	AdminSlot          = `0xb53127684a568b3173ae13b9f8a6016e243e63b6e8ee1178d6a717850b5d6103`
+	BeaconSlot         = `0xa3f0ad74e5423aebfd80d3ef4346578335a9a72aeaee59ff6cb3582b35133d50`
+)
+
+var namedSlots = map[string]string{
+	"implementation": ImplementationSlot,
+	"admin":          AdminSlot,
+	"beacon":         BeaconSlot,
+}
+
+var maxWord = new(big.Int).Lsh(big.NewInt(1), 256)
+
+// StorageReader reads storage slots in one batch against one block, and
+// tells which transactions of a block wrote which slots; chain.NewChain is
+// the real one.
+type StorageReader interface {
+	StorageBatch(ctx context.Context, slots []chain.StorageSlot, blockHash string) ([]string, error)
+	TraceBlockStateDiffs(ctx context.Context, block *entity.EthBlock) (*entity.RpcResponse[[]entity.TxStateDiff], error)
+}
+
+// StorageWatcher is a storage slot read at published blocks, and the word it
+// held at the last one.
+type StorageWatcher struct {
+	name string
+	slot chain.StorageSlot
+
+	// value is the word of the last read, at valueBlock; read is false until
+	// there was one.
+	value      string
+	valueBlock int
+	read       bool
+}
+
+// NewStorageWatcher watches slot of the contract at address: implementation,
+// admin or beacon for the EIP-1967 proxy slots, or any slot as a decimal or
+// 0x hex number.
+func NewStorageWatcher(name, address, slot string) (*StorageWatcher, error) {
+	if raw, hexErr := hex.DecodeString(strings.TrimPrefix(address, "0x")); hexErr != nil || len(raw) != 20 {
+		return nil, fmt.Errorf("slot '%s': %q is not an address", name, address)
+	}
+
+	key, ok := namedSlots[strings.ToLower(slot)]
+	if !ok {
+		var err error
+		if key, err = storageWord(slot); err != nil {
+			return nil, fmt.Errorf("slot '%s': %w", name, err)
+		}
+	}
+
+	return &StorageWatcher{
+		name: name,
+		slot: chain.StorageSlot{Address: strings.ToLower(address), Slot: key},
+	}, nil
+}
+
+// storageWord is s, a decimal or 0x hex number, as a 0x-prefixed 32-byte word
+// in lower case.
+func storageWord(s string) (string, error) {
+	n, ok := new(big.Int).SetString(s, 0)
+	if !ok || n.Sign() < 0 || n.Cmp(maxWord) >= 0 {
+		return "", fmt.Errorf("%q is not a 32-byte word", s)
+	}
+
+	return fmt.Sprintf("0x%064x", n), nil
+}
+
+// RunStorage reads slots at the blocks the feeder publishes and publishes the
+// ones that changed to subject. Like RunState it runs next to the block
+// stream and skips to the newest published block when it falls behind. Call
+// it before Run.
+func (w *Feeder) RunStorage(ctx context.Context, g *errgroup.Group, reader StorageReader, subject string, slots []*StorageWatcher) {
+	w.storage = newBlockHandoff(1, w.metricsStore.StorageSkippedBlocks)
+
+	g.Go(func() error {
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case block := <-w.storage.blocks:
+				w.publishStorage(ctx, reader, subject, slots, &block)
+			}
+		}
+	})
+}
+
+// publishStorage reads every slot at block in one batch and publishes the
+// ones that differ from the last read. The first read of a slot only sets
+// what the next ones are compared against. Words read by an instance that
+// lost the lease meanwhile still become its baseline, with only the publish
+// left to the leader, so it compares against them should it lead again. A
+// batch that fails is counted and logged, and the slots are read again at the
+// next block.
+func (w *Feeder) publishStorage(ctx context.Context, reader StorageReader, subject string, slots []*StorageWatcher, block *entity.EthBlock) {
+	keys := make([]chain.StorageSlot, 0, len(slots))
+	for _, s := range slots {
+		keys = append(keys, s.slot)
+	}
+
+	words, err := reader.StorageBatch(ctx, keys, block.Hash)
+	if err == nil {
+		err = normalizeWords(words, len(slots))
+	}
+
+	if err != nil {
+		w.metricsStore.StorageReadErrors.Inc()
+		w.log.Error(fmt.Sprintf("Could not read storage slots at block %d: %v", block.GetNumber(), err))
+		return
+	}
+
+	dto := buildStorageDto(block, slots, words)
+
+	if len(dto.Diffs) > 0 && w.isLeader() {
+		w.attributeDiffs(ctx, reader, block, &dto)
+
+		if publishErr := w.publishCompressed(subject, "storage-"+block.Hash, dto); publishErr != nil {
+			w.metricsStore.StoragePublished.With(prometheus.Labels{metrics.Status: metrics.StatusFail}).Inc()
+			w.log.Error(fmt.Sprintf("Could not publish storage diffs at block %d: %v", block.GetNumber(), publishErr),
+				slog.Int("diffs", len(dto.Diffs)),
+			)
+
+			return
+		}
+
+		w.metricsStore.StoragePublished.With(prometheus.Labels{metrics.Status: metrics.StatusOk}).Inc()
+		for _, d := range dto.Diffs {
+			w.metricsStore.StorageDiffs.With(prometheus.Labels{metrics.Watcher: d.Name}).Inc()
+		}
+	}
+
+	for i, s := range slots {
+		s.value = words[i]
+		s.valueBlock = dto.BlockNumber
+		s.read = true
+	}
+}
+
+// normalizeWords checks there is a word per slot and writes each one as
+// storageWord does, for nodes that leave out the leading zeroes.
+func normalizeWords(words []string, n int) error {
+	if len(words) != n {
+		return fmt.Errorf("got %d words for %d slots", len(words), n)
+	}
+
+	for i, word := range words {
+		normalized, err := storageWord(word)
+		if err != nil {
+			return err
+		}
+
+		words[i] = normalized
+	}
+
+	return nil
+}
+
+// buildStorageDto lists the slots whose word at block differs from the last
+// one read.
+func buildStorageDto(block *entity.EthBlock, slots []*StorageWatcher, words []string) databus.StorageDtoJson {
+	diffs := make([]databus.StorageDtoJsonDiffsElem, 0)
+
+	for i, s := range slots {
+		if !s.read || words[i] == s.value {
+			continue
+		}
+
+		diffs = append(diffs, databus.StorageDtoJsonDiffsElem{
+			Name:                s.name,
+			Address:             s.slot.Address,
+			Slot:                s.slot.Slot,
+			Previous:            s.value,
+			Current:             words[i],
+			PreviousBlockNumber: s.valueBlock,
+		})
+	}
+
+	return databus.StorageDtoJson{
+		BlockNumber: int(block.GetNumber()),
+		BlockHash:   block.Hash,
+		Timestamp:   int(block.GetTimestamp()),
+		Diffs:       diffs,
+	}
+}
+
+// attributeDiffs sets the transaction that wrote each slot of dto compared
+// against the parent block, the only ones block itself must have changed.
+// The state diffs of the block are as heavy as its traces, so they are asked
+// for once and only then; without them the diffs go out unattributed.
+func (w *Feeder) attributeDiffs(ctx context.Context, reader StorageReader, block *entity.EthBlock, dto *databus.StorageDtoJson) {
+	parent := dto.BlockNumber - 1
+
+	pending := false
+	for _, d := range dto.Diffs {
+		pending = pending || d.PreviousBlockNumber == parent
+	}
+
+	if !pending || len(block.Transactions) == 0 {
+		return
+	}
+
+	resp, err := reader.TraceBlockStateDiffs(ctx, block)
+	if err != nil {
+		w.log.Warn(fmt.Sprintf("Could not tell which transactions of block %d changed storage: %v", block.GetNumber(), err))
+		return
+	}
+
+	for i := range dto.Diffs {
+		d := &dto.Diffs[i]
+		if d.PreviousBlockNumber != parent {
+			continue
+		}
+
+		d.TransactionHash = lastWriter(*resp.Result, block, d.Address, d.Slot)
+	}
+}
+
+// lastWriter is the hash of the last transaction of block whose state diff
+// has slot of address, nil when none has.
+func lastWriter(diffs []entity.TxStateDiff, block *entity.EthBlock, address, slot string) *string {
+	for i := len(diffs) - 1; i >= 0; i-- {
+		if !wroteSlot(diffs[i].Result.Pre, address, slot) && !wroteSlot(diffs[i].Result.Post, address, slot) {
+			continue
+		}
+
+		txHash := diffs[i].TxHash
+		if txHash == "" && i < len(block.Transactions) {
+			txHash = block.Transactions[i]
+		}
+
+		return &txHash
+	}
+
+	return nil
+}
+
+// wroteSlot tells whether accounts, one side of a state diff, has slot of
+// address. Written slots are on both sides, except for a slot set from zero,
+// only in post, and one cleared, only in pre.
+func wroteSlot(accounts map[string]entity.AccountDiff, address, slot string) bool {
+	for account, diff := range accounts {
+		if !strings.EqualFold(account, address) {
+			continue
+		}
+
+		for key := range diff.Storage {
+			if strings.EqualFold(key, slot) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
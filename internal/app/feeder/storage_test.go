@@ -0,0 +1,218 @@
+package feeder
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/lidofinance/onchain-mon/generated/databus"
+	"github.com/lidofinance/onchain-mon/internal/pkg/chain"
+	"github.com/lidofinance/onchain-mon/internal/pkg/chain/entity"
+)
+
+// fakeStorageReader answers every slot with its entry in words, keyed by
+// slot, and every trace with diffs.
+type fakeStorageReader struct {
+	words  map[string]string
+	diffs  []entity.TxStateDiff
+	traced int
+	err    error
+}
+
+func (r *fakeStorageReader) StorageBatch(_ context.Context, slots []chain.StorageSlot, _ string) ([]string, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	words := make([]string, 0, len(slots))
+	for _, s := range slots {
+		words = append(words, r.words[s.Slot])
+	}
+
+	return words, nil
+}
+
+func (r *fakeStorageReader) TraceBlockStateDiffs(context.Context, *entity.EthBlock) (*entity.RpcResponse[[]entity.TxStateDiff], error) {
+	r.traced++
+	return &entity.RpcResponse[[]entity.TxStateDiff]{Result: &r.diffs}, nil
+}
+
+func mustStorageWatcher(t *testing.T, name, slot string) *StorageWatcher {
+	t.Helper()
+
+	s, err := NewStorageWatcher(name, queue, slot)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return s
+}
+
+func stateDiff(txHash, slot string) entity.TxStateDiff {
+	var d entity.TxStateDiff
+	d.TxHash = txHash
+	d.Result.Post = map[string]entity.AccountDiff{
+		// Nodes may checksum the address.
+		"0x889edC2eDab5f40e902b864aD4d7AdE8E412F9B1": {Storage: map[string]string{slot: abiWord(1)}},
+	}
+
+	return d
+}
+
+func Test_storage_publishes_only_the_slots_that_changed(t *testing.T) {
+	reader := &fakeStorageReader{words: map[string]string{
+		ImplementationSlot: abiWord(1),
+		abiWord(0):         abiWord(7),
+	}}
+
+	slots := []*StorageWatcher{
+		mustStorageWatcher(t, "queue_implementation", "implementation"),
+		mustStorageWatcher(t, "queue_slot_0", "0"),
+	}
+
+	js := &recordingJetStream{}
+	f := newTestFeeder(&canonicalChain{})
+	f.js = js
+
+	publish := func(number int, txs ...string) {
+		block := entity.EthBlock{Number: word(number), Hash: "0xb" + word(number), Transactions: txs}
+		f.publishStorage(context.Background(), reader, StorageSubject("test"), slots, &block)
+	}
+
+	// The first read is what the next ones are compared against.
+	publish(100)
+	publish(101)
+	if len(js.msgs) != 0 {
+		t.Fatalf("nothing changed, got %v", js.subjects())
+	}
+
+	reader.words[ImplementationSlot] = "0x2"
+	reader.diffs = []entity.TxStateDiff{
+		stateDiff("0xt1", ImplementationSlot),
+		stateDiff("0xt2", abiWord(5)),
+	}
+	publish(102, "0xt1", "0xt2")
+
+	if len(js.msgs) != 1 || js.msgs[0].subject != "storage.test" {
+		t.Fatalf("want one message to storage.test, got %v", js.subjects())
+	}
+
+	var dto databus.StorageDtoJson
+	if err := json.Unmarshal(js.msgs[0].payload, &dto); err != nil {
+		t.Fatal(err)
+	}
+
+	if dto.BlockNumber != 102 || len(dto.Diffs) != 1 {
+		t.Fatalf("got %+v, want the implementation slot at block 102", dto)
+	}
+
+	d := dto.Diffs[0]
+	if d.Name != "queue_implementation" || d.Slot != ImplementationSlot || d.Address != queue {
+		t.Errorf("slot not described: %+v", d)
+	}
+	if d.Previous != abiWord(1) || d.Current != abiWord(2) || d.PreviousBlockNumber != 101 {
+		t.Errorf("diff: %+v", d)
+	}
+	if d.TransactionHash == nil || *d.TransactionHash != "0xt1" {
+		t.Errorf("got transaction %v, want 0xt1", d.TransactionHash)
+	}
+
+	// Blocks 103 and 104 were skipped: the change may be theirs, so it is
+	// not pinned on a transaction of 105.
+	reader.words[abiWord(0)] = abiWord(8)
+	publish(105, "0xt3")
+
+	if err := json.Unmarshal(js.msgs[1].payload, &dto); err != nil {
+		t.Fatal(err)
+	}
+	if len(dto.Diffs) != 1 || dto.Diffs[0].PreviousBlockNumber != 102 || dto.Diffs[0].TransactionHash != nil {
+		t.Errorf("got %+v, want slot 0 changed since 102 with no transaction", dto.Diffs)
+	}
+	if reader.traced != 1 {
+		t.Errorf("traced %d blocks, want only the one whose diffs are pinned", reader.traced)
+	}
+}
+
+func Test_storage_keeps_the_baseline_when_a_read_fails(t *testing.T) {
+	reader := &fakeStorageReader{words: map[string]string{AdminSlot: abiWord(1)}}
+	slots := []*StorageWatcher{mustStorageWatcher(t, "queue_admin", "admin")}
+
+	js := &recordingJetStream{}
+	f := newTestFeeder(&canonicalChain{})
+	f.js = js
+
+	f.publishStorage(context.Background(), reader, StorageSubject("test"), slots, &entity.EthBlock{Number: word(1), Hash: "0xb1"})
+
+	reader.err = errors.New("all endpoints failed")
+	f.publishStorage(context.Background(), reader, StorageSubject("test"), slots, &entity.EthBlock{Number: word(2), Hash: "0xb2"})
+
+	reader.err = nil
+	reader.words[AdminSlot] = abiWord(2)
+	f.publishStorage(context.Background(), reader, StorageSubject("test"), slots, &entity.EthBlock{Number: word(3), Hash: "0xb3"})
+
+	var dto databus.StorageDtoJson
+	if len(js.msgs) != 1 {
+		t.Fatalf("want one message, got %v", js.subjects())
+	}
+	if err := json.Unmarshal(js.msgs[0].payload, &dto); err != nil {
+		t.Fatal(err)
+	}
+	if dto.Diffs[0].PreviousBlockNumber != 1 {
+		t.Errorf("previous block: got %d, want 1", dto.Diffs[0].PreviousBlockNumber)
+	}
+}
+
+func Test_storage_baseline_moves_on_a_standby_too(t *testing.T) {
+	reader := &fakeStorageReader{words: map[string]string{AdminSlot: abiWord(1)}}
+	slots := []*StorageWatcher{mustStorageWatcher(t, "queue_admin", "admin")}
+
+	lead := &switchLeader{}
+	lead.leader.Store(true)
+
+	js := &recordingJetStream{}
+	f := newTestFeeder(&canonicalChain{})
+	f.js = js
+	f.leader = lead
+
+	publish := func(number int) {
+		block := entity.EthBlock{Number: word(number), Hash: "0xb" + word(number)}
+		f.publishStorage(context.Background(), reader, StorageSubject("test"), slots, &block)
+	}
+
+	publish(1)
+
+	// The lease moved while block 2 was read: the change is the new leader's
+	// to publish, and the same change must not come back from here.
+	lead.leader.Store(false)
+	reader.words[AdminSlot] = abiWord(2)
+	publish(2)
+
+	lead.leader.Store(true)
+	publish(3)
+
+	if len(js.msgs) != 0 {
+		t.Fatalf("published %v, want nothing: the slot has not changed since block 2", js.subjects())
+	}
+}
+
+func Test_storage_watcher_is_rejected_when_its_slot_cannot_be_read(t *testing.T) {
+	tests := []struct {
+		name    string
+		address string
+		slot    string
+	}{
+		{"bad_address", "0xlido", "implementation"},
+		{"unknown_name", lido, "proxy"},
+		{"negative", lido, "-1"},
+		{"too_long", lido, "0x1" + abiWord(0)[2:]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewStorageWatcher(tt.name, tt.address, tt.slot); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,206 @@
+package feeder
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/lidofinance/onchain-mon/generated/databus"
+	"github.com/lidofinance/onchain-mon/internal/connectors/metrics"
+	"github.com/lidofinance/onchain-mon/internal/pkg/chain"
+	"github.com/lidofinance/onchain-mon/internal/pkg/chain/entity"
+)
+
+// TraceSubjectSuffix is appended to the block topic: blocks.mainnet.l1.traces.
+const TraceSubjectSuffix = `.traces`
+
+// TraceQueueSize is how many published blocks wait for their traces before
+// the oldest one is dropped. Tracing a block takes a while on a busy node; a
+// run of them slower than the chain must not pile up without end.
+const TraceQueueSize = 64
+
+// runTraces traces the blocks Run publishes next to the block stream, so a
+// slow debug_traceBlockByHash never delays the next block.
+func (w *Feeder) runTraces(ctx context.Context, g *errgroup.Group) {
+	if w.traceAPI == "" {
+		return
+	}
+
+	w.traces = newBlockHandoff(TraceQueueSize, w.metricsStore.TracesSkippedBlocks)
+
+	g.Go(func() error {
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case block := <-w.traces.blocks:
+				w.publishTraces(ctx, &block)
+			}
+		}
+	})
+}
+
+// publishTraces publishes the internal calls of a block that has just been
+// published, keyed by its hash. Traces are best effort: a block whose traces
+// cannot be fetched or do not fit into a message is counted and logged, and
+// the block stream goes on without them.
+func (w *Feeder) publishTraces(ctx context.Context, block *entity.EthBlock) {
+	if w.traceAPI == "" {
+		return
+	}
+
+	dto, err := w.fetchTraces(ctx, block)
+	if err != nil {
+		w.metricsStore.TracesPublished.With(prometheus.Labels{metrics.Status: metrics.StatusFail}).Inc()
+		w.log.Error(fmt.Sprintf("Could not trace block %d: %v", block.GetNumber(), err))
+		return
+	}
+
+	if publishErr := w.publishCompressed(w.topic+TraceSubjectSuffix, "trace-"+block.Hash, dto); publishErr != nil {
+		w.metricsStore.TracesPublished.With(prometheus.Labels{metrics.Status: metrics.StatusFail}).Inc()
+
+		if isUnpublishable(publishErr) {
+			w.metricsStore.UnpublishedBlock(metrics.ReasonTraceMaxPayload, block.GetNumber())
+			w.log.Error("Skipping unpublishable traces",
+				slog.Int64("blockNumber", block.GetNumber()),
+				slog.Int("calls", len(dto.Calls)),
+				slog.String("reason", metrics.ReasonTraceMaxPayload),
+				slog.String("error", publishErr.Error()),
+			)
+
+			return
+		}
+
+		w.log.Error(fmt.Sprintf("Could not publish traces of block %d: %v", block.GetNumber(), publishErr))
+		return
+	}
+
+	w.metricsStore.TracesPublished.With(prometheus.Labels{metrics.Status: metrics.StatusOk}).Inc()
+}
+
+func (w *Feeder) fetchTraces(ctx context.Context, block *entity.EthBlock) (databus.TraceDtoJson, error) {
+	if w.traceAPI == chain.TraceAPIParity {
+		traces, err := w.chainSrv.TraceBlock(ctx, block)
+		if err != nil {
+			return databus.TraceDtoJson{}, fmt.Errorf("trace_block: %w", err)
+		}
+
+		return buildParityTraceDto(block, *traces.Result), nil
+	}
+
+	traces, err := w.chainSrv.TraceBlockCalls(ctx, block)
+	if err != nil {
+		return databus.TraceDtoJson{}, fmt.Errorf("debug_traceBlockByHash: %w", err)
+	}
+
+	return buildCallTraceDto(block, *traces.Result), nil
+}
+
+// buildCallTraceDto flattens the callTracer trees into the order trace_block
+// uses, so bots get the same calls whichever API the node serves.
+func buildCallTraceDto(block *entity.EthBlock, traces []entity.TxCallTrace) databus.TraceDtoJson {
+	calls := make([]databus.TraceDtoJsonCallsElem, 0, len(traces))
+
+	var walk func(txHash string, txIndex int, frame *entity.CallFrame, address []int)
+	walk = func(txHash string, txIndex int, frame *entity.CallFrame, address []int) {
+		calls = append(calls, databus.TraceDtoJsonCallsElem{
+			TransactionHash:  txHash,
+			TransactionIndex: txIndex,
+			TraceAddress:     address,
+			Type:             strings.ToUpper(frame.Type),
+			From:             frame.From,
+			To:               optional(frame.To),
+			Value:            weiString(frame.Value),
+			Gas:              quantity(frame.Gas),
+			GasUsed:          quantity(frame.GasUsed),
+			Input:            frame.Input,
+			Output:           optional(frame.Output),
+			Error:            optional(frame.Error),
+		})
+
+		for i := range frame.Calls {
+			walk(txHash, txIndex, &frame.Calls[i], append(address[:len(address):len(address)], i))
+		}
+	}
+
+	for i := range traces {
+		txHash := traces[i].TxHash
+		if txHash == "" && i < len(block.Transactions) {
+			txHash = block.Transactions[i]
+		}
+
+		walk(txHash, i, &traces[i].Result, []int{})
+	}
+
+	return databus.TraceDtoJson{
+		Number: int(block.GetNumber()),
+		Hash:   block.Hash,
+		Calls:  calls,
+	}
+}
+
+// buildParityTraceDto maps trace_block onto the callTracer vocabulary. Block
+// and uncle rewards belong to no transaction and are left out.
+func buildParityTraceDto(block *entity.EthBlock, traces []entity.ParityTrace) databus.TraceDtoJson {
+	calls := make([]databus.TraceDtoJsonCallsElem, 0, len(traces))
+	for i := range traces {
+		trace := traces[i]
+		if trace.TransactionPosition == nil {
+			continue
+		}
+
+		call := databus.TraceDtoJsonCallsElem{
+			TransactionHash:  trace.TransactionHash,
+			TransactionIndex: *trace.TransactionPosition,
+			TraceAddress:     trace.TraceAddress,
+			From:             trace.Action.From,
+			Value:            weiString(trace.Action.Value),
+			Gas:              quantity(trace.Action.Gas),
+			Input:            trace.Action.Input,
+			Error:            optional(trace.Error),
+		}
+
+		if call.TraceAddress == nil {
+			call.TraceAddress = []int{}
+		}
+
+		switch trace.Type {
+		case "create":
+			call.Type = "CREATE"
+			if trace.Action.CreationMethod != "" {
+				call.Type = strings.ToUpper(trace.Action.CreationMethod)
+			}
+			call.Input = trace.Action.Init
+		case "suicide":
+			call.Type = "SELFDESTRUCT"
+			call.From = trace.Action.Address
+			call.To = optional(trace.Action.RefundAddress)
+			call.Value = weiString(trace.Action.Balance)
+		default:
+			call.Type = strings.ToUpper(trace.Action.CallType)
+			call.To = optional(trace.Action.To)
+		}
+
+		if trace.Result != nil {
+			call.GasUsed = quantity(trace.Result.GasUsed)
+			call.Output = optional(trace.Result.Output)
+
+			if trace.Type == "create" {
+				call.To = optional(trace.Result.Address)
+				call.Output = optional(trace.Result.Code)
+			}
+		}
+
+		calls = append(calls, call)
+	}
+
+	return databus.TraceDtoJson{
+		Number: int(block.GetNumber()),
+		Hash:   block.Hash,
+		Calls:  calls,
+	}
+}
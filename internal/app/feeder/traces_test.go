@@ -0,0 +1,160 @@
+package feeder
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/lidofinance/onchain-mon/internal/connectors/metrics"
+	"github.com/lidofinance/onchain-mon/internal/pkg/chain"
+	"github.com/lidofinance/onchain-mon/internal/pkg/chain/entity"
+)
+
+// A proxy call that delegates to its implementation, which pays out ETH; then
+// a contract deployment. Served by both trace APIs the way nodes return it.
+type tracingChain struct {
+	canonicalChain
+}
+
+func (c *tracingChain) TraceBlockCalls(_ context.Context, _ *entity.EthBlock) (*entity.RpcResponse[[]entity.TxCallTrace], error) {
+	traces := []entity.TxCallTrace{
+		{TxHash: "0xt1", Result: entity.CallFrame{
+			Type: "CALL", From: "0xeoa", To: "0xproxy", Value: "0x0", Gas: "0x7530", GasUsed: "0x5208", Input: "0x3ccfd60b",
+			Calls: []entity.CallFrame{{
+				Type: "DELEGATECALL", From: "0xproxy", To: "0ximpl", Gas: "0x6000", GasUsed: "0x4000", Input: "0x3ccfd60b",
+				Calls: []entity.CallFrame{{Type: "CALL", From: "0xproxy", To: "0xeoa", Value: "0xde0b6b3a7640000", Gas: "0x8fc", GasUsed: "0x0", Input: "0x"}},
+			}},
+		}},
+		// An older node: no txHash, the position tells which transaction.
+		{Result: entity.CallFrame{
+			Type: "CREATE", From: "0xeoa", To: "0xnew", Value: "0x0", Gas: "0x30d40", GasUsed: "0x1d4c0", Input: "0x6080", Output: "0x6001",
+		}},
+	}
+
+	return &entity.RpcResponse[[]entity.TxCallTrace]{Result: &traces}, nil
+}
+
+func (c *tracingChain) TraceBlock(_ context.Context, block *entity.EthBlock) (*entity.RpcResponse[[]entity.ParityTrace], error) {
+	first, second := 0, 1
+	traces := []entity.ParityTrace{
+		{Type: "call", TransactionHash: "0xt1", TransactionPosition: &first, TraceAddress: []int{}, BlockHash: block.Hash,
+			Action: entity.ParityAction{CallType: "call", From: "0xeoa", To: "0xproxy", Value: "0x0", Gas: "0x7530", Input: "0x3ccfd60b"},
+			Result: &entity.ParityResult{GasUsed: "0x5208"}},
+		{Type: "call", TransactionHash: "0xt1", TransactionPosition: &first, TraceAddress: []int{0}, BlockHash: block.Hash,
+			Action: entity.ParityAction{CallType: "delegatecall", From: "0xproxy", To: "0ximpl", Value: "0x0", Gas: "0x6000", Input: "0x3ccfd60b"},
+			Result: &entity.ParityResult{GasUsed: "0x4000"}},
+		{Type: "call", TransactionHash: "0xt1", TransactionPosition: &first, TraceAddress: []int{0, 0}, BlockHash: block.Hash,
+			Action: entity.ParityAction{CallType: "call", From: "0xproxy", To: "0xeoa", Value: "0xde0b6b3a7640000", Gas: "0x8fc", Input: "0x"},
+			Result: &entity.ParityResult{GasUsed: "0x0"}},
+		{Type: "create", TransactionHash: "0xt2", TransactionPosition: &second, TraceAddress: []int{}, BlockHash: block.Hash,
+			Action: entity.ParityAction{From: "0xeoa", Value: "0x0", Gas: "0x30d40", Init: "0x6080"},
+			Result: &entity.ParityResult{GasUsed: "0x1d4c0", Address: "0xnew", Code: "0x6001"}},
+		{Type: "reward", BlockHash: block.Hash, Action: entity.ParityAction{Value: "0x1bc16d674ec80000"}},
+	}
+
+	return &entity.RpcResponse[[]entity.ParityTrace]{Result: &traces}, nil
+}
+
+func Test_both_trace_apis_publish_the_same_calls(t *testing.T) {
+	block := &entity.EthBlock{Number: "0x64", Hash: "0xabc", Transactions: []string{"0xt1", "0xt2"}}
+
+	f := newTestFeeder(&tracingChain{})
+
+	f.traceAPI = chain.TraceAPIDebug
+	debug, err := f.fetchTraces(context.Background(), block)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	f.traceAPI = chain.TraceAPIParity
+	parity, err := f.fetchTraces(context.Background(), block)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(debug, parity) {
+		t.Fatalf("the APIs disagree:\ndebug:  %+v\nparity: %+v", debug, parity)
+	}
+
+	if len(debug.Calls) != 4 {
+		t.Fatalf("got %d calls, want 4: the block reward is no call", len(debug.Calls))
+	}
+
+	payout := debug.Calls[2]
+	if payout.Type != "CALL" || !reflect.DeepEqual(payout.TraceAddress, []int{0, 0}) || payout.Value != "1000000000000000000" {
+		t.Errorf("unexpected internal transfer: %+v", payout)
+	}
+
+	deploy := debug.Calls[3]
+	if deploy.TransactionHash != "0xt2" || deploy.TransactionIndex != 1 || deploy.To == nil || *deploy.To != "0xnew" {
+		t.Errorf("unexpected deployment: %+v", deploy)
+	}
+}
+
+func Test_oversized_traces_do_not_fail_the_block(t *testing.T) {
+	f := newTestFeeder(&tracingChain{})
+	f.js = &maxPayloadJetStream{}
+	f.traceAPI = chain.TraceAPIDebug
+
+	before := testutil.ToFloat64(f.metricsStore.UnpublishableBlocks.WithLabelValues(metrics.ReasonTraceMaxPayload))
+
+	f.publishTraces(context.Background(), &entity.EthBlock{Number: "0x64", Hash: "0xabc", Transactions: []string{"0xt1", "0xt2"}})
+
+	if got := testutil.ToFloat64(f.metricsStore.UnpublishableBlocks.WithLabelValues(metrics.ReasonTraceMaxPayload)); got != before+1 {
+		t.Errorf("unpublishable traces counter: got %v, want %v", got, before+1)
+	}
+}
+
+// Holds every trace until the test releases it.
+type stuckTracingChain struct {
+	tracingChain
+	release chan struct{}
+}
+
+func (c *stuckTracingChain) TraceBlockCalls(ctx context.Context, block *entity.EthBlock) (*entity.RpcResponse[[]entity.TxCallTrace], error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-c.release:
+		return c.tracingChain.TraceBlockCalls(ctx, block)
+	}
+}
+
+func Test_slow_traces_do_not_hold_the_blocks_back(t *testing.T) {
+	c := &stuckTracingChain{release: make(chan struct{})}
+
+	js := &recordingJetStream{}
+	f := newTestFeeder(c)
+	f.js = js
+	f.topic = "blocks.test"
+	f.traceAPI = chain.TraceAPIDebug
+
+	ctx, cancel := context.WithCancel(context.Background())
+	g, gCtx := errgroup.WithContext(ctx)
+	f.runTraces(gCtx, g)
+
+	if _, err := f.recoverBlockRange(ctx, 100, 101); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := js.subjects(); len(got) != 2 || got[0] != "blocks.test" || got[1] != "blocks.test" {
+		t.Fatalf("published %v before any trace, want both blocks", got)
+	}
+
+	close(c.release)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(js.subjects()) < 4 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	cancel()
+	_ = g.Wait()
+
+	if got := js.subjects(); len(got) != 4 || got[2] != "blocks.test.traces" || got[3] != "blocks.test.traces" {
+		t.Errorf("published %v, want the traces of both blocks after them", got)
+	}
+}
@@ -0,0 +1,79 @@
+package feeder
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/lidofinance/onchain-mon/generated/databus"
+	"github.com/lidofinance/onchain-mon/internal/pkg/chain"
+	"github.com/lidofinance/onchain-mon/internal/pkg/chain/entity"
+)
+
+// buildDto builds the DTO of block. With full transactions on it also fetches
+// them, so bots see calls that emit no events; a block whose transactions
+// cannot be fetched is not published without them.
+func (w *Feeder) buildDto(ctx context.Context, block *entity.EthBlock, receipts []entity.BlockReceipt) (databus.BlockDtoJson, error) {
+	dto := buildBlockDto(block, receipts)
+	if !w.fullTransactions {
+		return dto, nil
+	}
+
+	full, err := w.chainSrv.GetBlockTransactions(ctx, block)
+	if err != nil {
+		return dto, fmt.Errorf("could not fetch transactions of block %d: %w", block.GetNumber(), err)
+	}
+
+	if full.Result == nil {
+		return dto, fmt.Errorf("could not fetch transactions of block %d: %w", block.GetNumber(), chain.ErrEmptyResponse)
+	}
+
+	dto.Transactions = buildTransactions(full.Result.Transactions, receipts)
+
+	return dto, nil
+}
+
+// buildTransactions pairs every transaction with its receipt by hash: with
+// receipt verification off the node's list may be short or out of order. A
+// transaction without a receipt, or with one from before Byzantium, gets no
+// status rather than a failed one.
+func buildTransactions(txs []entity.Transaction, receipts []entity.BlockReceipt) []databus.BlockDtoJsonTransactionsElem {
+	statuses := make(map[string]string, len(receipts))
+	for _, r := range receipts {
+		statuses[strings.ToLower(r.TransactionHash)] = r.Status
+	}
+
+	out := make([]databus.BlockDtoJsonTransactionsElem, 0, len(txs))
+	for i := range txs {
+		tx := txs[i]
+
+		txIndex, _ := strconv.ParseInt(tx.TransactionIndex, 0, 64)
+		nonce, _ := strconv.ParseInt(tx.Nonce, 0, 64)
+		txType, _ := strconv.ParseInt(tx.Type, 0, 64)
+		gas, _ := strconv.ParseInt(tx.Gas, 0, 64)
+
+		elem := databus.BlockDtoJsonTransactionsElem{
+			Hash:                 tx.Hash,
+			TransactionIndex:     int(txIndex),
+			From:                 tx.From,
+			Input:                tx.Input,
+			Value:                weiString(tx.Value),
+			Nonce:                int(nonce),
+			Type:                 int(txType),
+			Gas:                  int(gas),
+			GasPrice:             optionalWei(tx.GasPrice),
+			MaxFeePerGas:         optionalWei(tx.MaxFeePerGas),
+			MaxPriorityFeePerGas: optionalWei(tx.MaxPriorityFeePerGas),
+			Status:               optionalQuantity(statuses[strings.ToLower(tx.Hash)]),
+		}
+
+		if tx.To != "" {
+			elem.To = &tx.To
+		}
+
+		out = append(out, elem)
+	}
+
+	return out
+}
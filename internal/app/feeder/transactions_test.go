@@ -0,0 +1,105 @@
+package feeder
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/lidofinance/onchain-mon/internal/pkg/chain"
+	"github.com/lidofinance/onchain-mon/internal/pkg/chain/entity"
+)
+
+// Serves the full transactions of any block, or fails with err.
+type fullTxChain struct {
+	canonicalChain
+	txs []entity.Transaction
+	err error
+}
+
+func (c *fullTxChain) GetBlockTransactions(_ context.Context, block *entity.EthBlock) (*entity.RpcResponse[entity.EthFullBlock], error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	return &entity.RpcResponse[entity.EthFullBlock]{Result: &entity.EthFullBlock{Hash: block.Hash, Transactions: c.txs}}, nil
+}
+
+func Test_full_transactions_carry_calldata_and_status(t *testing.T) {
+	block := &entity.EthBlock{Number: "0x64", Hash: "0xabc", Transactions: []string{"0xt1", "0xt2"}}
+	receipts := []entity.BlockReceipt{
+		{TransactionHash: "0xt1", BlockHash: "0xabc", Status: "0x1"},
+		{TransactionHash: "0xt2", BlockHash: "0xabc", Status: "0x0"},
+	}
+
+	c := &fullTxChain{txs: []entity.Transaction{
+		// 10 ETH: more wei than fits in int64.
+		{Hash: "0xt1", TransactionIndex: "0x0", To: "0xdao", Input: "0xfe0d94c1", Value: "0x8ac7230489e80000", Nonce: "0x7", Gas: "0x5208", Type: "0x2", MaxFeePerGas: "0x3b9aca00"},
+		{Hash: "0xt2", TransactionIndex: "0x1", Input: "0x6080", Value: "0x0", Nonce: "0x8", Gas: "0x30d40", GasPrice: "0x1"},
+	}}
+
+	f := newTestFeeder(c)
+
+	dto, err := f.buildDto(context.Background(), block, receipts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if dto.Transactions != nil {
+		t.Fatal("transactions must stay out of the DTO unless asked for")
+	}
+
+	f.fullTransactions = true
+
+	dto, err = f.buildDto(context.Background(), block, receipts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(dto.Transactions) != 2 {
+		t.Fatalf("got %d transactions, want 2", len(dto.Transactions))
+	}
+
+	execute, deploy := dto.Transactions[0], dto.Transactions[1]
+	if execute.Input != "0xfe0d94c1" || execute.Value != "10000000000000000000" || execute.Nonce != 7 || execute.Gas != 21000 || execute.Status == nil || *execute.Status != 1 {
+		t.Errorf("unexpected first transaction: %+v", execute)
+	}
+	if execute.MaxFeePerGas == nil || *execute.MaxFeePerGas != "1000000000" || execute.GasPrice != nil {
+		t.Errorf("unexpected fee fields of the first transaction: %+v", execute)
+	}
+	if deploy.To != nil || deploy.Status == nil || *deploy.Status != 0 || deploy.TransactionIndex != 1 {
+		t.Errorf("a reverted deployment must have no to and status 0: %+v", deploy)
+	}
+}
+
+func Test_block_is_not_published_without_its_transactions(t *testing.T) {
+	f := newTestFeeder(&fullTxChain{err: chain.ErrEmptyResponse})
+	f.fullTransactions = true
+
+	_, err := f.buildDto(context.Background(), &entity.EthBlock{Number: "0x64", Hash: "0xabc"}, nil)
+	if !errors.Is(err, chain.ErrEmptyResponse) {
+		t.Errorf("got %v, want the fetch error", err)
+	}
+}
+
+func Test_transactions_are_paired_with_their_receipts_by_hash(t *testing.T) {
+	txs := []entity.Transaction{
+		{Hash: "0xt1", TransactionIndex: "0x0"},
+		{Hash: "0xt2", TransactionIndex: "0x1"},
+		{Hash: "0xt3", TransactionIndex: "0x2"},
+	}
+	// Out of order, one missing and one from before Byzantium.
+	receipts := []entity.BlockReceipt{
+		{TransactionHash: "0xT2", Status: "0x0"},
+		{TransactionHash: "0xt1"},
+	}
+
+	got := buildTransactions(txs, receipts)
+
+	if got[0].Status != nil {
+		t.Errorf("a receipt without a status must leave it unknown, got %d", *got[0].Status)
+	}
+	if got[1].Status == nil || *got[1].Status != 0 {
+		t.Errorf("got status %v for 0xt2, want its own receipt's 0", got[1].Status)
+	}
+	if got[2].Status != nil {
+		t.Errorf("a transaction without a receipt must have no status, got %d", *got[2].Status)
+	}
+}
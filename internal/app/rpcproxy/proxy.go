@@ -0,0 +1,282 @@
+// Package rpcproxy answers bots' JSON-RPC reads over NATS request-reply, so
+// the same eth_call for the same block reaches the providers once however many
+// bots ask.
+//
+// Every call is pinned to one block: a tag or a number is resolved to the
+// block's hash first and the call goes out with {"blockHash": ...}
+// (EIP-1898). A result for a block hash never changes, so it is cached by
+// hash; identical calls in flight at the same time share one request.
+package rpcproxy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/golang-lru/v2/expirable"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/lidofinance/onchain-mon/internal/connectors/metrics"
+	"github.com/lidofinance/onchain-mon/internal/pkg/chain"
+	"github.com/lidofinance/onchain-mon/internal/pkg/chain/entity"
+)
+
+// Caller runs a JSON-RPC method upstream; chain.NewChain is the real one.
+type Caller interface {
+	Call(ctx context.Context, method string, params []any) (json.RawMessage, error)
+}
+
+// BlockParams are the methods the proxy serves and where each takes its block.
+// They all read state at a block, so their results are fixed by its hash.
+var BlockParams = map[string]int{
+	"eth_call":                1,
+	"eth_getBalance":          1,
+	"eth_getCode":             1,
+	"eth_getTransactionCount": 1,
+	"eth_getStorageAt":        2,
+}
+
+// JSON-RPC error codes the proxy answers with.
+const (
+	CodeParseError     = -32700
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+)
+
+// TagTTL is how long a block tag stays resolved: a burst of bots asking for
+// latest gets one block, and the next slot is seconds away anyway.
+const TagTTL = time.Second
+
+// NumberTTL bounds how long a height stays tied to a hash. Near the head a
+// reorg may give it another block.
+const NumberTTL = time.Minute
+
+// CallTimeout bounds one upstream call. It does not depend on the bot that
+// asked first: the others share the answer.
+const CallTimeout = 30 * time.Second
+
+// Request is a JSON-RPC request as a bot sends it.
+type Request struct {
+	JsonRpc string            `json:"jsonrpc"`
+	ID      json.RawMessage   `json:"id"`
+	Method  string            `json:"method"`
+	Params  []json.RawMessage `json:"params"`
+}
+
+// Response is a JSON-RPC response.
+type Response struct {
+	JsonRpc string           `json:"jsonrpc"`
+	ID      json.RawMessage  `json:"id"`
+	Result  json.RawMessage  `json:"result,omitempty"`
+	Error   *entity.RpcError `json:"error,omitempty"`
+}
+
+// answer is what is cached: a result, or the revert every endpoint would give.
+type answer struct {
+	result json.RawMessage
+	err    *entity.RpcError
+}
+
+type Proxy struct {
+	log     *slog.Logger
+	caller  Caller
+	metrics *metrics.Store
+
+	answers *expirable.LRU[string, answer]
+	tags    *expirable.LRU[string, string]
+	numbers *expirable.LRU[string, string]
+	flight  singleflight.Group
+}
+
+// New keeps at most cacheSize answers, each for cacheTTL. Answers never go
+// stale; the TTL only frees memory of blocks nobody asks about anymore.
+func New(log *slog.Logger, caller Caller, metricsStore *metrics.Store, cacheSize int, cacheTTL time.Duration) *Proxy {
+	return &Proxy{
+		log:     log,
+		caller:  caller,
+		metrics: metricsStore,
+		answers: expirable.NewLRU[string, answer](cacheSize, nil, cacheTTL),
+		tags:    expirable.NewLRU[string, string](16, nil, TagTTL),
+		numbers: expirable.NewLRU[string, string](cacheSize, nil, NumberTTL),
+	}
+}
+
+// Handle answers one JSON-RPC request body. It returns the response body and
+// the hash of the block the call was pinned to, empty if it never got that far.
+func (p *Proxy) Handle(ctx context.Context, body []byte) ([]byte, string) {
+	var req Request
+	if err := json.Unmarshal(body, &req); err != nil {
+		return p.respond(nil, "", answer{err: &entity.RpcError{Code: CodeParseError, Message: err.Error()}}), ""
+	}
+
+	a, blockHash := p.handle(ctx, &req)
+
+	return p.respond(req.ID, req.Method, a), blockHash
+}
+
+func (p *Proxy) handle(ctx context.Context, req *Request) (answer, string) {
+	at, ok := BlockParams[req.Method]
+	if !ok {
+		return answer{err: &entity.RpcError{Code: CodeMethodNotFound, Message: fmt.Sprintf("%s is not served by the proxy", req.Method)}}, ""
+	}
+
+	if len(req.Params) > at+1 || len(req.Params) < at {
+		return answer{err: &entity.RpcError{Code: CodeInvalidParams, Message: fmt.Sprintf("%s takes %d params", req.Method, at+1)}}, ""
+	}
+
+	block := json.RawMessage(`"latest"`)
+	if len(req.Params) == at+1 {
+		block = req.Params[at]
+	}
+
+	blockHash, err := p.pin(ctx, block)
+	if err != nil {
+		var badBlock *chain.CallError
+		if errors.As(err, &badBlock) {
+			return answer{err: &badBlock.RpcError}, ""
+		}
+
+		return answer{err: &entity.RpcError{Code: CodeInternalError, Message: err.Error()}}, ""
+	}
+
+	params := make([]any, 0, at+1)
+	for _, param := range req.Params[:at] {
+		params = append(params, param)
+	}
+	params = append(params, map[string]string{"blockHash": blockHash})
+
+	key, err := json.Marshal(append([]any{req.Method}, params...))
+	if err != nil {
+		return answer{err: &entity.RpcError{Code: CodeInvalidParams, Message: err.Error()}}, blockHash
+	}
+
+	if a, ok := p.answers.Get(string(key)); ok {
+		p.metrics.RpcProxyCache.With(prometheus.Labels{metrics.Cache: metrics.CacheHit}).Inc()
+		return a, blockHash
+	}
+
+	// Do reports shared to the caller that ran the call too; only the others
+	// were spared one.
+	called := false
+	v, err, _ := p.flight.Do(string(key), func() (any, error) {
+		called = true
+
+		callCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), CallTimeout)
+		defer cancel()
+
+		result, callErr := p.caller.Call(callCtx, req.Method, params)
+		if callErr != nil {
+			var reverted *chain.CallError
+			if !errors.As(callErr, &reverted) || !reverted.Reverted() {
+				return nil, callErr
+			}
+
+			a := answer{err: &reverted.RpcError}
+			p.answers.Add(string(key), a)
+
+			return a, nil
+		}
+
+		a := answer{result: result}
+		p.answers.Add(string(key), a)
+
+		return a, nil
+	})
+
+	if called {
+		p.metrics.RpcProxyCache.With(prometheus.Labels{metrics.Cache: metrics.CacheMiss}).Inc()
+	} else {
+		p.metrics.RpcProxyCache.With(prometheus.Labels{metrics.Cache: metrics.CacheShared}).Inc()
+	}
+
+	if err != nil {
+		p.log.Warn("Upstream call failed", slog.String("method", req.Method), slog.String("error", err.Error()))
+		return answer{err: &entity.RpcError{Code: CodeInternalError, Message: err.Error()}}, blockHash
+	}
+
+	return v.(answer), blockHash
+}
+
+// pin resolves a block parameter to the hash of its block. A parameter the
+// proxy cannot pin is a *chain.CallError to hand back to the bot.
+func (p *Proxy) pin(ctx context.Context, block json.RawMessage) (string, error) {
+	var byHash struct {
+		BlockHash string `json:"blockHash"`
+	}
+	if err := json.Unmarshal(block, &byHash); err == nil && byHash.BlockHash != "" {
+		return byHash.BlockHash, nil
+	}
+
+	var tag string
+	if err := json.Unmarshal(block, &tag); err != nil {
+		return "", &chain.CallError{RpcError: entity.RpcError{Code: CodeInvalidParams, Message: fmt.Sprintf("bad block parameter %s", block)}}
+	}
+
+	// pending is not a block yet: nothing to pin to.
+	if tag == "pending" {
+		return "", &chain.CallError{RpcError: entity.RpcError{Code: CodeInvalidParams, Message: "pending is not served by the proxy"}}
+	}
+
+	cache := p.tags
+	if strings.HasPrefix(tag, "0x") {
+		cache = p.numbers
+	}
+
+	if hash, ok := cache.Get(tag); ok {
+		return hash, nil
+	}
+
+	v, err, _ := p.flight.Do("block:"+tag, func() (any, error) {
+		callCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), CallTimeout)
+		defer cancel()
+
+		raw, err := p.caller.Call(callCtx, "eth_getBlockByNumber", []any{tag, false})
+		if err != nil {
+			return "", fmt.Errorf("resolve block %s: %w", tag, err)
+		}
+
+		var b struct {
+			Hash string `json:"hash"`
+		}
+		if err := json.Unmarshal(raw, &b); err != nil || b.Hash == "" {
+			return "", fmt.Errorf("resolve block %s: no hash in %s", tag, raw)
+		}
+
+		cache.Add(tag, b.Hash)
+
+		return b.Hash, nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return v.(string), nil
+}
+
+func (p *Proxy) respond(id json.RawMessage, method string, a answer) []byte {
+	status := metrics.StatusOk
+	if a.err != nil {
+		status = metrics.StatusFail
+	}
+
+	// Unknown methods are one series, not one per name a bot made up.
+	if _, ok := BlockParams[method]; !ok {
+		method = "other"
+	}
+
+	p.metrics.RpcProxyRequests.With(prometheus.Labels{metrics.Method: method, metrics.Status: status}).Inc()
+
+	if len(id) == 0 {
+		id = json.RawMessage(`null`)
+	}
+
+	out, _ := json.Marshal(Response{JsonRpc: chain.JsonRpcVersion, ID: id, Result: a.result, Error: a.err})
+
+	return out
+}
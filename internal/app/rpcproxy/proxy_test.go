@@ -0,0 +1,242 @@
+package rpcproxy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/lidofinance/onchain-mon/internal/connectors/metrics"
+	"github.com/lidofinance/onchain-mon/internal/pkg/chain"
+	"github.com/lidofinance/onchain-mon/internal/pkg/chain/entity"
+)
+
+// fakeCaller is a node whose latest block is 0xhead, counting eth_calls by
+// what they were pinned to.
+type fakeCaller struct {
+	mu     sync.Mutex
+	calls  map[string]int
+	blocks atomic.Int32
+
+	// release, when set, holds every eth_call until it is closed.
+	release chan struct{}
+	revert  bool
+}
+
+func (c *fakeCaller) Call(_ context.Context, method string, params []any) (json.RawMessage, error) {
+	if method == "eth_getBlockByNumber" {
+		c.blocks.Add(1)
+		return json.RawMessage(`{"number":"0x64","hash":"0xhead"}`), nil
+	}
+
+	key, _ := json.Marshal(params)
+
+	c.mu.Lock()
+	if c.calls == nil {
+		c.calls = map[string]int{}
+	}
+	c.calls[method+string(key)]++
+	c.mu.Unlock()
+
+	if c.release != nil {
+		<-c.release
+	}
+
+	if c.revert {
+		return nil, &chain.CallError{RpcError: entity.RpcError{Code: chain.RevertedCode, Message: "execution reverted"}}
+	}
+
+	return json.RawMessage(`"0x2a"`), nil
+}
+
+func (c *fakeCaller) total() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	n := 0
+	for _, v := range c.calls {
+		n += v
+	}
+
+	return n
+}
+
+func newTestProxy(caller Caller) (*Proxy, *metrics.Store) {
+	m := metrics.New(prometheus.NewRegistry(), "rpcproxy_test", "t", "t")
+	return New(slog.New(slog.NewTextHandler(io.Discard, nil)), caller, m, 100, time.Minute), m
+}
+
+func decode(t *testing.T, body []byte) Response {
+	t.Helper()
+
+	var r Response
+	if err := json.Unmarshal(body, &r); err != nil {
+		t.Fatalf("bad response %s: %v", body, err)
+	}
+
+	return r
+}
+
+const ethCall = `{"jsonrpc":"2.0","id":7,"method":"eth_call","params":[{"to":"0xc0","data":"0x70a08231"},"latest"]}`
+
+func Test_call_is_pinned_to_a_hash_and_cached(t *testing.T) {
+	caller := &fakeCaller{}
+	p, m := newTestProxy(caller)
+
+	body, blockHash := p.Handle(context.Background(), []byte(ethCall))
+	if blockHash != "0xhead" {
+		t.Errorf("pinned to %q, want 0xhead", blockHash)
+	}
+
+	r := decode(t, body)
+	if r.Error != nil || string(r.Result) != `"0x2a"` || string(r.ID) != "7" {
+		t.Fatalf("got %s", body)
+	}
+
+	for key := range caller.calls {
+		if !strings.Contains(key, `{"blockHash":"0xhead"}`) {
+			t.Errorf("the call went out unpinned: %s", key)
+		}
+	}
+
+	// The same call by number and by hash is the same block.
+	for _, block := range []string{`"0x64"`, `{"blockHash":"0xhead"}`} {
+		req := strings.Replace(ethCall, `"latest"`, block, 1)
+		if r := decode(t, mustHandle(p, req)); string(r.Result) != `"0x2a"` {
+			t.Errorf("%s: got %s", block, r.Result)
+		}
+	}
+
+	if caller.total() != 1 {
+		t.Errorf("the node was called %d times, want 1", caller.total())
+	}
+	if hits := testutil.ToFloat64(m.RpcProxyCache.WithLabelValues(metrics.CacheHit)); hits != 2 {
+		t.Errorf("got %v cache hits, want 2", hits)
+	}
+}
+
+func mustHandle(p *Proxy, req string) []byte {
+	body, _ := p.Handle(context.Background(), []byte(req))
+	return body
+}
+
+func Test_concurrent_identical_calls_share_one_request(t *testing.T) {
+	caller := &fakeCaller{release: make(chan struct{})}
+	p, m := newTestProxy(caller)
+
+	// Pin first, so every bot waits on the same eth_call.
+	p.tags.Add("latest", "0xhead")
+
+	const bots = 5
+
+	var wg sync.WaitGroup
+	for range bots {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if r := decode(t, mustHandle(p, ethCall)); string(r.Result) != `"0x2a"` {
+				t.Errorf("got %s", r.Result)
+			}
+		}()
+	}
+
+	// Let the waiting bots line up behind the first before it returns.
+	for caller.total() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(caller.release)
+	wg.Wait()
+
+	if caller.total() != 1 {
+		t.Errorf("the node was called %d times, want 1", caller.total())
+	}
+
+	answered := testutil.ToFloat64(m.RpcProxyCache.WithLabelValues(metrics.CacheShared)) +
+		testutil.ToFloat64(m.RpcProxyCache.WithLabelValues(metrics.CacheHit))
+	if answered != bots-1 {
+		t.Errorf("%v bots were answered without a call, want %d", answered, bots-1)
+	}
+}
+
+func Test_revert_is_cached_like_a_result(t *testing.T) {
+	caller := &fakeCaller{revert: true}
+	p, _ := newTestProxy(caller)
+
+	for range 2 {
+		r := decode(t, mustHandle(p, ethCall))
+		if r.Error == nil || r.Error.Code != chain.RevertedCode {
+			t.Fatalf("want the revert, got %+v", r)
+		}
+	}
+
+	if caller.total() != 1 {
+		t.Errorf("the node was called %d times, want 1", caller.total())
+	}
+}
+
+type failingCaller struct{ calls atomic.Int32 }
+
+func (c *failingCaller) Call(_ context.Context, method string, _ []any) (json.RawMessage, error) {
+	if method == "eth_getBlockByNumber" {
+		return json.RawMessage(`{"hash":"0xhead"}`), nil
+	}
+
+	c.calls.Add(1)
+
+	return nil, errors.New("all endpoints are down")
+}
+
+func Test_failure_is_not_cached(t *testing.T) {
+	caller := &failingCaller{}
+	p, _ := newTestProxy(caller)
+
+	for range 2 {
+		r := decode(t, mustHandle(p, ethCall))
+		if r.Error == nil || r.Error.Code != CodeInternalError {
+			t.Fatalf("want an internal error, got %+v", r)
+		}
+	}
+
+	if caller.calls.Load() != 2 {
+		t.Errorf("the node was called %d times, want 2", caller.calls.Load())
+	}
+}
+
+func Test_requests_the_proxy_does_not_serve(t *testing.T) {
+	tests := []struct {
+		name string
+		req  string
+		code int
+	}{
+		{"not_json", `{`, CodeParseError},
+		{"unknown_method", `{"jsonrpc":"2.0","id":1,"method":"eth_sendRawTransaction","params":["0x00"]}`, CodeMethodNotFound},
+		{"pending", strings.Replace(ethCall, `"latest"`, `"pending"`, 1), CodeInvalidParams},
+		{"bad_block", strings.Replace(ethCall, `"latest"`, `42`, 1), CodeInvalidParams},
+		{"too_many_params", `{"jsonrpc":"2.0","id":1,"method":"eth_getBalance","params":["0xc0","latest","extra"]}`, CodeInvalidParams},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			caller := &fakeCaller{}
+			p, _ := newTestProxy(caller)
+
+			body, blockHash := p.Handle(context.Background(), []byte(tt.req))
+			if r := decode(t, body); r.Error == nil || r.Error.Code != tt.code {
+				t.Errorf("got %s, want code %d", body, tt.code)
+			}
+			if blockHash != "" || caller.total() != 0 {
+				t.Errorf("nothing should have been called")
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package rpcproxy
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/micro"
+	"golang.org/x/sync/errgroup"
+)
+
+// BlockHashHeader carries the hash of the block a call was pinned to, so a bot
+// asking for latest knows which block its answer is for.
+const BlockHashHeader = `Rpc-Block-Hash`
+
+// QueueGroup spreads requests over every proxy instance on the subject.
+const QueueGroup = `rpc-proxy`
+
+// Run serves JSON-RPC requests on subject until ctx is done, at most
+// concurrency of them at a time. Instances share the subject through
+// QueueGroup, but each keeps its own cache.
+func (p *Proxy) Run(ctx context.Context, g *errgroup.Group, natsClient *nats.Conn, subject string, concurrency int) error {
+	svc, err := micro.AddService(natsClient, micro.Config{
+		Name:        "rpc-proxy",
+		Version:     "1.0.0",
+		Description: "JSON-RPC reads pinned to a block and cached by its hash",
+		QueueGroup:  QueueGroup,
+	})
+	if err != nil {
+		return fmt.Errorf("add rpc proxy service: %w", err)
+	}
+
+	// micro calls the handler for one request at a time; a slow call must
+	// not hold up the cache hits queued behind it.
+	slots := make(chan struct{}, max(concurrency, 1))
+
+	handler := micro.HandlerFunc(func(req micro.Request) {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			return
+		}
+
+		go func() {
+			defer func() { <-slots }()
+
+			body, blockHash := p.Handle(ctx, req.Data())
+
+			var opts []micro.RespondOpt
+			if blockHash != "" {
+				opts = append(opts, micro.WithHeaders(micro.Headers{BlockHashHeader: []string{blockHash}}))
+			}
+
+			if respondErr := req.Respond(body, opts...); respondErr != nil {
+				p.log.Warn("Could not answer rpc request", slog.String("error", respondErr.Error()))
+			}
+		}()
+	})
+
+	if err := svc.AddEndpoint("call", handler, micro.WithEndpointSubject(subject)); err != nil {
+		_ = svc.Stop()
+		return fmt.Errorf("add rpc proxy endpoint %s: %w", subject, err)
+	}
+
+	g.Go(func() error {
+		<-ctx.Done()
+
+		return svc.Stop()
+	})
+
+	return nil
+}
@@ -22,6 +22,70 @@ type Store struct {
 	LastUnpublishableBlock      prometheus.Gauge
 	LastPublishedBlockTimestamp prometheus.Gauge
 	BlockPayloadSize            *prometheus.GaugeVec
+
+	RpcEndpointRequests  *prometheus.CounterVec
+	RpcEndpointLatency   *prometheus.GaugeVec
+	RpcEndpointErrorRate *prometheus.GaugeVec
+	RpcFailovers         prometheus.Counter
+
+	Reorgs         prometheus.Counter
+	LastReorgDepth prometheus.Gauge
+
+	StreamPublishedBlocks             *prometheus.CounterVec
+	StreamLastPublishedBlockTimestamp *prometheus.GaugeVec
+	StreamLastPublishedBlockNumber    *prometheus.GaugeVec
+
+	HeadSubscriptionUp         prometheus.Gauge
+	HeadSubscriptionReconnects prometheus.Counter
+
+	CursorErrors          prometheus.Counter
+	BackfillSkippedBlocks prometheus.Counter
+
+	Leader                prometheus.Gauge
+	LeaderTransitions     prometheus.Counter
+	MirroredMessages      *prometheus.CounterVec
+	MirrorAppendErrors    prometheus.Counter
+	MirrorDroppedMessages prometheus.Counter
+
+	BlockConsensus         *prometheus.CounterVec
+	ConsensusDissent       *prometheus.CounterVec
+	ConsensusStallAttempts *prometheus.GaugeVec
+
+	ReceiptsMismatches *prometheus.CounterVec
+
+	TracesPublished     *prometheus.CounterVec
+	TracesSkippedBlocks prometheus.Counter
+	LogBatchesPublished *prometheus.CounterVec
+	ChunkedPayloads     prometheus.Counter
+	ArchivedBlocks      *prometheus.CounterVec
+
+	RpcProxyRequests *prometheus.CounterVec
+	RpcProxyCache    *prometheus.CounterVec
+
+	EstimatedBlockTime prometheus.Gauge
+	PollMisses         prometheus.Counter
+
+	BeaconSlotsPublished   *prometheus.CounterVec
+	BeaconLastSlot         prometheus.Gauge
+	BeaconMissedSlots      prometheus.Counter
+	BeaconFinalizedEpoch   prometheus.Gauge
+	BeaconEventsUp         prometheus.Gauge
+	BeaconEventsReconnects prometheus.Counter
+
+	MempoolTransactions           *prometheus.CounterVec
+	MempoolSeen                   prometheus.Counter
+	MempoolDropped                prometheus.Counter
+	PendingSubscriptionUp         prometheus.Gauge
+	PendingSubscriptionReconnects prometheus.Counter
+
+	StateSnapshots     *prometheus.CounterVec
+	StateCallErrors    *prometheus.CounterVec
+	StateSkippedBlocks prometheus.Counter
+
+	StoragePublished     *prometheus.CounterVec
+	StorageDiffs         *prometheus.CounterVec
+	StorageReadErrors    prometheus.Counter
+	StorageSkippedBlocks prometheus.Counter
 }
 
 const Status = `status`
@@ -29,17 +93,49 @@ const Channel = `channel`
 const ConsumerName = `consumerName`
 const Reason = `reason`
 const Stage = `stage`
+const Endpoint = `endpoint`
+const Stream = `stream`
+const Chain = `chain`
+const Encoding = `encoding`
+const Method = `method`
+const Cache = `cache`
+const Watcher = `watcher`
 
 const StatusOk = `Ok`
 const StatusFail = `Fail`
 
-// ReasonMaxPayload marks a block NATS refused because of its size.
+// How the RPC proxy got an answer: from the cache, from a call, or from a call
+// another bot's identical request had already started.
+const CacheHit = `hit`
+const CacheMiss = `miss`
+const CacheShared = `shared`
+
+// ReasonMaxPayload marks a block NATS refused because of its size, even split
+// into chunks.
 const ReasonMaxPayload = `max_payload`
 
-// Payload stages for BlockPayloadSize — one metric, two lines on a chart.
+// ReasonTraceMaxPayload marks the traces of a block NATS refused because of
+// their size; the block itself was published.
+const ReasonTraceMaxPayload = `trace_max_payload`
+
+// ReasonLogsMaxPayload marks a per-address or per-topic0 log batch NATS
+// refused because of its size; the block itself was published.
+const ReasonLogsMaxPayload = `logs_max_payload`
+
+// Why receipts were rejected: fewer or more than the block's transactions, out
+// of order or from another block, logs that do not add up to logsBloom.
+const ReasonReceiptsCount = `tx_count`
+const ReasonReceiptsOrder = `tx_hash`
+const ReasonReceiptsBloom = `logs_bloom`
+
+// Payload stages for BlockPayloadSize — one metric, one line per stage on a chart.
 const StageRaw = `raw`
 const StageCompressed = `compressed`
 
+// StageCompressedNoDict is the compressed size the block would have without
+// the trained zstd dictionary; set only when the feeder has one.
+const StageCompressedNoDict = `compressed_no_dict`
+
 var Commit string
 
 func New(promRegistry *prometheus.Registry, prefix, appName, env string) *Store {
@@ -48,9 +144,33 @@ func New(promRegistry *prometheus.Registry, prefix, appName, env string) *Store
 		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
 	)
 
+	return newStore(promRegistry, promRegistry, prefix, appName, env)
+}
+
+// NewChains builds one store per chain for a feeder following several chains
+// from one process. Every collector of a store carries chain=<name>, so the
+// same metric of two chains is two series; the runtime collectors are shared.
+func NewChains(promRegistry *prometheus.Registry, prefix, appName, env string, chains []string) []*Store {
+	promRegistry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+
+	stores := make([]*Store, 0, len(chains))
+	for _, name := range chains {
+		registerer := prometheus.WrapRegistererWith(prometheus.Labels{Chain: name}, promRegistry)
+		stores = append(stores, newStore(promRegistry, registerer, prefix, appName, env))
+	}
+
+	return stores
+}
+
+// newStore registers the collectors with registerer; promRegistry is what
+// /metrics serves.
+func newStore(promRegistry *prometheus.Registry, registerer prometheus.Registerer, prefix, appName, env string) *Store {
 	store := &Store{
 		Prometheus: promRegistry,
-		BuildInfo: promauto.With(promRegistry).NewCounter(prometheus.CounterOpts{
+		BuildInfo: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
 			Name: prefix + "_metric_build_info",
 			Help: "Build information",
 			ConstLabels: prometheus.Labels{
@@ -60,52 +180,270 @@ func New(promRegistry *prometheus.Registry, prefix, appName, env string) *Store
 				"version": runtime.Version(),
 			},
 		}),
-		PublishedBlocks: promauto.With(promRegistry).NewCounterVec(prometheus.CounterOpts{
+		PublishedBlocks: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
 			Name: prefix + "_blocks_published_total",
 			Help: "The total number of published blocks",
 		}, []string{Status}),
-		SentAlerts: promauto.With(promRegistry).NewCounterVec(prometheus.CounterOpts{
+		SentAlerts: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
 			Name: prefix + "_finding_sent_total",
 			Help: "The total number of published findings",
 		}, []string{ConsumerName, Status}),
-		RedisErrors: promauto.With(promRegistry).NewCounter(prometheus.CounterOpts{
+		RedisErrors: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
 			Name: prefix + "_redis_error_total",
 			Help: "The total number of redis errors",
 		}),
-		SummaryHandlers: promauto.With(promRegistry).NewHistogramVec(prometheus.HistogramOpts{
+		SummaryHandlers: promauto.With(registerer).NewHistogramVec(prometheus.HistogramOpts{
 			Name:    prefix + "_request_processing_seconds",
 			Help:    "Time spent processing request to notification channel",
 			Buckets: prometheus.DefBuckets,
 		}, []string{Channel}),
-		NotifyChannels: promauto.With(promRegistry).NewCounterVec(prometheus.CounterOpts{
+		NotifyChannels: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
 			Name: prefix + "_notification_channel_error_total",
 			Help: "The total number of network errors of telegram, discord, opsgenie channels",
 		}, []string{Channel, Status}),
-		BlockResets: promauto.With(promRegistry).NewCounter(prometheus.CounterOpts{
+		BlockResets: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
 			Name: prefix + "_block_reset_total",
 			Help: "The total number of reset blocks",
 		}),
-		UnpublishableBlocks: promauto.With(promRegistry).NewCounterVec(prometheus.CounterOpts{
+		UnpublishableBlocks: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
 			Name: prefix + "_blocks_unpublishable_total",
 			Help: "The total number of blocks skipped because NATS refused them",
 		}, []string{Reason}),
-		LastUnpublishableBlock: promauto.With(promRegistry).NewGauge(prometheus.GaugeOpts{
+		LastUnpublishableBlock: promauto.With(registerer).NewGauge(prometheus.GaugeOpts{
 			Name: prefix + "_last_unpublishable_block_number",
 			// The number is the value, not a label: as a label it would spawn a
 			// new time series for every block.
 			Help: "Number of the last block that could not be published",
 		}),
-		BlockPayloadSize: promauto.With(promRegistry).NewGaugeVec(prometheus.GaugeOpts{
+		BlockPayloadSize: promauto.With(registerer).NewGaugeVec(prometheus.GaugeOpts{
 			Name: prefix + "_block_payload_bytes",
-			Help: "Size of the last published block payload, before and after zstd",
-		}, []string{Stage}),
-		LastPublishedBlockTimestamp: promauto.With(promRegistry).NewGauge(prometheus.GaugeOpts{
+			Help: "Size of the last published block payload, before and after zstd, per encoding",
+		}, []string{Stage, Encoding}),
+		LastPublishedBlockTimestamp: promauto.With(registerer).NewGauge(prometheus.GaugeOpts{
 			Name: prefix + "_last_published_block_timestamp",
 			// A counter cannot tell "nothing published for a while" apart from
 			// "no blocks to publish"; this gauge feeds the staleness alert:
 			//   time() - <prefix>_last_published_block_timestamp > 120
 			Help: "Unix time of the last successfully published block",
 		}),
+		RpcEndpointRequests: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+			Name: prefix + "_rpc_endpoint_requests_total",
+			Help: "The total number of RPC attempts per endpoint",
+		}, []string{Endpoint, Status}),
+		RpcEndpointLatency: promauto.With(registerer).NewGaugeVec(prometheus.GaugeOpts{
+			Name: prefix + "_rpc_endpoint_latency_seconds",
+			Help: "Smoothed latency of successful RPC attempts per endpoint",
+		}, []string{Endpoint}),
+		RpcEndpointErrorRate: promauto.With(registerer).NewGaugeVec(prometheus.GaugeOpts{
+			Name: prefix + "_rpc_endpoint_error_rate",
+			// The same value the pool ranks endpoints by, so the chart shows
+			// why traffic moved.
+			Help: "Smoothed share of failed RPC attempts per endpoint, 0..1",
+		}, []string{Endpoint}),
+		RpcFailovers: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+			Name: prefix + "_rpc_failover_total",
+			Help: "The total number of RPC requests answered by a fallback endpoint",
+		}),
+		Reorgs: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+			Name: prefix + "_reorgs_total",
+			Help: "The total number of chain reorganizations seen by the feeder",
+		}),
+		LastReorgDepth: promauto.With(registerer).NewGauge(prometheus.GaugeOpts{
+			Name: prefix + "_last_reorg_depth",
+			Help: "How many published blocks the last reorganization orphaned",
+		}),
+		StreamPublishedBlocks: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+			Name: prefix + "_stream_blocks_published_total",
+			Help: "The total number of blocks published to the safe/finalized streams",
+		}, []string{Stream, Status}),
+		StreamLastPublishedBlockTimestamp: promauto.With(registerer).NewGaugeVec(prometheus.GaugeOpts{
+			Name: prefix + "_stream_last_published_block_timestamp",
+			// Same idea as last_published_block_timestamp, per stream. finalized
+			// moves once per epoch, so its staleness threshold is minutes, not 120s.
+			Help: "Unix time of the last block published to a safe/finalized stream",
+		}, []string{Stream}),
+		StreamLastPublishedBlockNumber: promauto.With(registerer).NewGaugeVec(prometheus.GaugeOpts{
+			Name: prefix + "_stream_last_published_block_number",
+			Help: "Number of the last block published to a safe/finalized stream",
+		}, []string{Stream}),
+		HeadSubscriptionUp: promauto.With(registerer).NewGauge(prometheus.GaugeOpts{
+			Name: prefix + "_head_subscription_up",
+			// 0 while JSON_RPC_WS_URL is set means the feeder is back to polling.
+			Help: "1 while the newHeads WebSocket subscription is live, 0 otherwise",
+		}),
+		HeadSubscriptionReconnects: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+			Name: prefix + "_head_subscription_reconnects_total",
+			Help: "The total number of times the newHeads subscription dropped",
+		}),
+		CursorErrors: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+			Name: prefix + "_cursor_errors_total",
+			Help: "The total number of failed writes of the feeder cursor",
+		}),
+		BackfillSkippedBlocks: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+			Name: prefix + "_backfill_skipped_blocks_total",
+			// Non-zero means bots never saw these blocks: the feeder was down
+			// longer than MAX_BACKFILL_BLOCKS covers.
+			Help: "The total number of blocks left out of a restart backfill",
+		}),
+		Leader: promauto.With(registerer).NewGauge(prometheus.GaugeOpts{
+			Name: prefix + "_leader",
+			// Summed across instances this must be exactly 1 with
+			// LEADER_ELECTION on: 0 is nobody polling, 2 is a split brain.
+			Help: "1 while this feeder holds the leader lease, 0 while it stands by",
+		}),
+		LeaderTransitions: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+			Name: prefix + "_leader_transitions_total",
+			Help: "The total number of times this feeder gained or lost leadership",
+		}),
+		MirroredMessages: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+			Name: prefix + "_mirrored_messages_total",
+			Help: "The total number of leader publishes replayed to this cell's JetStream",
+		}, []string{Status}),
+		MirrorAppendErrors: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+			Name: prefix + "_mirror_append_errors_total",
+			Help: "The total number of publishes the leader could not hand to the other cells",
+		}),
+		MirrorDroppedMessages: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+			Name: prefix + "_mirror_dropped_messages_total",
+			Help: "The total number of publishes dropped before the leader could hand them to the other cells",
+		}),
+		BlockConsensus: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+			Name: prefix + "_block_consensus_total",
+			Help: "The total number of blocks checked against BLOCK_CONSENSUS endpoints, by outcome",
+		}, []string{Status}),
+		ConsensusDissent: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+			Name: prefix + "_consensus_dissent_total",
+			// One endpoint growing here is a provider serving a stale or wrong
+			// chain; all of them growing together is more likely a reorg.
+			Help: "The total number of times an endpoint answered with a different block",
+		}, []string{Endpoint}),
+		ConsensusStallAttempts: promauto.With(registerer).NewGaugeVec(prometheus.GaugeOpts{
+			Name: prefix + "_consensus_stall_attempts",
+			Help: "Attempts in a row that failed to confirm the block at one height with BLOCK_CONSENSUS endpoints; 0 once one is confirmed",
+		}, []string{Stream}),
+		ReceiptsMismatches: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+			Name: prefix + "_receipts_mismatches_total",
+			Help: "The total number of receipt lists that did not match their block and were refetched",
+		}, []string{Reason}),
+		TracesPublished: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+			Name: prefix + "_traces_published_total",
+			Help: "The total number of blocks whose call traces were published, by outcome",
+		}, []string{Status}),
+		TracesSkippedBlocks: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+			Name: prefix + "_traces_blocks_skipped_total",
+			Help: "The total number of published blocks left untraced because tracing fell behind",
+		}),
+		LogBatchesPublished: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+			Name: prefix + "_log_batches_published_total",
+			Help: "The total number of per-address and per-topic0 log batches published, by outcome",
+		}, []string{Status}),
+		ChunkedPayloads: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+			Name: prefix + "_chunked_payloads_total",
+			Help: "The total number of payloads too big for one message that were published in chunks",
+		}),
+		ArchivedBlocks: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+			Name: prefix + "_archived_blocks_total",
+			Help: "The total number of published blocks written to the on-disk archive, by outcome",
+		}, []string{Status}),
+		RpcProxyRequests: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+			Name: prefix + "_rpc_proxy_requests_total",
+			Help: "The total number of JSON-RPC requests the proxy answered, by method and outcome",
+		}, []string{Method, Status}),
+		RpcProxyCache: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+			Name: prefix + "_rpc_proxy_cache_total",
+			// miss is what reached the providers; hit and shared are what the
+			// bots would have sent them without the proxy.
+			Help: "The total number of pinned calls by how they were answered: hit, miss or shared",
+		}, []string{Cache}),
+		EstimatedBlockTime: promauto.With(registerer).NewGauge(prometheus.GaugeOpts{
+			Name: prefix + "_estimated_block_time_seconds",
+			Help: "Block interval the feeder learned from recent blocks and paces its polling by",
+		}),
+		PollMisses: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+			Name: prefix + "_poll_misses_total",
+			// Growing fast against blocks_published_total means the feeder
+			// polls ahead of the chain: a slower chain or a lagging endpoint.
+			Help: "The total number of polls for the next block that found it not there yet",
+		}),
+		BeaconSlotsPublished: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+			Name: prefix + "_beacon_slots_published_total",
+			Help: "The total number of beacon blocks published, by outcome",
+		}, []string{Status}),
+		BeaconLastSlot: promauto.With(registerer).NewGauge(prometheus.GaugeOpts{
+			Name: prefix + "_beacon_last_slot",
+			Help: "Slot of the last beacon block published",
+		}),
+		BeaconMissedSlots: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+			Name: prefix + "_beacon_missed_slots_total",
+			// Slots without a block: the proposer missed them. Nothing is
+			// published for them.
+			Help: "The total number of slots the feeder passed that had no block",
+		}),
+		BeaconFinalizedEpoch: promauto.With(registerer).NewGauge(prometheus.GaugeOpts{
+			Name: prefix + "_beacon_finalized_epoch",
+			Help: "Epoch of the last finalized checkpoint published",
+		}),
+		BeaconEventsUp: promauto.With(registerer).NewGauge(prometheus.GaugeOpts{
+			Name: prefix + "_beacon_events_up",
+			// 0 while BEACON_API_URL is set means the beacon feeder is back to
+			// polling every slot.
+			Help: "1 while the Beacon API event stream is live, 0 otherwise",
+		}),
+		BeaconEventsReconnects: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+			Name: prefix + "_beacon_events_reconnects_total",
+			Help: "The total number of times the Beacon API event stream dropped",
+		}),
+		MempoolTransactions: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+			Name: prefix + "_mempool_transactions_published_total",
+			Help: "The total number of pending transactions to watched addresses published to mempool.<chain>",
+		}, []string{Status}),
+		MempoolSeen: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+			Name: prefix + "_mempool_transactions_seen_total",
+			// Against the published ones: how much of the mempool the watch list lets through.
+			Help: "The total number of pending transactions the feeder looked at, watched or not",
+		}),
+		MempoolDropped: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+			Name: prefix + "_mempool_transactions_dropped_total",
+			Help: "The total number of pending transactions dropped because the feeder fell behind the subscription",
+		}),
+		PendingSubscriptionUp: promauto.With(registerer).NewGauge(prometheus.GaugeOpts{
+			Name: prefix + "_pending_subscription_up",
+			Help: "1 while the newPendingTransactions WebSocket subscription is live, 0 otherwise",
+		}),
+		PendingSubscriptionReconnects: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+			Name: prefix + "_pending_subscription_reconnects_total",
+			Help: "The total number of times the newPendingTransactions subscription dropped",
+		}),
+		StateSnapshots: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+			Name: prefix + "_state_snapshots_published_total",
+			Help: "The total number of watched state snapshots published to state.<chain>, by outcome",
+		}, []string{Status}),
+		StateCallErrors: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+			Name: prefix + "_state_call_errors_total",
+			// Published with an error instead of a value: a revert, or an
+			// output that is not what the watcher says the function returns.
+			Help: "The total number of watched calls that reverted or did not decode, by watcher",
+		}, []string{Watcher}),
+		StateSkippedBlocks: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+			Name: prefix + "_state_blocks_skipped_total",
+			Help: "The total number of published blocks the state watchers were not called at because they fell behind",
+		}),
+		StoragePublished: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+			Name: prefix + "_storage_diffs_published_total",
+			Help: "The total number of storage slot diffs messages published to storage.<chain>, by outcome",
+		}, []string{Status}),
+		StorageDiffs: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+			Name: prefix + "_storage_slot_diffs_total",
+			Help: "The total number of published changes of a watched storage slot, by slot name",
+		}, []string{Watcher}),
+		StorageReadErrors: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+			Name: prefix + "_storage_read_errors_total",
+			Help: "The total number of published blocks the watched storage slots could not be read at",
+		}),
+		StorageSkippedBlocks: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+			Name: prefix + "_storage_blocks_skipped_total",
+			Help: "The total number of published blocks the storage slots were not read at because the reads fell behind",
+		}),
 	}
 
 	return store
@@ -0,0 +1,24 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func Test_chains_share_a_registry_as_separate_series(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	stores := NewChains(registry, "feeder", "feeder", "test", []string{"mainnet", "arbitrum"})
+
+	stores[0].PublishedBlocks.With(prometheus.Labels{Status: StatusOk}).Inc()
+	stores[1].PublishedBlocks.With(prometheus.Labels{Status: StatusOk}).Add(4)
+
+	if got := testutil.CollectAndCount(registry, "feeder_blocks_published_total"); got != 2 {
+		t.Fatalf("got %d series, want one per chain", got)
+	}
+
+	if got := testutil.ToFloat64(stores[1].PublishedBlocks.With(prometheus.Labels{Status: StatusOk})); got != 4 {
+		t.Errorf("arbitrum counted %v blocks, want its own 4", got)
+	}
+}
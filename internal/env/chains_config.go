@@ -0,0 +1,212 @@
+package env
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// DefaultBlockTime is the Ethereum L1 slot.
+const DefaultBlockTime = 12 * time.Second
+
+// ChainConfig is what one feeder loop needs: where its blocks come from, where
+// they go and what it takes to publish one.
+type ChainConfig struct {
+	// Name labels the metrics and logs of the chain: mainnet, arbitrum, hoodi.
+	Name  string   `mapstructure:"name"`
+	RPC   []string `mapstructure:"rpc"`
+	WS    string   `mapstructure:"ws"`
+	Topic string   `mapstructure:"topic"`
+	// Beacon is the Beacon API of the chain's consensus layer; empty follows
+	// the execution layer only.
+	Beacon string `mapstructure:"beacon"`
+	// Mempool is subscribe or txpool to also publish pending transactions
+	// sent to MempoolWatch; empty follows no mempool.
+	Mempool      string   `mapstructure:"mempool"`
+	MempoolWatch []string `mapstructure:"mempool_watch"`
+	// StateWatchers is a YAML file of view functions called at every
+	// published block, their values published to state.<Name>. Empty
+	// watches none.
+	StateWatchers string `mapstructure:"state_watchers"`
+	// StorageSlots is a YAML file of contract storage slots read at every
+	// published block, their changes published to storage.<Name>. Empty
+	// reads none.
+	StorageSlots string `mapstructure:"storage_slots"`
+	// BlockTime is how often the chain is expected to make a block.
+	BlockTime time.Duration `mapstructure:"block_time"`
+	// Confirmation policy: how many RPC endpoints must serve the same block
+	// and which of the safe/finalized streams follow the head.
+	Consensus int      `mapstructure:"consensus"`
+	Streams   []string `mapstructure:"streams"`
+	// VerifyReceipts checks the receipts of every block against it; nil
+	// means on.
+	VerifyReceipts   *bool    `mapstructure:"verify_receipts"`
+	FullTransactions bool     `mapstructure:"full_transactions"`
+	Traces           string   `mapstructure:"traces"`
+	LogSubjects      []string `mapstructure:"log_subjects"`
+	Encodings        []string `mapstructure:"encodings"`
+	ZstdDictionary   string   `mapstructure:"zstd_dictionary"`
+	MaxBackfill      int64    `mapstructure:"max_backfill"`
+	// Archive is the directory published blocks are kept in, split into
+	// segments of ArchiveSegmentBlocks heights, the newest ArchiveMaxSegments
+	// of them. Empty keeps none.
+	Archive              string `mapstructure:"archive"`
+	ArchiveSegmentBlocks int64  `mapstructure:"archive_segment_blocks"`
+	ArchiveMaxSegments   int    `mapstructure:"archive_max_segments"`
+}
+
+type ChainsConfig struct {
+	Chains []ChainConfig `mapstructure:"chains"`
+}
+
+// ReadChains returns the chains the feeder follows: the ones listed in the
+// app.ChainsConfig file, or the single chain the env describes without one.
+// In the file only max_backfill, archive_segment_blocks and
+// archive_max_segments a chain leaves out fall back to the env; block_time
+// falls back to DefaultBlockTime, verify_receipts to true and every other
+// setting to its zero value.
+func ReadChains(app *AppConfig) ([]ChainConfig, error) {
+	verifyReceipts := app.VerifyReceipts
+
+	chains := []ChainConfig{{
+		Name:             app.ChainName,
+		RPC:              app.JsonRpcURLs,
+		WS:               app.JsonRpcWsURL,
+		Topic:            app.BlockTopic,
+		Beacon:           app.BeaconApiURL,
+		Mempool:          app.MempoolSource,
+		MempoolWatch:     app.MempoolWatch,
+		StateWatchers:    app.StateWatchers,
+		StorageSlots:     app.StorageSlots,
+		BlockTime:        app.BlockTime,
+		Consensus:        app.BlockConsensus,
+		Streams:          app.BlockStreams,
+		VerifyReceipts:   &verifyReceipts,
+		FullTransactions: app.FullTransactions,
+		Traces:           app.BlockTraces,
+		LogSubjects:      app.BlockLogSubjects,
+		Encodings:        app.BlockEncodings,
+		ZstdDictionary:   app.ZstdDictionary,
+		MaxBackfill:      app.MaxBackfill,
+		Archive:          app.BlockArchive,
+	}}
+
+	if app.ChainsConfig != "" {
+		configData, err := readChainsConfig(app.ChainsConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		chains = configData.Chains
+	}
+
+	for i := range chains {
+		if chains[i].BlockTime == 0 {
+			chains[i].BlockTime = DefaultBlockTime
+		}
+
+		if chains[i].VerifyReceipts == nil {
+			verify := true
+			chains[i].VerifyReceipts = &verify
+		}
+
+		if chains[i].MaxBackfill == 0 {
+			chains[i].MaxBackfill = app.MaxBackfill
+		}
+
+		if chains[i].ArchiveSegmentBlocks == 0 {
+			chains[i].ArchiveSegmentBlocks = app.BlockArchiveSegmentBlocks
+		}
+
+		if chains[i].ArchiveMaxSegments == 0 {
+			chains[i].ArchiveMaxSegments = app.BlockArchiveMaxSegments
+		}
+	}
+
+	if err := ValidateChains(chains); err != nil {
+		return nil, err
+	}
+
+	return chains, nil
+}
+
+func readChainsConfig(configPath string) (*ChainsConfig, error) {
+	if _, err := os.Stat(configPath); err != nil {
+		return nil, err
+	}
+
+	v := viper.New()
+	v.SetConfigName(filepath.Base(configPath))
+	v.SetConfigType("yaml")
+	v.AddConfigPath(filepath.Dir(configPath))
+
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("error reading chains config file, %w", err)
+	}
+
+	var configData ChainsConfig
+	if err := v.Unmarshal(&configData); err != nil {
+		return nil, fmt.Errorf("unable to decode chains config into struct, %w", err)
+	}
+
+	return &configData, nil
+}
+
+// ValidateChains rejects chains that would clash in one process: the name
+// tells their metrics apart and the topic keys their cursor, lease and mirror.
+func ValidateChains(chains []ChainConfig) error {
+	if len(chains) == 0 {
+		return errors.New("chains config has no chains")
+	}
+
+	names := make(map[string]bool, len(chains))
+	topics := make(map[string]string, len(chains))
+	archives := make(map[string]string, len(chains))
+
+	for _, c := range chains {
+		if c.Name == "" {
+			return fmt.Errorf("chain publishing to '%s' has an empty name", c.Topic)
+		}
+
+		if names[c.Name] {
+			return fmt.Errorf("chain name '%s' is duplicated", c.Name)
+		}
+		names[c.Name] = true
+
+		if c.Topic == "" {
+			return fmt.Errorf("chain '%s' has no topic", c.Name)
+		}
+
+		if owner, exists := topics[c.Topic]; exists {
+			return fmt.Errorf("chains '%s' and '%s' both publish to '%s'", owner, c.Name, c.Topic)
+		}
+		topics[c.Topic] = c.Name
+
+		if c.Archive != "" {
+			dir := filepath.Clean(c.Archive)
+			if owner, exists := archives[dir]; exists {
+				return fmt.Errorf("chains '%s' and '%s' both archive to '%s'", owner, c.Name, c.Archive)
+			}
+			archives[dir] = c.Name
+		}
+
+		if len(c.RPC) == 0 {
+			return fmt.Errorf("chain '%s' must list at least one rpc endpoint", c.Name)
+		}
+
+		if c.Consensus < 0 || c.Consensus > len(c.RPC) {
+			return fmt.Errorf("chain '%s': consensus must be between 0 and its %d rpc endpoints, got %d",
+				c.Name, len(c.RPC), c.Consensus)
+		}
+
+		if c.BlockTime < 0 {
+			return fmt.Errorf("chain '%s' has a negative block_time %s", c.Name, c.BlockTime)
+		}
+	}
+
+	return nil
+}
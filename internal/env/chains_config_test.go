@@ -0,0 +1,161 @@
+package env
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func validChains() []ChainConfig {
+	return []ChainConfig{
+		{Name: "mainnet", RPC: []string{"https://a", "https://b"}, Topic: "blocks.mainnet.l1", Consensus: 2},
+		{Name: "arbitrum", RPC: []string{"https://c"}, Topic: "blocks.arbitrum.l2"},
+	}
+}
+
+func Test_chains_config_is_read_from_yaml(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chains.yaml")
+	config := `
+chains:
+  - name: mainnet
+    rpc: [https://a, https://b]
+    ws: wss://a
+    topic: blocks.mainnet.l1
+    consensus: 2
+    streams: [safe, finalized]
+  - name: arbitrum
+    rpc: [https://c]
+    topic: blocks.arbitrum.l2
+    block_time: 250ms
+    full_transactions: true
+    traces: debug
+    max_backfill: 100000
+`
+	if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	chains, err := ReadChains(&AppConfig{
+		ChainsConfig: path,
+		MaxBackfill:  7200,
+		// Only MaxBackfill and the archive segments are inherited.
+		BlockTime:      time.Second,
+		BlockTraces:    "parity",
+		BlockEncodings: []string{"msgpack"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(chains) != 2 {
+		t.Fatalf("got %d chains, want 2", len(chains))
+	}
+
+	mainnet, arbitrum := chains[0], chains[1]
+	if mainnet.BlockTime != DefaultBlockTime || mainnet.MaxBackfill != 7200 {
+		t.Errorf("mainnet must fall back to the defaults: %+v", mainnet)
+	}
+	if mainnet.VerifyReceipts == nil || !*mainnet.VerifyReceipts {
+		t.Errorf("receipts must be verified unless a chain turns it off: %v", mainnet.VerifyReceipts)
+	}
+	if mainnet.Traces != "" || mainnet.Encodings != nil {
+		t.Errorf("mainnet must not inherit the env chain's settings: %+v", mainnet)
+	}
+	if mainnet.Consensus != 2 || len(mainnet.Streams) != 2 || mainnet.WS != "wss://a" {
+		t.Errorf("unexpected mainnet: %+v", mainnet)
+	}
+	if arbitrum.BlockTime != 250*time.Millisecond || arbitrum.MaxBackfill != 100000 || !arbitrum.FullTransactions || arbitrum.Traces != "debug" {
+		t.Errorf("unexpected arbitrum: %+v", arbitrum)
+	}
+}
+
+func Test_single_chain_comes_from_env_without_a_config(t *testing.T) {
+	chains, err := ReadChains(&AppConfig{
+		ChainName:      "hoodi",
+		JsonRpcURLs:    []string{"https://hoodi"},
+		BlockTopic:     "blocks.hoodi.l1",
+		BlockConsensus: 1,
+		MaxBackfill:    7200,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(chains) != 1 || chains[0].Name != "hoodi" || chains[0].Topic != "blocks.hoodi.l1" || chains[0].BlockTime != DefaultBlockTime {
+		t.Errorf("unexpected chains: %+v", chains)
+	}
+}
+
+func Test_chains_are_rejected_when(t *testing.T) {
+	tests := []struct {
+		name    string
+		mutate  func([]ChainConfig) []ChainConfig
+		wantErr string
+	}{
+		{
+			name:    "no_chains",
+			mutate:  func([]ChainConfig) []ChainConfig { return nil },
+			wantErr: "no chains",
+		},
+		{
+			name:    "empty_name",
+			mutate:  func(c []ChainConfig) []ChainConfig { c[1].Name = ""; return c },
+			wantErr: "empty name",
+		},
+		{
+			// The name is the metrics label: two chains would write one series.
+			name:    "duplicated_name",
+			mutate:  func(c []ChainConfig) []ChainConfig { c[1].Name = "mainnet"; return c },
+			wantErr: "is duplicated",
+		},
+		{
+			// The topic keys the cursor: two chains would resume from each other's blocks.
+			name:    "shared_topic",
+			mutate:  func(c []ChainConfig) []ChainConfig { c[1].Topic = "blocks.mainnet.l1"; return c },
+			wantErr: "both publish to",
+		},
+		{
+			// One archive segment would interleave the blocks of both chains.
+			name: "shared_archive",
+			mutate: func(c []ChainConfig) []ChainConfig {
+				c[0].Archive, c[1].Archive = "/data/archive", "/data/archive/"
+				return c
+			},
+			wantErr: "both archive to",
+		},
+		{
+			name:    "no_topic",
+			mutate:  func(c []ChainConfig) []ChainConfig { c[1].Topic = ""; return c },
+			wantErr: "has no topic",
+		},
+		{
+			name:    "no_rpc",
+			mutate:  func(c []ChainConfig) []ChainConfig { c[1].RPC = nil; return c },
+			wantErr: "at least one rpc endpoint",
+		},
+		{
+			name:    "consensus_above_endpoints",
+			mutate:  func(c []ChainConfig) []ChainConfig { c[0].Consensus = 3; return c },
+			wantErr: "consensus must be between",
+		},
+		{
+			name:    "negative_block_time",
+			mutate:  func(c []ChainConfig) []ChainConfig { c[1].BlockTime = -time.Second; return c },
+			wantErr: "negative block_time",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateChains(tt.mutate(validChains()))
+			if err == nil {
+				t.Fatalf("expected an error mentioning %q", tt.wantErr)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("got %q, want it to mention %q", err, tt.wantErr)
+			}
+		})
+	}
+}
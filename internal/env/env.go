@@ -1,8 +1,11 @@
 package env
 
 import (
+	"os"
 	"regexp"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -22,8 +25,88 @@ type AppConfig struct {
 	NatsDefaultURL string
 	MetricsPrefix  string
 
-	JsonRpcURL string
-	BlockTopic string
+	// ChainsConfig is an optional YAML file listing every chain the feeder
+	// follows. Without it the feeder follows the one chain described below,
+	// named ChainName in metrics and logs.
+	ChainsConfig string
+	ChainName    string
+	// BlockTime is how often the chain is expected to make a block.
+	BlockTime time.Duration
+
+	// JsonRpcURLs are tried in this order until the pool has seen how each of
+	// them behaves; after that the healthiest one goes first.
+	JsonRpcURLs []string
+	// BlockConsensus is how many of JsonRpcURLs must serve the same block
+	// before the feeder publishes it. 0 turns the check off.
+	BlockConsensus int
+	// JsonRpcWsURL is an optional WebSocket endpoint for newHeads. Empty means
+	// the feeder only polls.
+	JsonRpcWsURL string
+	BlockTopic   string
+	// BlockStreams are the extra block tags (safe, finalized) the feeder
+	// follows next to the head, each on <BlockTopic>.<tag>.
+	BlockStreams []string
+	// CursorBucket is the JetStream KV bucket holding the last published
+	// block, so a restarted feeder resumes instead of jumping to latest.
+	// Empty keeps no cursor.
+	CursorBucket string
+	// MaxBackfill caps how many blocks a restarted feeder republishes.
+	MaxBackfill int64
+	// LeaderElection lets feeders of several cells elect one to poll the RPC
+	// through Redis; the others replay its blocks. LeaderLease bounds how long
+	// a dead leader is waited for.
+	LeaderElection bool
+	LeaderLease    time.Duration
+	// VerifyReceipts checks the receipts of every block against its
+	// transactions and logs bloom, failing over to the next endpoint on a
+	// mismatch. Chains whose receipts legitimately differ turn it off.
+	VerifyReceipts bool
+	// FullTransactions makes the feeder publish every transaction with its
+	// calldata next to the receipts.
+	FullTransactions bool
+	// BlockTraces picks the node API call traces come from: debug or parity.
+	// Empty publishes no traces.
+	BlockTraces string
+	// BlockLogSubjects split the logs of every block by address and/or
+	// topic0 onto subjects of their own.
+	BlockLogSubjects []string
+	// BlockEncodings are the encodings every payload is published in: json
+	// to the subject itself, the others right after BlockTopic, before the
+	// partition. Empty means json only.
+	BlockEncodings []string
+	// ZstdDictionary is the file of a trained zstd dictionary payloads are
+	// compressed with. Empty compresses without one.
+	ZstdDictionary string
+	// BlockArchive is an optional directory the feeder keeps every published
+	// block in, compressed as it went out, for cmd/replay -archive. It rolls
+	// over in segments of BlockArchiveSegmentBlocks heights and keeps the
+	// newest BlockArchiveMaxSegments; 0 keeps every segment.
+	BlockArchive              string
+	BlockArchiveSegmentBlocks int64
+	BlockArchiveMaxSegments   int
+	// BeaconApiURL is an optional Beacon API node. With it the feeder also
+	// publishes the consensus layer to <BlockTopic>.beacon.
+	BeaconApiURL string
+	// MempoolSource is where pending transactions come from: subscribe
+	// (JsonRpcWsURL) or txpool (txpool_content over JsonRpcURLs). Those sent
+	// to MempoolWatch go to mempool.<ChainName>. Empty follows no mempool.
+	MempoolSource string
+	MempoolWatch  []string
+	// StateWatchers is an optional YAML file of view functions the feeder
+	// calls at every published block, publishing their values to
+	// state.<ChainName>.
+	StateWatchers string
+	// StorageSlots is an optional YAML file of contract storage slots the
+	// feeder reads at every published block, publishing their changes to
+	// storage.<ChainName>.
+	StorageSlots string
+	// RpcProxySubject is where cmd/rpc-proxy answers JSON-RPC requests for
+	// the chain at JsonRpcURLs. It keeps RpcProxyCacheSize answers for
+	// RpcProxyCacheTTL each and runs RpcProxyConcurrency calls at a time.
+	RpcProxySubject     string
+	RpcProxyCacheSize   int
+	RpcProxyCacheTTL    time.Duration
+	RpcProxyConcurrency int
 
 	QuorumSize    uint
 	SentryDSN     string
@@ -68,6 +151,61 @@ func Read(configPath string) (*Config, error) {
 
 		var re = regexp.MustCompile(`[ -]`)
 
+		// Set but empty turns the cursor off, so it is told apart from unset:
+		// viper takes an empty variable of the shell for a missing one.
+		cursorBucket := `feeder_cursor`
+		if bucket, ok := os.LookupEnv("CURSOR_BUCKET"); ok {
+			cursorBucket = bucket
+		} else if viper.IsSet("CURSOR_BUCKET") {
+			cursorBucket = viper.GetString("CURSOR_BUCKET")
+		}
+
+		// One day of mainnet blocks.
+		maxBackfill := viper.GetInt64("MAX_BACKFILL_BLOCKS")
+		if maxBackfill <= 0 {
+			maxBackfill = 7200
+		}
+
+		archiveSegmentBlocks := viper.GetInt64("BLOCK_ARCHIVE_SEGMENT_BLOCKS")
+		if archiveSegmentBlocks <= 0 {
+			archiveSegmentBlocks = 10_000
+		}
+
+		leaderLease := viper.GetDuration("LEADER_LEASE")
+		if leaderLease <= 0 {
+			leaderLease = 15 * time.Second
+		}
+
+		chainName := viper.GetString("CHAIN_NAME")
+		if chainName == "" {
+			chainName = `mainnet`
+		}
+
+		rpcProxySubject := viper.GetString("RPC_PROXY_SUBJECT")
+		if rpcProxySubject == "" {
+			rpcProxySubject = `rpc.` + chainName
+		}
+
+		rpcProxyCacheSize := viper.GetInt("RPC_PROXY_CACHE_SIZE")
+		if rpcProxyCacheSize <= 0 {
+			rpcProxyCacheSize = 100_000
+		}
+
+		rpcProxyCacheTTL := viper.GetDuration("RPC_PROXY_CACHE_TTL")
+		if rpcProxyCacheTTL <= 0 {
+			rpcProxyCacheTTL = time.Hour
+		}
+
+		rpcProxyConcurrency := viper.GetInt("RPC_PROXY_CONCURRENCY")
+		if rpcProxyConcurrency <= 0 {
+			rpcProxyConcurrency = 64
+		}
+
+		verifyReceipts := true
+		if viper.IsSet("BLOCK_VERIFY_RECEIPTS") {
+			verifyReceipts = viper.GetBool("BLOCK_VERIFY_RECEIPTS")
+		}
+
 		blockExplorer := viper.GetString("BLOCK_EXPLORER")
 		if blockExplorer == "" {
 			blockExplorer = `etherscan.io`
@@ -84,8 +222,39 @@ func Read(configPath string) (*Config, error) {
 
 				NatsDefaultURL: viper.GetString("NATS_DEFAULT_URL"),
 				MetricsPrefix:  re.ReplaceAllString(viper.GetString("APP_NAME"), `_`),
-				JsonRpcURL:     viper.GetString("JSON_RPC_URL"),
-				BlockTopic:     viper.GetString("BLOCK_TOPIC"),
+				ChainsConfig:   viper.GetString("FEEDER_CHAINS"),
+				ChainName:      chainName,
+				BlockTime:      viper.GetDuration("BLOCK_TIME"),
+
+				JsonRpcURLs:      splitList(viper.GetString("JSON_RPC_URL")),
+				BlockConsensus:   viper.GetInt("BLOCK_CONSENSUS"),
+				JsonRpcWsURL:     viper.GetString("JSON_RPC_WS_URL"),
+				BlockTopic:       viper.GetString("BLOCK_TOPIC"),
+				BlockStreams:     splitList(viper.GetString("BLOCK_STREAMS")),
+				CursorBucket:     cursorBucket,
+				MaxBackfill:      maxBackfill,
+				LeaderElection:   viper.GetBool("LEADER_ELECTION"),
+				LeaderLease:      leaderLease,
+				VerifyReceipts:   verifyReceipts,
+				FullTransactions: viper.GetBool("BLOCK_FULL_TRANSACTIONS"),
+				BlockTraces:      viper.GetString("BLOCK_TRACES"),
+				BlockLogSubjects: splitList(viper.GetString("BLOCK_LOG_SUBJECTS")),
+				BlockEncodings:   splitList(viper.GetString("BLOCK_ENCODINGS")),
+				ZstdDictionary:   viper.GetString("ZSTD_DICTIONARY"),
+				BeaconApiURL:     viper.GetString("BEACON_API_URL"),
+				MempoolSource:    viper.GetString("MEMPOOL_SOURCE"),
+				MempoolWatch:     splitList(viper.GetString("MEMPOOL_WATCH")),
+				StateWatchers:    viper.GetString("STATE_WATCHERS"),
+				StorageSlots:     viper.GetString("STORAGE_SLOTS"),
+
+				BlockArchive:              viper.GetString("BLOCK_ARCHIVE_DIR"),
+				BlockArchiveSegmentBlocks: archiveSegmentBlocks,
+				BlockArchiveMaxSegments:   viper.GetInt("BLOCK_ARCHIVE_MAX_SEGMENTS"),
+
+				RpcProxySubject:     rpcProxySubject,
+				RpcProxyCacheSize:   rpcProxyCacheSize,
+				RpcProxyCacheTTL:    rpcProxyCacheTTL,
+				RpcProxyConcurrency: rpcProxyConcurrency,
 
 				QuorumSize:    viper.GetUint("QUORUM_SIZE"),
 				SentryDSN:     viper.GetString("SENTRY_DSN"),
@@ -101,3 +270,16 @@ func Read(configPath string) (*Config, error) {
 
 	return &cfg, err
 }
+
+// splitList reads a comma-separated env value, dropping blanks so a trailing
+// comma does not turn into an empty entry.
+func splitList(value string) []string {
+	var out []string
+	for item := range strings.SplitSeq(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,101 @@
+package env
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// StateWatcher is a view function the feeder calls at published blocks:
+// Function (balanceOf(address)) of the contract at Address with Args,
+// decoded as Returns. Interval 0 calls it at every block; a longer one at
+// the first block at least Interval after the last call, by block time.
+type StateWatcher struct {
+	// Name tells the watcher apart in the published state and the metrics.
+	Name     string        `mapstructure:"name"`
+	Address  string        `mapstructure:"address"`
+	Function string        `mapstructure:"function"`
+	Args     []string      `mapstructure:"args"`
+	Returns  []string      `mapstructure:"returns"`
+	Interval time.Duration `mapstructure:"interval"`
+}
+
+type StateWatchersConfig struct {
+	Watchers []StateWatcher `mapstructure:"watchers"`
+}
+
+// ReadStateWatchers reads the watchers file of a chain. Whether a function
+// and its args make sense is up to the feeder, which encodes them.
+func ReadStateWatchers(configPath string) ([]StateWatcher, error) {
+	var configData StateWatchersConfig
+	if err := readYAML(configPath, "state watchers", &configData); err != nil {
+		return nil, err
+	}
+
+	if err := ValidateStateWatchers(configData.Watchers); err != nil {
+		return nil, err
+	}
+
+	return configData.Watchers, nil
+}
+
+// readYAML decodes the YAML file at configPath into out; what names the file
+// in the errors.
+func readYAML(configPath, what string, out any) error {
+	if _, err := os.Stat(configPath); err != nil {
+		return err
+	}
+
+	v := viper.New()
+	v.SetConfigName(filepath.Base(configPath))
+	v.SetConfigType("yaml")
+	v.AddConfigPath(filepath.Dir(configPath))
+
+	if err := v.ReadInConfig(); err != nil {
+		return fmt.Errorf("error reading %s file, %w", what, err)
+	}
+
+	if err := v.Unmarshal(out); err != nil {
+		return fmt.Errorf("unable to decode %s into struct, %w", what, err)
+	}
+
+	return nil
+}
+
+// ValidateStateWatchers rejects watchers bots could not tell apart and the
+// ones missing what it takes to make a call.
+func ValidateStateWatchers(watchers []StateWatcher) error {
+	if len(watchers) == 0 {
+		return errors.New("state watchers file has no watchers")
+	}
+
+	names := make(map[string]bool, len(watchers))
+	for _, w := range watchers {
+		if w.Name == "" {
+			return fmt.Errorf("watcher of %s %s has an empty name", w.Address, w.Function)
+		}
+
+		if names[w.Name] {
+			return fmt.Errorf("watcher name '%s' is duplicated", w.Name)
+		}
+		names[w.Name] = true
+
+		if w.Address == "" || w.Function == "" {
+			return fmt.Errorf("watcher '%s' needs an address and a function", w.Name)
+		}
+
+		if len(w.Returns) == 0 {
+			return fmt.Errorf("watcher '%s' needs the types its function returns", w.Name)
+		}
+
+		if w.Interval < 0 {
+			return fmt.Errorf("watcher '%s' has a negative interval %s", w.Name, w.Interval)
+		}
+	}
+
+	return nil
+}
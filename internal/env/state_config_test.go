@@ -0,0 +1,80 @@
+package env
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_state_watchers_are_read_from_yaml(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.yaml")
+	config := `
+watchers:
+  - name: buffered_ether
+    address: "0xae7ab96520de3a18e5e111b5eaab095312d7fe84"
+    function: getBufferedEther()
+    returns: [uint256]
+  - name: withdrawal_queue_steth
+    address: "0xae7ab96520de3a18e5e111b5eaab095312d7fe84"
+    function: balanceOf(address)
+    args: ["0x889edc2edab5f40e902b864ad4d7ade8e412f9b1"]
+    returns: [uint256]
+    interval: 1m
+`
+	if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	watchers, err := ReadStateWatchers(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(watchers) != 2 {
+		t.Fatalf("got %d watchers, want 2", len(watchers))
+	}
+
+	buffered, queue := watchers[0], watchers[1]
+	if buffered.Interval != 0 || len(buffered.Args) != 0 || buffered.Returns[0] != "uint256" {
+		t.Errorf("unexpected buffered_ether: %+v", buffered)
+	}
+	if queue.Interval != time.Minute || len(queue.Args) != 1 || queue.Function != "balanceOf(address)" {
+		t.Errorf("unexpected withdrawal_queue_steth: %+v", queue)
+	}
+}
+
+func Test_state_watchers_are_rejected_when(t *testing.T) {
+	valid := func() []StateWatcher {
+		return []StateWatcher{
+			{Name: "a", Address: "0xc0", Function: "totalSupply()", Returns: []string{"uint256"}},
+			{Name: "b", Address: "0xc1", Function: "totalSupply()", Returns: []string{"uint256"}},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		mutate  func([]StateWatcher) []StateWatcher
+		wantErr string
+	}{
+		{"no_watchers", func([]StateWatcher) []StateWatcher { return nil }, "no watchers"},
+		{"empty_name", func(w []StateWatcher) []StateWatcher { w[1].Name = ""; return w }, "empty name"},
+		{"duplicated_name", func(w []StateWatcher) []StateWatcher { w[1].Name = "a"; return w }, "is duplicated"},
+		{"no_address", func(w []StateWatcher) []StateWatcher { w[1].Address = ""; return w }, "needs an address"},
+		{"no_returns", func(w []StateWatcher) []StateWatcher { w[1].Returns = nil; return w }, "types its function returns"},
+		{"negative_interval", func(w []StateWatcher) []StateWatcher { w[1].Interval = -time.Second; return w }, "negative interval"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateStateWatchers(tt.mutate(valid()))
+			if err == nil {
+				t.Fatalf("expected an error mentioning %q", tt.wantErr)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("got %q, want it to mention %q", err, tt.wantErr)
+			}
+		})
+	}
+}
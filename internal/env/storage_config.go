@@ -0,0 +1,61 @@
+package env
+
+import (
+	"errors"
+	"fmt"
+)
+
+// StorageSlot is a storage slot of a contract the feeder reads at every
+// published block, publishing it when it changes. Slot is implementation,
+// admin or beacon for the EIP-1967 proxy slots, or any slot as a number.
+type StorageSlot struct {
+	// Name tells the slot apart in the published diffs and the metrics.
+	Name    string `mapstructure:"name"`
+	Address string `mapstructure:"address"`
+	Slot    string `mapstructure:"slot"`
+}
+
+type StorageSlotsConfig struct {
+	Slots []StorageSlot `mapstructure:"slots"`
+}
+
+// ReadStorageSlots reads the storage slots file of a chain. Whether a slot
+// makes sense is up to the feeder, which knows the EIP-1967 names.
+func ReadStorageSlots(configPath string) ([]StorageSlot, error) {
+	var configData StorageSlotsConfig
+	if err := readYAML(configPath, "storage slots", &configData); err != nil {
+		return nil, err
+	}
+
+	if err := ValidateStorageSlots(configData.Slots); err != nil {
+		return nil, err
+	}
+
+	return configData.Slots, nil
+}
+
+// ValidateStorageSlots rejects slots bots could not tell apart and the ones
+// missing what it takes to read them.
+func ValidateStorageSlots(slots []StorageSlot) error {
+	if len(slots) == 0 {
+		return errors.New("storage slots file has no slots")
+	}
+
+	names := make(map[string]bool, len(slots))
+	for _, s := range slots {
+		if s.Name == "" {
+			return fmt.Errorf("slot %s of %s has an empty name", s.Slot, s.Address)
+		}
+
+		if names[s.Name] {
+			return fmt.Errorf("slot name '%s' is duplicated", s.Name)
+		}
+		names[s.Name] = true
+
+		if s.Address == "" || s.Slot == "" {
+			return fmt.Errorf("slot '%s' needs an address and a slot", s.Name)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,65 @@
+package env
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_storage_slots_are_read_from_yaml(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "storage.yaml")
+	config := `
+slots:
+  - name: withdrawal_queue_implementation
+    address: "0x889edc2edab5f40e902b864ad4d7ade8e412f9b1"
+    slot: implementation
+  - name: withdrawal_queue_slot_0
+    address: "0x889edc2edab5f40e902b864ad4d7ade8e412f9b1"
+    slot: "0x0"
+`
+	if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	slots, err := ReadStorageSlots(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(slots) != 2 || slots[0].Slot != "implementation" || slots[1].Slot != "0x0" {
+		t.Errorf("unexpected slots: %+v", slots)
+	}
+}
+
+func Test_storage_slots_are_rejected_when(t *testing.T) {
+	valid := func() []StorageSlot {
+		return []StorageSlot{
+			{Name: "a", Address: "0xc0", Slot: "admin"},
+			{Name: "b", Address: "0xc1", Slot: "admin"},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		mutate  func([]StorageSlot) []StorageSlot
+		wantErr string
+	}{
+		{"no_slots", func([]StorageSlot) []StorageSlot { return nil }, "no slots"},
+		{"empty_name", func(s []StorageSlot) []StorageSlot { s[1].Name = ""; return s }, "empty name"},
+		{"duplicated_name", func(s []StorageSlot) []StorageSlot { s[1].Name = "a"; return s }, "is duplicated"},
+		{"no_slot", func(s []StorageSlot) []StorageSlot { s[1].Slot = ""; return s }, "needs an address and a slot"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateStorageSlots(tt.mutate(valid()))
+			if err == nil {
+				t.Fatalf("expected an error mentioning %q", tt.wantErr)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("got %q, want it to mention %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,353 @@
+// Package abi encodes calls to contract view functions and decodes what they
+// return, for the value types state watchers ask for: uint<M>, int<M>,
+// address, bool, bytes<M>, bytes and string. Arrays and tuples are not
+// supported.
+package abi
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// WordSize is the ABI slot every value is padded to.
+const WordSize = 32
+
+var ErrShortOutput = errors.New("output is shorter than the types it returns")
+
+type kind int
+
+const (
+	kindUint kind = iota
+	kindInt
+	kindAddress
+	kindBool
+	kindFixedBytes
+	kindBytes
+	kindString
+)
+
+// Type is one ABI type. Size is the bits of an integer or the bytes of a
+// bytes<M>.
+type Type struct {
+	Name string
+	kind kind
+	Size int
+}
+
+func (t Type) dynamic() bool {
+	return t.kind == kindBytes || t.kind == kindString
+}
+
+// ParseType reads a type name. uint and int are the uint256 and int256 the
+// selector is computed over.
+func ParseType(name string) (Type, error) {
+	name = strings.TrimSpace(name)
+
+	switch name {
+	case "address":
+		return Type{Name: name, kind: kindAddress}, nil
+	case "bool":
+		return Type{Name: name, kind: kindBool}, nil
+	case "bytes":
+		return Type{Name: name, kind: kindBytes}, nil
+	case "string":
+		return Type{Name: name, kind: kindString}, nil
+	case "uint":
+		return Type{Name: "uint256", kind: kindUint, Size: 256}, nil
+	case "int":
+		return Type{Name: "int256", kind: kindInt, Size: 256}, nil
+	}
+
+	for prefix, k := range map[string]kind{"uint": kindUint, "int": kindInt, "bytes": kindFixedBytes} {
+		size, found := strings.CutPrefix(name, prefix)
+		if !found {
+			continue
+		}
+
+		n, err := strconv.Atoi(size)
+		if err != nil {
+			break
+		}
+
+		if k == kindFixedBytes {
+			if n < 1 || n > WordSize {
+				return Type{}, fmt.Errorf("%s: size must be 1 to %d bytes", name, WordSize)
+			}
+		} else if n < 8 || n > 256 || n%8 != 0 {
+			return Type{}, fmt.Errorf("%s: size must be a multiple of 8 up to 256", name)
+		}
+
+		return Type{Name: name, kind: k, Size: n}, nil
+	}
+
+	return Type{}, fmt.Errorf("unsupported type %q", name)
+}
+
+// Function is a view function as a watcher calls it.
+type Function struct {
+	// Signature is the canonical name(type,...) the selector is hashed from.
+	Signature string
+	Selector  [4]byte
+	Inputs    []Type
+	Outputs   []Type
+}
+
+// ParseFunction reads a signature like balanceOf(address) and the types the
+// function returns.
+func ParseFunction(signature string, returns []string) (*Function, error) {
+	open := strings.IndexByte(signature, '(')
+	if open <= 0 || !strings.HasSuffix(signature, ")") {
+		return nil, fmt.Errorf("function %q: want name(type,...)", signature)
+	}
+
+	name := strings.TrimSpace(signature[:open])
+
+	var inputs []Type
+	if params := strings.TrimSpace(signature[open+1 : len(signature)-1]); params != "" {
+		for param := range strings.SplitSeq(params, ",") {
+			t, err := ParseType(param)
+			if err != nil {
+				return nil, fmt.Errorf("function %q: %w", signature, err)
+			}
+
+			inputs = append(inputs, t)
+		}
+	}
+
+	if len(returns) == 0 {
+		return nil, fmt.Errorf("function %q returns nothing to watch", signature)
+	}
+
+	outputs := make([]Type, 0, len(returns))
+	for _, r := range returns {
+		t, err := ParseType(r)
+		if err != nil {
+			return nil, fmt.Errorf("function %q: %w", signature, err)
+		}
+
+		outputs = append(outputs, t)
+	}
+
+	names := make([]string, 0, len(inputs))
+	for _, t := range inputs {
+		names = append(names, t.Name)
+	}
+
+	f := &Function{
+		Signature: name + "(" + strings.Join(names, ",") + ")",
+		Inputs:    inputs,
+		Outputs:   outputs,
+	}
+
+	h := sha3.NewLegacyKeccak256()
+	h.Write([]byte(f.Signature))
+	copy(f.Selector[:], h.Sum(nil))
+
+	return f, nil
+}
+
+// Pack encodes a call of f with args, given as text: integers in decimal or
+// 0x hex, addresses and bytes in 0x hex, bools as true or false.
+func (f *Function) Pack(args []string) ([]byte, error) {
+	if len(args) != len(f.Inputs) {
+		return nil, fmt.Errorf("%s takes %d arguments, got %d", f.Signature, len(f.Inputs), len(args))
+	}
+
+	head := make([]byte, 0, len(args)*WordSize)
+	var tail []byte
+
+	for i, t := range f.Inputs {
+		word, data, err := pack(t, args[i])
+		if err != nil {
+			return nil, fmt.Errorf("%s argument %d: %w", f.Signature, i, err)
+		}
+
+		if t.dynamic() {
+			// Dynamic values go after the head, which points at them.
+			word = uintWord(big.NewInt(int64(len(f.Inputs)*WordSize + len(tail))))
+			tail = append(tail, data...)
+		}
+
+		head = append(head, word...)
+	}
+
+	return slices.Concat(f.Selector[:], head, tail), nil
+}
+
+func pack(t Type, arg string) ([]byte, []byte, error) {
+	switch t.kind {
+	case kindUint, kindInt:
+		n, ok := new(big.Int).SetString(arg, 0)
+		if !ok {
+			return nil, nil, fmt.Errorf("%q is not an integer", arg)
+		}
+
+		limit := new(big.Int).Lsh(big.NewInt(1), uint(t.Size))
+		if t.kind == kindInt {
+			limit.Rsh(limit, 1)
+			if n.Cmp(limit) >= 0 || n.Cmp(new(big.Int).Neg(limit)) < 0 {
+				return nil, nil, fmt.Errorf("%s out of %s range", arg, t.Name)
+			}
+
+			// Two's complement over the whole word.
+			if n.Sign() < 0 {
+				n = new(big.Int).Add(n, new(big.Int).Lsh(big.NewInt(1), WordSize*8))
+			}
+
+			return uintWord(n), nil, nil
+		}
+
+		if n.Sign() < 0 || n.Cmp(limit) >= 0 {
+			return nil, nil, fmt.Errorf("%s out of %s range", arg, t.Name)
+		}
+
+		return uintWord(n), nil, nil
+	case kindAddress:
+		raw, err := decodeHex(arg)
+		if err != nil || len(raw) != 20 {
+			return nil, nil, fmt.Errorf("%q is not an address", arg)
+		}
+
+		return leftPad(raw), nil, nil
+	case kindBool:
+		switch arg {
+		case "true":
+			return uintWord(big.NewInt(1)), nil, nil
+		case "false":
+			return uintWord(big.NewInt(0)), nil, nil
+		}
+
+		return nil, nil, fmt.Errorf("%q is not a bool", arg)
+	case kindFixedBytes:
+		raw, err := decodeHex(arg)
+		if err != nil || len(raw) != t.Size {
+			return nil, nil, fmt.Errorf("%q is not %d bytes of hex", arg, t.Size)
+		}
+
+		return rightPad(raw), nil, nil
+	case kindBytes:
+		raw, err := decodeHex(arg)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%q is not hex", arg)
+		}
+
+		return nil, packDynamic(raw), nil
+	default:
+		return nil, packDynamic([]byte(arg)), nil
+	}
+}
+
+func packDynamic(raw []byte) []byte {
+	out := uintWord(big.NewInt(int64(len(raw))))
+	for i := 0; i < len(raw); i += WordSize {
+		out = append(out, rightPad(raw[i:min(i+WordSize, len(raw))])...)
+	}
+
+	return out
+}
+
+// Unpack decodes what f returned as text the way Pack reads it: integers in
+// decimal, addresses and bytes in lowercase 0x hex, bools as true or false.
+func (f *Function) Unpack(output []byte) ([]string, error) {
+	if len(output) < len(f.Outputs)*WordSize {
+		return nil, fmt.Errorf("%s: %w: got %d bytes", f.Signature, ErrShortOutput, len(output))
+	}
+
+	values := make([]string, 0, len(f.Outputs))
+	for i, t := range f.Outputs {
+		word := output[i*WordSize : (i+1)*WordSize]
+
+		value, err := unpack(t, word, output)
+		if err != nil {
+			return nil, fmt.Errorf("%s value %d: %w", f.Signature, i, err)
+		}
+
+		values = append(values, value)
+	}
+
+	return values, nil
+}
+
+func unpack(t Type, word, output []byte) (string, error) {
+	switch t.kind {
+	case kindUint:
+		return new(big.Int).SetBytes(word).String(), nil
+	case kindInt:
+		n := new(big.Int).SetBytes(word)
+		if word[0]&0x80 != 0 {
+			n.Sub(n, new(big.Int).Lsh(big.NewInt(1), WordSize*8))
+		}
+
+		return n.String(), nil
+	case kindAddress:
+		return "0x" + hex.EncodeToString(word[WordSize-20:]), nil
+	case kindBool:
+		return strconv.FormatBool(new(big.Int).SetBytes(word).Sign() != 0), nil
+	case kindFixedBytes:
+		return "0x" + hex.EncodeToString(word[:t.Size]), nil
+	}
+
+	offset, err := index(word, len(output))
+	if err != nil {
+		return "", err
+	}
+
+	if offset+WordSize > len(output) {
+		return "", ErrShortOutput
+	}
+
+	length, err := index(output[offset:offset+WordSize], len(output))
+	if err != nil {
+		return "", err
+	}
+
+	start := offset + WordSize
+	if start+length > len(output) {
+		return "", ErrShortOutput
+	}
+
+	data := output[start : start+length]
+	if t.kind == kindString {
+		return string(data), nil
+	}
+
+	return "0x" + hex.EncodeToString(data), nil
+}
+
+// index reads an offset or a length, which cannot point past the output.
+func index(word []byte, limit int) (int, error) {
+	n := new(big.Int).SetBytes(word)
+	if !n.IsInt64() || n.Int64() > int64(limit) {
+		return 0, ErrShortOutput
+	}
+
+	return int(n.Int64()), nil
+}
+
+func uintWord(n *big.Int) []byte {
+	return n.FillBytes(make([]byte, WordSize))
+}
+
+func leftPad(raw []byte) []byte {
+	return append(bytes.Repeat([]byte{0}, WordSize-len(raw)), raw...)
+}
+
+func rightPad(raw []byte) []byte {
+	if len(raw)%WordSize == 0 {
+		return raw
+	}
+
+	return append(raw[:len(raw):len(raw)], bytes.Repeat([]byte{0}, WordSize-len(raw)%WordSize)...)
+}
+
+func decodeHex(value string) ([]byte, error) {
+	return hex.DecodeString(strings.TrimPrefix(value, "0x"))
+}
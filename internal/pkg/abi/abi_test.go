@@ -0,0 +1,146 @@
+package abi
+
+import (
+	"encoding/hex"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func mustParse(t *testing.T, signature string, returns ...string) *Function {
+	t.Helper()
+
+	f, err := ParseFunction(signature, returns)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return f
+}
+
+func words(hexWords ...string) []byte {
+	raw, err := hex.DecodeString(strings.Join(hexWords, ""))
+	if err != nil {
+		panic(err)
+	}
+
+	return raw
+}
+
+func Test_selector_is_hashed_from_the_canonical_signature(t *testing.T) {
+	tests := []struct {
+		signature string
+		want      string
+	}{
+		{"totalSupply()", "18160ddd"},
+		{"balanceOf(address)", "70a08231"},
+		{"transfer(address, uint)", "a9059cbb"},
+	}
+
+	for _, tt := range tests {
+		f := mustParse(t, tt.signature, "uint256")
+		if got := hex.EncodeToString(f.Selector[:]); got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.signature, got, tt.want)
+		}
+	}
+}
+
+func Test_pack_encodes_static_and_dynamic_arguments(t *testing.T) {
+	f := mustParse(t, "f(address,int8,bool,string)", "bool")
+
+	got, err := f.Pack([]string{"0xae7ab96520DE3A18E5e111B5EaAb095312D7fE84", "-1", "true", "hi"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := slices.Concat(f.Selector[:], words(
+		"000000000000000000000000ae7ab96520de3a18e5e111b5eaab095312d7fe84",
+		"ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+		"0000000000000000000000000000000000000000000000000000000000000001",
+		"0000000000000000000000000000000000000000000000000000000000000080",
+		"0000000000000000000000000000000000000000000000000000000000000002",
+		"6869000000000000000000000000000000000000000000000000000000000000",
+	))
+	if !slices.Equal(got, want) {
+		t.Errorf("got  %x\nwant %x", got, want)
+	}
+}
+
+func Test_pack_rejects_arguments_that_do_not_fit(t *testing.T) {
+	tests := []struct {
+		name string
+		typ  string
+		arg  string
+	}{
+		{"negative_uint", "uint256", "-1"},
+		{"uint8_overflow", "uint8", "256"},
+		{"int8_overflow", "int8", "128"},
+		{"short_address", "address", "0xae7a"},
+		{"bool", "bool", "yes"},
+		{"bytes32_length", "bytes32", "0x01"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := mustParse(t, "f("+tt.typ+")", "bool")
+			if _, err := f.Pack([]string{tt.arg}); err == nil {
+				t.Errorf("%s accepted %q", tt.typ, tt.arg)
+			}
+		})
+	}
+
+	if _, err := mustParse(t, "f(uint256)", "bool").Pack(nil); err == nil {
+		t.Error("a missing argument was accepted")
+	}
+}
+
+func Test_unpack_decodes_what_the_function_returns(t *testing.T) {
+	f := mustParse(t, "f()", "uint256", "int256", "address", "bool", "bytes4", "string")
+
+	got, err := f.Unpack(words(
+		"0000000000000000000000000000000000000000000000000de0b6b3a7640000",
+		"fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffe",
+		"000000000000000000000000ae7ab96520de3a18e5e111b5eaab095312d7fe84",
+		"0000000000000000000000000000000000000000000000000000000000000000",
+		"70a0823100000000000000000000000000000000000000000000000000000000",
+		"00000000000000000000000000000000000000000000000000000000000000c0",
+		"0000000000000000000000000000000000000000000000000000000000000005",
+		"7374455448000000000000000000000000000000000000000000000000000000",
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"1000000000000000000", "-2", "0xae7ab96520de3a18e5e111b5eaab095312d7fe84", "false", "0x70a08231", "stETH"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func Test_unpack_rejects_short_output(t *testing.T) {
+	// An EOA answers every call with nothing.
+	if _, err := mustParse(t, "f()", "uint256").Unpack(nil); err == nil {
+		t.Error("empty output decoded")
+	}
+
+	// A string whose length points past the output.
+	f := mustParse(t, "f()", "string")
+	if _, err := f.Unpack(words(
+		"0000000000000000000000000000000000000000000000000000000000000020",
+		"00000000000000000000000000000000000000000000000000000000000000ff",
+	)); err == nil {
+		t.Error("truncated string decoded")
+	}
+}
+
+func Test_unsupported_types_are_rejected(t *testing.T) {
+	for _, typ := range []string{"uint7", "uint264", "bytes33", "address[]", "tuple", "(uint256,bool)"} {
+		if _, err := ParseFunction("f("+typ+")", []string{"bool"}); err == nil {
+			t.Errorf("%s accepted", typ)
+		}
+	}
+
+	if _, err := ParseFunction("f()", nil); err == nil {
+		t.Error("a function returning nothing accepted")
+	}
+}
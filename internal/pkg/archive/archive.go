@@ -0,0 +1,302 @@
+// Package archive keeps the blocks the feeder published on local disk, exactly
+// as they went out: the zstd-compressed JSON payload and the dictionary it
+// needs. A replay reads them back instead of asking the RPC again.
+//
+// The archive is a directory of segments, one per SegmentBlocks heights. A
+// segment is two files named by its first height: <start>.blocks holds the
+// payloads back to back, <start>.index one fixed-size entry per payload:
+//
+//	number uint64 | offset uint64 | size uint32 | dictID uint32 | hash [32]byte
+//
+// big-endian. Entries are only ever appended; a height published twice (a
+// reorg) is read back as its last entry. Payloads are written before their
+// entry, so a crash leaves at most a payload nobody points at.
+package archive
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultSegmentBlocks is about 33 hours of mainnet per segment.
+const DefaultSegmentBlocks = 10_000
+
+const (
+	blocksExt = `.blocks`
+	indexExt  = `.index`
+	entrySize = 8 + 8 + 4 + 4 + 32
+	hashSize  = 32
+)
+
+// Entry is one archived block.
+type Entry struct {
+	Number int64
+	// Hash is 0x-prefixed, the way the DTO carries it.
+	Hash   string
+	DictID uint32
+	// Payload is the message as it was published, zstd-compressed.
+	Payload []byte
+}
+
+// Writer appends published blocks to the archive in dir, keeping at most
+// maxSegments segments; the oldest go first. It is safe for concurrent use.
+type Writer struct {
+	dir           string
+	segmentBlocks int64
+	maxSegments   int
+
+	mu  sync.Mutex
+	cur *segment
+}
+
+type segment struct {
+	start  int64
+	blocks *os.File
+	index  *os.File
+	size   int64
+}
+
+// NewWriter opens the archive in dir, creating it if needed. segmentBlocks
+// below 1 means DefaultSegmentBlocks, maxSegments below 1 keeps every segment.
+func NewWriter(dir string, segmentBlocks int64, maxSegments int) (*Writer, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create archive dir: %w", err)
+	}
+
+	if segmentBlocks < 1 {
+		segmentBlocks = DefaultSegmentBlocks
+	}
+
+	return &Writer{dir: dir, segmentBlocks: segmentBlocks, maxSegments: maxSegments}, nil
+}
+
+// Append archives the payload of block number with its hash and the ID of the
+// zstd dictionary it was compressed with, 0 for none.
+func (w *Writer) Append(number int64, hash string, dictID uint32, payload []byte) error {
+	rawHash, err := decodeHash(hash)
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	start := number - number%w.segmentBlocks
+	if w.cur == nil || w.cur.start != start {
+		if err := w.open(start); err != nil {
+			return err
+		}
+	}
+
+	s := w.cur
+
+	if _, err := s.blocks.Write(payload); err != nil {
+		return fmt.Errorf("write block %d: %w", number, err)
+	}
+
+	var entry [entrySize]byte
+	binary.BigEndian.PutUint64(entry[0:], uint64(number))
+	binary.BigEndian.PutUint64(entry[8:], uint64(s.size))
+	binary.BigEndian.PutUint32(entry[16:], uint32(len(payload)))
+	binary.BigEndian.PutUint32(entry[20:], dictID)
+	copy(entry[24:], rawHash)
+
+	s.size += int64(len(payload))
+
+	if _, err := s.index.Write(entry[:]); err != nil {
+		return fmt.Errorf("write index of block %d: %w", number, err)
+	}
+
+	return nil
+}
+
+// Close closes the open segment.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.closeSegment()
+}
+
+func (w *Writer) open(start int64) error {
+	if err := w.closeSegment(); err != nil {
+		return err
+	}
+
+	base := filepath.Join(w.dir, segmentName(start))
+
+	blocks, err := os.OpenFile(base+blocksExt, os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return fmt.Errorf("open segment %d: %w", start, err)
+	}
+
+	index, err := os.OpenFile(base+indexExt, os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		blocks.Close()
+		return fmt.Errorf("open index of segment %d: %w", start, err)
+	}
+
+	s := &segment{start: start, blocks: blocks, index: index}
+
+	// A crash may have cut the last entry short, or left a payload nobody
+	// points at; both are dropped so new ones line up.
+	if err := s.repair(); err != nil {
+		s.close()
+		return fmt.Errorf("repair segment %d: %w", start, err)
+	}
+
+	w.cur = s
+
+	return w.rotate()
+}
+
+func (w *Writer) closeSegment() error {
+	if w.cur == nil {
+		return nil
+	}
+
+	err := w.cur.close()
+	w.cur = nil
+
+	return err
+}
+
+// rotate removes the oldest segments beyond maxSegments.
+func (w *Writer) rotate() error {
+	if w.maxSegments < 1 {
+		return nil
+	}
+
+	starts, err := segments(w.dir)
+	if err != nil {
+		return err
+	}
+
+	for len(starts) > w.maxSegments {
+		oldest := starts[0]
+		starts = starts[1:]
+
+		// Never the one being written: backfilling below the oldest segment
+		// would remove it right away.
+		if oldest == w.cur.start {
+			continue
+		}
+
+		base := filepath.Join(w.dir, segmentName(oldest))
+		if err := errors.Join(os.Remove(base+indexExt), os.Remove(base+blocksExt)); err != nil {
+			return fmt.Errorf("remove segment %d: %w", oldest, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *segment) repair() error {
+	entries, err := readIndex(s.index)
+	if err != nil {
+		return err
+	}
+
+	if err := s.index.Truncate(int64(len(entries) * entrySize)); err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		s.size = max(s.size, int64(e.offset)+int64(e.size))
+	}
+
+	if err := s.blocks.Truncate(s.size); err != nil {
+		return err
+	}
+
+	if _, err := s.index.Seek(0, io.SeekEnd); err != nil {
+		return err
+	}
+
+	_, err = s.blocks.Seek(0, io.SeekEnd)
+	return err
+}
+
+func (s *segment) close() error {
+	return errors.Join(s.blocks.Close(), s.index.Close())
+}
+
+type indexEntry struct {
+	number int64
+	offset uint64
+	size   uint32
+	dictID uint32
+	hash   [hashSize]byte
+}
+
+// readIndex reads every whole entry of an index from its start.
+func readIndex(f *os.File) ([]indexEntry, error) {
+	data, err := io.ReadAll(io.NewSectionReader(f, 0, 1<<62))
+	if err != nil {
+		return nil, fmt.Errorf("read index: %w", err)
+	}
+
+	entries := make([]indexEntry, 0, len(data)/entrySize)
+	for off := 0; off+entrySize <= len(data); off += entrySize {
+		e := indexEntry{
+			number: int64(binary.BigEndian.Uint64(data[off:])),
+			offset: binary.BigEndian.Uint64(data[off+8:]),
+			size:   binary.BigEndian.Uint32(data[off+16:]),
+			dictID: binary.BigEndian.Uint32(data[off+20:]),
+		}
+		copy(e.hash[:], data[off+24:off+entrySize])
+
+		entries = append(entries, e)
+	}
+
+	return entries, nil
+}
+
+// segments returns the first heights of the segments in dir, in order.
+func segments(dir string) ([]int64, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("list archive: %w", err)
+	}
+
+	var starts []int64
+	for _, f := range files {
+		name, ok := strings.CutSuffix(f.Name(), indexExt)
+		if !ok {
+			continue
+		}
+
+		start, err := strconv.ParseInt(name, 10, 64)
+		if err != nil {
+			continue
+		}
+
+		starts = append(starts, start)
+	}
+
+	slices.Sort(starts)
+
+	return starts, nil
+}
+
+func segmentName(start int64) string {
+	return fmt.Sprintf("%012d", start)
+}
+
+func decodeHash(hash string) ([]byte, error) {
+	raw, err := hex.DecodeString(strings.TrimPrefix(hash, "0x"))
+	if err != nil || len(raw) != hashSize {
+		return nil, fmt.Errorf("bad block hash %q", hash)
+	}
+
+	return raw, nil
+}
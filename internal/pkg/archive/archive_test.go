@@ -0,0 +1,130 @@
+package archive
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func hash(n int64, fork string) string {
+	return fmt.Sprintf("0x%s%062x", fork, n)[:66]
+}
+
+func readAll(t *testing.T, dir string, from, to int64) []Entry {
+	t.Helper()
+
+	var out []Entry
+	if err := Read(dir, from, to, func(e Entry) error {
+		out = append(out, e)
+		return nil
+	}); err != nil {
+		t.Fatalf("read: %v", err)
+	}
+
+	return out
+}
+
+func Test_blocks_come_back_as_they_were_written(t *testing.T) {
+	dir := t.TempDir()
+
+	w, err := NewWriter(dir, 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Across a segment boundary, with 15 republished after a reorg.
+	for n := int64(8); n <= 16; n++ {
+		if err := w.Append(n, hash(n, "aa"), 7, []byte(fmt.Sprintf("block %d", n))); err != nil {
+			t.Fatalf("append %d: %v", n, err)
+		}
+	}
+	if err := w.Append(15, hash(15, "bb"), 7, []byte("block 15 again")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	got := readAll(t, dir, 9, 15)
+	if len(got) != 7 {
+		t.Fatalf("got %d blocks, want 9..15", len(got))
+	}
+
+	for i, e := range got {
+		if e.Number != int64(9+i) || e.DictID != 7 {
+			t.Errorf("entry %d: got block %d with dictionary %d", i, e.Number, e.DictID)
+		}
+	}
+
+	last := got[len(got)-1]
+	if string(last.Payload) != "block 15 again" || last.Hash != hash(15, "bb") {
+		t.Errorf("a republished height must read back as its last block, got %q %s", last.Payload, last.Hash)
+	}
+}
+
+func Test_oldest_segments_roll_off(t *testing.T) {
+	dir := t.TempDir()
+
+	w, err := NewWriter(dir, 10, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	for _, n := range []int64{5, 15, 25} {
+		if err := w.Append(n, hash(n, "aa"), 0, []byte("x")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := readAll(t, dir, 0, 100); len(got) != 2 || got[0].Number != 15 {
+		t.Errorf("got %v, want blocks 15 and 25 only", got)
+	}
+}
+
+func Test_a_torn_entry_is_dropped_on_reopen(t *testing.T) {
+	dir := t.TempDir()
+
+	w, err := NewWriter(dir, 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = w.Append(1, hash(1, "aa"), 0, []byte("one"))
+	_ = w.Close()
+
+	// A crash halfway through the next entry.
+	index := filepath.Join(dir, segmentName(0)+indexExt)
+	f, err := os.OpenFile(index, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.Write([]byte("torn"))
+	_ = f.Close()
+
+	if w, err = NewWriter(dir, 10, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Append(2, hash(2, "aa"), 0, []byte("two")); err != nil {
+		t.Fatal(err)
+	}
+	_ = w.Close()
+
+	got := readAll(t, dir, 0, 10)
+	if len(got) != 2 || string(got[1].Payload) != "two" {
+		t.Errorf("got %v, want blocks 1 and 2", got)
+	}
+}
+
+func Test_bad_hash_is_refused(t *testing.T) {
+	w, err := NewWriter(t.TempDir(), 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	if err := w.Append(1, "0x1", 0, nil); err == nil || !strings.Contains(err.Error(), "hash") {
+		t.Errorf("got %v, want a bad hash error", err)
+	}
+}
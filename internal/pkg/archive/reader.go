@@ -0,0 +1,84 @@
+package archive
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// Read calls fn for every archived block from..to in height order, with the
+// last payload archived for each height. Heights the archive does not have
+// are skipped; fn tells by the numbers. An error from fn stops the read and
+// is returned as is.
+func Read(dir string, from, to int64, fn func(Entry) error) error {
+	starts, err := segments(dir)
+	if err != nil {
+		return err
+	}
+
+	for i, start := range starts {
+		// Segments only say where they start; the next one bounds this one.
+		if start > to || (i+1 < len(starts) && starts[i+1] <= from) {
+			continue
+		}
+
+		if err := readSegment(filepath.Join(dir, segmentName(start)), from, to, fn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func readSegment(base string, from, to int64, fn func(Entry) error) error {
+	index, err := os.Open(base + indexExt)
+	if err != nil {
+		return fmt.Errorf("open index: %w", err)
+	}
+	defer index.Close()
+
+	entries, err := readIndex(index)
+	if err != nil {
+		return err
+	}
+
+	latest := make(map[int64]indexEntry, len(entries))
+	for _, e := range entries {
+		if e.number >= from && e.number <= to {
+			latest[e.number] = e
+		}
+	}
+
+	if len(latest) == 0 {
+		return nil
+	}
+
+	blocks, err := os.Open(base + blocksExt)
+	if err != nil {
+		return fmt.Errorf("open segment: %w", err)
+	}
+	defer blocks.Close()
+
+	numbers := make([]int64, 0, len(latest))
+	for n := range latest {
+		numbers = append(numbers, n)
+	}
+	slices.Sort(numbers)
+
+	for _, n := range numbers {
+		e := latest[n]
+
+		payload := make([]byte, e.size)
+		if _, err := blocks.ReadAt(payload, int64(e.offset)); err != nil {
+			return fmt.Errorf("read block %d: %w", n, err)
+		}
+
+		if err := fn(Entry{Number: n, Hash: "0x" + hex.EncodeToString(e.hash[:]), DictID: e.dictID, Payload: payload}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
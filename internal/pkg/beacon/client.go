@@ -0,0 +1,128 @@
+package beacon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/avast/retry-go/v4"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/lidofinance/onchain-mon/internal/connectors/metrics"
+	"github.com/lidofinance/onchain-mon/internal/utils/text"
+)
+
+// SlotsPerEpoch is the same on every chain Lido runs validators on.
+const SlotsPerEpoch = 32
+
+const MaxAttempts = 4
+const RetryDelay = 100 * time.Millisecond
+const MaxDelay = 2 * time.Second
+
+// MaxErrorBody is how much of an error answer goes into the error.
+const MaxErrorBody = 200
+
+// The head block, and the state at it, as the Beacon API names them.
+const (
+	BlockHead = `head`
+	StateHead = `head`
+)
+
+// ErrNoBlock is a slot without a block: the proposer missed it.
+var ErrNoBlock = errors.New("no block at this slot")
+
+// Client reads blocks and checkpoints from a Beacon API node.
+type Client struct {
+	url        string
+	httpClient *http.Client
+	metrics    *metrics.Store
+}
+
+func NewClient(url string, httpClient *http.Client, metricsStore *metrics.Store) *Client {
+	return &Client{
+		url:        strings.TrimRight(url, "/"),
+		httpClient: httpClient,
+		metrics:    metricsStore,
+	}
+}
+
+// GetHeader returns the header of the block at blockID: a slot, a root or
+// BlockHead. A missed slot is ErrNoBlock.
+func (c *Client) GetHeader(ctx context.Context, blockID string) (*Header, error) {
+	return get[Header](ctx, c, "beacon_headers", "/eth/v1/beacon/headers/"+blockID)
+}
+
+// GetBlock returns the block at blockID, a root preferably: a slot may be
+// taken by another block after a reorg.
+func (c *Client) GetBlock(ctx context.Context, blockID string) (*SignedBlock, error) {
+	return get[SignedBlock](ctx, c, "beacon_blocks", "/eth/v2/beacon/blocks/"+blockID)
+}
+
+// GetFinalityCheckpoints returns the checkpoints as of stateID, StateHead for
+// the latest ones.
+func (c *Client) GetFinalityCheckpoints(ctx context.Context, stateID string) (*FinalityCheckpoints, error) {
+	return get[FinalityCheckpoints](ctx, c, "beacon_finality_checkpoints", "/eth/v1/beacon/states/"+stateID+"/finality_checkpoints")
+}
+
+func get[T any](ctx context.Context, c *Client, channel, path string) (*T, error) {
+	out, err := retry.DoWithData(
+		func() (*T, error) {
+			start := time.Now()
+			defer func() {
+				c.metrics.SummaryHandlers.With(prometheus.Labels{metrics.Channel: channel}).Observe(time.Since(start).Seconds())
+			}()
+
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url+path, nil)
+			if err != nil {
+				return nil, retry.Unrecoverable(fmt.Errorf("could not create request: %w", err))
+			}
+
+			req.Header.Set("Accept", "application/json")
+
+			resp, err := c.httpClient.Do(req)
+			if err != nil {
+				return nil, fmt.Errorf("could not send request: %w", err)
+			}
+			defer resp.Body.Close()
+
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				return nil, fmt.Errorf("could not read response body: %w", err)
+			}
+
+			if resp.StatusCode == http.StatusNotFound {
+				return nil, retry.Unrecoverable(ErrNoBlock)
+			}
+
+			if resp.StatusCode != http.StatusOK {
+				return nil, fmt.Errorf("%s: status %d: %s", path, resp.StatusCode, body[:min(len(body), MaxErrorBody)])
+			}
+
+			var r Response[T]
+			if err := json.Unmarshal(body, &r); err != nil {
+				return nil, fmt.Errorf("could not unmarshal %s: %w", path, err)
+			}
+
+			return &r.Data, nil
+		},
+		retry.Attempts(MaxAttempts),
+		retry.Delay(RetryDelay),
+		retry.MaxDelay(MaxDelay),
+		retry.LastErrorOnly(true),
+		retry.Context(ctx),
+	)
+	if err != nil {
+		if errors.Is(err, ErrNoBlock) {
+			return nil, ErrNoBlock
+		}
+
+		return nil, errors.New(text.LeaveOnlyDomainInURLs(err.Error()))
+	}
+
+	return out, nil
+}
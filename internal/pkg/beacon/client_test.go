@@ -0,0 +1,94 @@
+package beacon
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/lidofinance/onchain-mon/internal/connectors/metrics"
+)
+
+func newTestMetrics(t *testing.T) *metrics.Store {
+	t.Helper()
+	return metrics.New(prometheus.NewRegistry(), "beacon_test", "t", "t")
+}
+
+// A block as the Beacon API serves it: string quantities, one of each operation the feeder
+// publishes, and the validators 7 and 9 in both conflicting attestations.
+const blockBody = `{
+  "version": "deneb",
+  "execution_optimistic": false,
+  "finalized": false,
+  "data": {
+    "message": {
+      "slot": "100",
+      "proposer_index": "42",
+      "parent_root": "0xparent",
+      "state_root": "0xstate",
+      "body": {
+        "proposer_slashings": [{"signed_header_1": {"message": {"slot": "90", "proposer_index": "5"}}}],
+        "attester_slashings": [{
+          "attestation_1": {"attesting_indices": ["7", "8", "9"], "data": {"slot": "60", "target": {"epoch": "1"}}},
+          "attestation_2": {"attesting_indices": ["7", "9", "10"], "data": {"slot": "61", "target": {"epoch": "1"}}}
+        }],
+        "voluntary_exits": [{"message": {"epoch": "3", "validator_index": "11"}}],
+        "execution_payload": {
+          "block_number": "2000",
+          "block_hash": "0xexec",
+          "withdrawals": [{"index": "1", "validator_index": "12", "address": "0xaddr", "amount": "32000000000"}]
+        }
+      }
+    }
+  }
+}`
+
+func Test_block_is_decoded(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/eth/v2/beacon/blocks/0xroot" {
+			http.NotFound(w, r)
+			return
+		}
+
+		_, _ = w.Write([]byte(blockBody))
+	}))
+	defer srv.Close()
+
+	block, err := NewClient(srv.URL+"/", srv.Client(), newTestMetrics(t)).GetBlock(context.Background(), "0xroot")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	m := block.Message
+	if m.Slot != 100 || m.ProposerIndex != 42 || m.Body.ExecutionPayload.Withdrawals[0].Amount != 32_000_000_000 {
+		t.Errorf("got slot %d, proposer %d, %+v", m.Slot, m.ProposerIndex, m.Body.ExecutionPayload)
+	}
+
+	slashed := m.Body.AttesterSlashings[0].SlashedIndices()
+	if len(slashed) != 2 || slashed[0] != 7 || slashed[1] != 9 {
+		t.Errorf("got slashed %v, want [7 9]", slashed)
+	}
+}
+
+func Test_missed_slot_is_no_block(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"code":404,"message":"NOT_FOUND: beacon block at slot 101"}`))
+	}))
+	defer srv.Close()
+
+	_, err := NewClient(srv.URL, srv.Client(), newTestMetrics(t)).GetHeader(context.Background(), "101")
+	if !errors.Is(err, ErrNoBlock) {
+		t.Errorf("got %v, want ErrNoBlock", err)
+	}
+}
+
+func Test_bad_quantity_is_an_error(t *testing.T) {
+	var q Quantity
+	if err := q.UnmarshalJSON([]byte(`12`)); err == nil {
+		t.Error("a bare number must be rejected: the Beacon API sends strings")
+	}
+}
@@ -0,0 +1,142 @@
+package beacon
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// Quantity is an unsigned integer the Beacon API sends as a decimal string.
+type Quantity uint64
+
+func (q *Quantity) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("quantity must be a string: %w", err)
+	}
+
+	v, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return fmt.Errorf("bad quantity %q: %w", s, err)
+	}
+
+	*q = Quantity(v)
+	return nil
+}
+
+// Response wraps every Beacon API answer.
+type Response[T any] struct {
+	Data                T    `json:"data"`
+	ExecutionOptimistic bool `json:"execution_optimistic"`
+	Finalized           bool `json:"finalized"`
+}
+
+// Header is /eth/v1/beacon/headers/{block_id}: the root of the block at a
+// slot, or of the head.
+type Header struct {
+	Root      string `json:"root"`
+	Canonical bool   `json:"canonical"`
+	Header    struct {
+		Message BlockHeader `json:"message"`
+	} `json:"header"`
+}
+
+type BlockHeader struct {
+	Slot          Quantity `json:"slot"`
+	ProposerIndex Quantity `json:"proposer_index"`
+	ParentRoot    string   `json:"parent_root"`
+	StateRoot     string   `json:"state_root"`
+	BodyRoot      string   `json:"body_root"`
+}
+
+// SignedBlock is /eth/v2/beacon/blocks/{block_id}, with only the fields the
+// feeder publishes.
+type SignedBlock struct {
+	Message Block `json:"message"`
+}
+
+type Block struct {
+	Slot          Quantity  `json:"slot"`
+	ProposerIndex Quantity  `json:"proposer_index"`
+	ParentRoot    string    `json:"parent_root"`
+	StateRoot     string    `json:"state_root"`
+	Body          BlockBody `json:"body"`
+}
+
+type BlockBody struct {
+	ProposerSlashings []ProposerSlashing `json:"proposer_slashings"`
+	AttesterSlashings []AttesterSlashing `json:"attester_slashings"`
+	VoluntaryExits    []SignedExit       `json:"voluntary_exits"`
+	// ExecutionPayload is nil before the merge.
+	ExecutionPayload *ExecutionPayload `json:"execution_payload"`
+}
+
+type ProposerSlashing struct {
+	SignedHeader1 struct {
+		Message BlockHeader `json:"message"`
+	} `json:"signed_header_1"`
+}
+
+type AttesterSlashing struct {
+	Attestation1 IndexedAttestation `json:"attestation_1"`
+	Attestation2 IndexedAttestation `json:"attestation_2"`
+}
+
+type IndexedAttestation struct {
+	AttestingIndices []Quantity `json:"attesting_indices"`
+	Data             struct {
+		Slot   Quantity `json:"slot"`
+		Target struct {
+			Epoch Quantity `json:"epoch"`
+		} `json:"target"`
+	} `json:"data"`
+}
+
+type SignedExit struct {
+	Message struct {
+		Epoch          Quantity `json:"epoch"`
+		ValidatorIndex Quantity `json:"validator_index"`
+	} `json:"message"`
+}
+
+type ExecutionPayload struct {
+	BlockNumber Quantity     `json:"block_number"`
+	BlockHash   string       `json:"block_hash"`
+	Withdrawals []Withdrawal `json:"withdrawals"`
+}
+
+type Withdrawal struct {
+	Index          Quantity `json:"index"`
+	ValidatorIndex Quantity `json:"validator_index"`
+	Address        string   `json:"address"`
+	// Amount is in gwei.
+	Amount Quantity `json:"amount"`
+}
+
+// FinalityCheckpoints is /eth/v1/beacon/states/{state_id}/finality_checkpoints.
+type FinalityCheckpoints struct {
+	Finalized Checkpoint `json:"finalized"`
+}
+
+type Checkpoint struct {
+	Epoch Quantity `json:"epoch"`
+	Root  string   `json:"root"`
+}
+
+// SlashedIndices are the validators an attester slashing punishes: the ones
+// that signed both conflicting attestations.
+func (s *AttesterSlashing) SlashedIndices() []Quantity {
+	signed := make(map[Quantity]struct{}, len(s.Attestation1.AttestingIndices))
+	for _, i := range s.Attestation1.AttestingIndices {
+		signed[i] = struct{}{}
+	}
+
+	out := make([]Quantity, 0, len(s.Attestation2.AttestingIndices))
+	for _, i := range s.Attestation2.AttestingIndices {
+		if _, ok := signed[i]; ok {
+			out = append(out, i)
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,165 @@
+package beacon
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/lidofinance/onchain-mon/internal/connectors/metrics"
+	"github.com/lidofinance/onchain-mon/internal/utils/text"
+)
+
+// Event topics the feeder wakes up on.
+const (
+	TopicHead                = `head`
+	TopicFinalizedCheckpoint = `finalized_checkpoint`
+)
+
+// EventReadTimeout drops a stream that went quiet: a head comes every slot.
+const EventReadTimeout = 45 * time.Second
+
+// Reconnect backoff of the event stream, doubled after every failure.
+const EventReconnectDelay = time.Second
+const EventMaxReconnectDelay = 30 * time.Second
+
+// MaxEventSize bounds one line of the stream. head and finalized_checkpoint
+// events are a few hundred bytes.
+const MaxEventSize = 64 << 10
+
+// EventSubscriber follows /eth/v1/events for heads and finalized checkpoints.
+// Like HeadSubscriber for newHeads, it only tells when to look: blocks and
+// checkpoints are still fetched from the API, so a dropped stream costs
+// latency, never slots.
+type EventSubscriber struct {
+	url        string
+	httpClient *http.Client
+	log        *slog.Logger
+	metrics    *metrics.Store
+	minDelay   time.Duration
+}
+
+// NewEventSubscriber needs an httpClient without a Timeout: the stream is one
+// request that never ends.
+func NewEventSubscriber(url string, httpClient *http.Client, log *slog.Logger, metricsStore *metrics.Store) *EventSubscriber {
+	return &EventSubscriber{
+		url:        strings.TrimRight(url, "/"),
+		httpClient: httpClient,
+		log:        log,
+		metrics:    metricsStore,
+		minDelay:   EventReconnectDelay,
+	}
+}
+
+// Wakeups ticks on every head and finalized checkpoint until ctx is done,
+// reconnecting with backoff. Ticks the reader has not picked up yet are
+// merged into one. The channel is closed when ctx is done.
+func (s *EventSubscriber) Wakeups(ctx context.Context) <-chan struct{} {
+	out := make(chan struct{}, 1)
+
+	go func() {
+		defer close(out)
+
+		delay := s.minDelay
+		for {
+			subscribed, err := s.follow(ctx, out)
+			s.metrics.BeaconEventsUp.Set(0)
+
+			if ctx.Err() != nil {
+				return
+			}
+
+			if subscribed {
+				delay = s.minDelay
+			}
+
+			s.metrics.BeaconEventsReconnects.Inc()
+			s.log.Warn("Beacon event stream dropped, polling until it is back",
+				slog.String("error", text.LeaveOnlyDomainInURLs(err.Error())),
+				slog.Duration("retryIn", delay),
+			)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(delay):
+			}
+
+			delay = min(delay*2, EventMaxReconnectDelay)
+		}
+	}()
+
+	return out
+}
+
+// follow reads one stream until it breaks. It reports whether the node
+// accepted it, so a flapping connection still backs off.
+func (s *EventSubscriber) follow(ctx context.Context, out chan struct{}) (bool, error) {
+	streamCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	url := s.url + "/eth/v1/events?topics=" + TopicHead + "&topics=" + TopicFinalizedCheckpoint
+
+	req, err := http.NewRequestWithContext(streamCtx, http.MethodGet, url, nil)
+	if err != nil {
+		return false, fmt.Errorf("could not create request: %w", err)
+	}
+
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("connect: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("connect: status %d", resp.StatusCode)
+	}
+
+	s.metrics.BeaconEventsUp.Set(1)
+	s.log.Info("Beacon event stream is live")
+
+	// Reading the body does not watch a deadline; cancelling the request
+	// unblocks it.
+	quiet := time.AfterFunc(EventReadTimeout, cancel)
+	defer quiet.Stop()
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 4096), MaxEventSize)
+
+	for scanner.Scan() {
+		quiet.Reset(EventReadTimeout)
+
+		event, ok := strings.CutPrefix(scanner.Text(), "event:")
+		if !ok {
+			continue
+		}
+
+		switch strings.TrimSpace(event) {
+		case TopicHead, TopicFinalizedCheckpoint:
+			wake(out)
+		}
+	}
+
+	if err := scanner.Err(); err != nil && !errors.Is(err, context.Canceled) {
+		return true, fmt.Errorf("read: %w", err)
+	}
+
+	if ctx.Err() == nil && streamCtx.Err() != nil {
+		return true, errors.New("no events for too long")
+	}
+
+	return true, errors.New("stream closed")
+}
+
+func wake(out chan struct{}) {
+	select {
+	case out <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,65 @@
+package beacon
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// sseStub sends one event of every given topic and hangs up, the way a node
+// does when it restarts.
+func sseStub(t *testing.T, topics ...string) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	conns := &atomic.Int32{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conns.Add(1)
+
+		if r.Header.Get("Accept") != "text/event-stream" || len(r.URL.Query()["topics"]) != 2 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, topic := range topics {
+			_, _ = fmt.Fprintf(w, "event: %s\ndata: {}\n\n", topic)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, conns
+}
+
+func Test_events_wake_up_and_the_stream_comes_back(t *testing.T) {
+	srv, conns := sseStub(t, TopicHead)
+
+	s := NewEventSubscriber(srv.URL, srv.Client(), slog.New(slog.NewTextHandler(io.Discard, nil)), newTestMetrics(t))
+	s.minDelay = 10 * time.Millisecond
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	wakeups := s.Wakeups(ctx)
+
+	for range 2 {
+		select {
+		case <-wakeups:
+		case <-ctx.Done():
+			t.Fatal("no wake-up")
+		}
+	}
+
+	if conns.Load() < 2 {
+		t.Errorf("got %d connections, want the stream to reconnect", conns.Load())
+	}
+
+	cancel()
+	for range wakeups {
+	}
+}
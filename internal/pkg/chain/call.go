@@ -0,0 +1,172 @@
+package chain
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/lidofinance/onchain-mon/internal/pkg/chain/entity"
+	"github.com/lidofinance/onchain-mon/internal/utils/text"
+)
+
+// RevertedCode is what geth-compatible nodes answer a reverted eth_call with.
+const RevertedCode = 3
+
+// CallError is an error the node answered a request with, as opposed to one
+// getting there.
+type CallError struct {
+	entity.RpcError
+}
+
+func (e *CallError) Error() string {
+	return fmt.Sprintf("RPC code(%d) error: %s", e.Code, e.Message)
+}
+
+// Reverted reports whether the call itself failed. That is an answer, the
+// same from every endpoint, not a reason to retry.
+func (e *CallError) Reverted() bool {
+	return e.Code == RevertedCode || strings.Contains(e.Message, "execution reverted")
+}
+
+func isReverted(err error) bool {
+	var callErr *CallError
+	return errors.As(err, &callErr) && callErr.Reverted()
+}
+
+// Call runs any read-only method through the pool and returns its result as
+// the node sent it. A reverted call comes back as a *CallError.
+func (c *chain) Call(ctx context.Context, method string, params []any) (json.RawMessage, error) {
+	resp, err := doRpcRequest[json.RawMessage](ctx, method, params, c.httpClient, c.metrics, c.pool, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return *resp.Result, nil
+}
+
+// EthCall is one eth_call of a CallBatch: calldata for a contract.
+type EthCall struct {
+	To   string `json:"to"`
+	Data string `json:"data"`
+}
+
+// EthCallResult is what one call of a batch returned, or Err when it reverted.
+type EthCallResult struct {
+	Output string
+	Err    *CallError
+}
+
+// CallBatch runs calls as one JSON-RPC batch against the block blockHash, so
+// every value comes from the same state whichever endpoint answers. A call
+// that reverts is an answer like any other; any other error fails the whole
+// batch over to the next endpoint.
+func (c *chain) CallBatch(ctx context.Context, calls []EthCall, blockHash string) ([]EthCallResult, error) {
+	if len(calls) == 0 {
+		return nil, nil
+	}
+
+	// EIP-1898: by hash, a block reorged out fails instead of answering for
+	// whatever replaced it.
+	block := map[string]any{"blockHash": blockHash}
+
+	params := make([][]any, 0, len(calls))
+	for _, call := range calls {
+		params = append(params, []any{call, block})
+	}
+
+	payload, err := batchPayload("eth_call", params)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := do(ctx, c.pool,
+		func(jsonRpcUrl string) ([]EthCallResult, error) {
+			body, err := post(ctx, c.httpClient, jsonRpcUrl, payload)
+			if err != nil {
+				return nil, err
+			}
+
+			answers, err := batchAnswers(body, len(calls))
+			if err != nil {
+				return nil, err
+			}
+
+			results := make([]EthCallResult, len(calls))
+			for i, r := range answers {
+				switch {
+				case r.Error != nil:
+					callErr := &CallError{RpcError: *r.Error}
+					if !callErr.Reverted() {
+						return nil, callErr
+					}
+
+					results[i].Err = callErr
+				case r.Result != nil:
+					results[i].Output = *r.Result
+				default:
+					return nil, fmt.Errorf("eth_call %d: %w", i, ErrEmptyResponse)
+				}
+			}
+
+			return results, nil
+		},
+	)
+
+	if err != nil {
+		return nil, errors.New(text.LeaveOnlyDomainInURLs(err.Error()))
+	}
+
+	return out, nil
+}
+
+// batchPayload is a JSON-RPC batch of method, one request per params, each
+// with its index as the ID for batchAnswers to put the answers back in order.
+func batchPayload(method string, params [][]any) ([]byte, error) {
+	requests := make([]entity.RpcRequest, 0, len(params))
+	for i, p := range params {
+		requests = append(requests, entity.RpcRequest{
+			JsonRpc: JsonRpcVersion,
+			Method:  method,
+			Params:  p,
+			ID:      strconv.Itoa(i),
+		})
+	}
+
+	payload, err := json.Marshal(requests)
+	if err != nil {
+		return nil, fmt.Errorf("marshal batch: %w", err)
+	}
+
+	return payload, nil
+}
+
+// batchAnswers reads the answers to a batchPayload of n requests in request
+// order. Batch answers may come in any order; one missing fails the batch.
+func batchAnswers(body []byte, n int) ([]entity.RpcResponse[string], error) {
+	var rawResponses []entity.RpcResponse[string]
+	if err := json.Unmarshal(body, &rawResponses); err != nil {
+		return nil, fmt.Errorf("unmarshal response: %w", err)
+	}
+
+	answers := make([]entity.RpcResponse[string], n)
+	answered := make([]bool, n)
+	for _, r := range rawResponses {
+		i, convErr := strconv.Atoi(r.ID)
+		if convErr != nil || i < 0 || i >= n {
+			return nil, fmt.Errorf("answer to unknown request %q", r.ID)
+		}
+
+		answers[i] = r
+		answered[i] = true
+	}
+
+	if missing := slices.Index(answered, false); missing != -1 {
+		return nil, fmt.Errorf("batch of %d requests left request %d unanswered", n, missing)
+	}
+
+	return answers, nil
+}
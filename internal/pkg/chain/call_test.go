@@ -0,0 +1,82 @@
+package chain
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func Test_reverted_call_is_an_answer_not_a_failure(t *testing.T) {
+	first, firstCalls := rpcStub(t, `{"jsonrpc":"2.0","id":"1","error":{"code":3,"message":"execution reverted","data":"0x08c379a0"}}`)
+	second, secondCalls := rpcStub(t, `{"jsonrpc":"2.0","id":"1","result":"0x01"}`)
+
+	c := NewChain([]string{first.URL, second.URL}, &http.Client{}, newTestMetrics(t))
+
+	_, err := c.Call(context.Background(), "eth_call", []any{map[string]string{"to": "0xc0"}, "latest"})
+
+	var callErr *CallError
+	if !errors.As(err, &callErr) || !callErr.Reverted() {
+		t.Fatalf("want a reverted *CallError, got %v", err)
+	}
+	if string(callErr.Data) != `"0x08c379a0"` {
+		t.Errorf("the revert data got lost: %s", callErr.Data)
+	}
+	if firstCalls.Load() != 1 || secondCalls.Load() != 0 {
+		t.Errorf("a revert must not be retried: asked %d and %d times", firstCalls.Load(), secondCalls.Load())
+	}
+}
+
+func Test_call_returns_the_raw_result(t *testing.T) {
+	srv, _ := rpcStub(t, `{"jsonrpc":"2.0","id":"1","result":"0x0de0b6b3a7640000"}`)
+
+	c := NewChain([]string{srv.URL}, &http.Client{}, newTestMetrics(t))
+
+	got, err := c.Call(context.Background(), "eth_getBalance", []any{"0xc0", "latest"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != `"0x0de0b6b3a7640000"` {
+		t.Errorf("got %s", got)
+	}
+}
+
+func Test_call_batch_matches_answers_and_keeps_reverts(t *testing.T) {
+	// Out of order, with the second call reverted.
+	srv, calls := rpcStub(t, `[
+		{"jsonrpc":"2.0","id":"1","error":{"code":3,"message":"execution reverted"}},
+		{"jsonrpc":"2.0","id":"0","result":"0x2a"}
+	]`)
+
+	c := NewChain([]string{srv.URL}, &http.Client{}, newTestMetrics(t))
+
+	got, err := c.CallBatch(context.Background(), []EthCall{{To: "0xc0", Data: "0x18160ddd"}, {To: "0xc1", Data: "0x18160ddd"}}, "0xb10c")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got[0].Output != "0x2a" || got[0].Err != nil {
+		t.Errorf("call 0: got %+v", got[0])
+	}
+	if got[1].Err == nil || !got[1].Err.Reverted() {
+		t.Errorf("call 1: want the revert, got %+v", got[1])
+	}
+	if calls.Load() != 1 {
+		t.Errorf("a revert must not be retried: asked %d times", calls.Load())
+	}
+}
+
+func Test_call_batch_fails_over_on_a_short_answer(t *testing.T) {
+	short, _ := rpcStub(t, `[{"jsonrpc":"2.0","id":"0","result":"0x2a"}]`)
+	full, fullCalls := rpcStub(t, `[{"jsonrpc":"2.0","id":"0","result":"0x2a"},{"jsonrpc":"2.0","id":"1","result":"0x01"}]`)
+
+	c := NewChain([]string{short.URL, full.URL}, &http.Client{}, newTestMetrics(t))
+
+	got, err := c.CallBatch(context.Background(), []EthCall{{To: "0xc0"}, {To: "0xc1"}}, "0xb10c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fullCalls.Load() != 1 || got[1].Output != "0x01" {
+		t.Errorf("want the full answer, got %+v", got)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/avast/retry-go/v4"
@@ -20,45 +21,104 @@ import (
 )
 
 type chain struct {
-	jsonRpcUrl string
+	pool       *pool
 	httpClient *http.Client
 	metrics    *metrics.Store
 }
 
 var ErrEmptyResponse = errors.New("empty response")
+var ErrTransactionsMismatch = errors.New("transactions do not match the block")
 
 const MaxAttempts = 6
 const RetryDelay = 75 * time.Millisecond
 const MaxDelay = 5 * time.Second
 const JsonRpcVersion = "2.0"
 
-func NewChain(jsonRpcUrl string, httpClient *http.Client, metricsStore *metrics.Store) *chain {
+// Block tags understood by eth_getBlockByNumber.
+const (
+	TagLatest    = `latest`
+	TagSafe      = `safe`
+	TagFinalized = `finalized`
+)
+
+// NewChain takes the RPC endpoints in order of preference. Requests go to the
+// healthiest one and fail over to the rest within the same call.
+func NewChain(jsonRpcUrls []string, httpClient *http.Client, metricsStore *metrics.Store) *chain {
 	return &chain{
-		jsonRpcUrl: jsonRpcUrl,
+		pool:       newPool(jsonRpcUrls, metricsStore),
 		httpClient: httpClient,
 		metrics:    metricsStore,
 	}
 }
 
 func (c *chain) GetLatestBlock(ctx context.Context) (*entity.RpcResponse[entity.EthBlock], error) {
-	return doRpcRequest[entity.EthBlock](ctx, "eth_getBlockByNumber", []any{"latest", false}, c.httpClient, c.metrics, c.jsonRpcUrl)
+	return c.GetBlockByTag(ctx, TagLatest)
+}
+
+// GetBlockByTag asks for the block behind a block tag: latest, safe or finalized.
+func (c *chain) GetBlockByTag(ctx context.Context, tag string) (*entity.RpcResponse[entity.EthBlock], error) {
+	return doRpcRequest[entity.EthBlock](ctx, "eth_getBlockByNumber", []any{tag, false}, c.httpClient, c.metrics, c.pool, nil)
 }
 
 func (c *chain) GetBlockReceipts(ctx context.Context, blockHash string) (*entity.RpcResponse[[]entity.BlockReceipt], error) {
-	return doRpcRequest[[]entity.BlockReceipt](ctx, "eth_getBlockReceipts", []any{blockHash}, c.httpClient, c.metrics, c.jsonRpcUrl)
+	return doRpcRequest[[]entity.BlockReceipt](ctx, "eth_getBlockReceipts", []any{blockHash}, c.httpClient, c.metrics, c.pool, nil)
+}
+
+// GetVerifiedReceipts fetches the receipts of block and checks them with
+// VerifyReceipts. A provider answering with a truncated or foreign list counts
+// as failed, so the request fails over to the next endpoint.
+func (c *chain) GetVerifiedReceipts(ctx context.Context, block *entity.EthBlock) (*entity.RpcResponse[[]entity.BlockReceipt], error) {
+	return doRpcRequest[[]entity.BlockReceipt](ctx, "eth_getBlockReceipts", []any{block.Hash}, c.httpClient, c.metrics, c.pool,
+		func(receipts *[]entity.BlockReceipt) error {
+			err := VerifyReceipts(block, *receipts)
+
+			var mismatch *ReceiptsMismatchError
+			if errors.As(err, &mismatch) {
+				c.metrics.ReceiptsMismatches.With(prometheus.Labels{metrics.Reason: mismatch.Reason}).Inc()
+			}
+
+			return err
+		},
+	)
+}
+
+// GetBlockTransactions fetches the full transaction objects of block. The
+// answer must list the same transactions in the same order as block, or the
+// request fails over to the next endpoint.
+func (c *chain) GetBlockTransactions(ctx context.Context, block *entity.EthBlock) (*entity.RpcResponse[entity.EthFullBlock], error) {
+	return doRpcRequest[entity.EthFullBlock](ctx, "eth_getBlockByHash", []any{block.Hash, true}, c.httpClient, c.metrics, c.pool,
+		func(full *entity.EthFullBlock) error {
+			if !strings.EqualFold(full.Hash, block.Hash) || len(full.Transactions) != len(block.Transactions) {
+				return fmt.Errorf("%w: got %d transactions of block %s, want %d of block %s",
+					ErrTransactionsMismatch, len(full.Transactions), full.Hash, len(block.Transactions), block.Hash)
+			}
+
+			for i := range full.Transactions {
+				if !strings.EqualFold(full.Transactions[i].Hash, block.Transactions[i]) {
+					return fmt.Errorf("%w: transaction %d is %s, want %s",
+						ErrTransactionsMismatch, i, full.Transactions[i].Hash, block.Transactions[i])
+				}
+			}
+
+			return nil
+		},
+	)
 }
 
 func (c *chain) FetchBlockByNumber(ctx context.Context, blockNumber int64) (*entity.RpcResponse[entity.EthBlock], error) {
 	hexValue := fmt.Sprintf("0x%x", blockNumber)
-	return doRpcRequest[entity.EthBlock](ctx, "eth_getBlockByNumber", []any{hexValue, false}, c.httpClient, c.metrics, c.jsonRpcUrl)
+	return doRpcRequest[entity.EthBlock](ctx, "eth_getBlockByNumber", []any{hexValue, false}, c.httpClient, c.metrics, c.pool, nil)
 }
 
+// doRpcRequest runs method against the pool. A non-nil verify gets every
+// result; an error from it fails the attempt like a transport error would.
 func doRpcRequest[T any](
 	ctx context.Context, method string, params []any,
-	httpClient *http.Client, m *metrics.Store, jsonRpcUrl string,
+	httpClient *http.Client, m *metrics.Store, rpcPool *pool,
+	verify func(*T) error,
 ) (*entity.RpcResponse[T], error) {
-	out, err := retry.DoWithData(
-		func() (*entity.RpcResponse[T], error) {
+	out, err := do(ctx, rpcPool,
+		func(jsonRpcUrl string) (*entity.RpcResponse[T], error) {
 			rpcRequest := entity.RpcRequest{
 				JsonRpc: JsonRpcVersion,
 				Method:  method,
@@ -71,27 +131,15 @@ func doRpcRequest[T any](
 				return nil, marshaErr
 			}
 
-			req, err := http.NewRequestWithContext(ctx, http.MethodPost, jsonRpcUrl, bytes.NewBuffer(payload))
-			if err != nil {
-				return nil, fmt.Errorf("could not create request: %w", err)
-			}
-
-			req.Header.Set("Content-Type", "application/json")
-
 			start := time.Now()
-			resp, err := httpClient.Do(req)
-			if err != nil {
-				return nil, fmt.Errorf("could not send request: %w", err)
-			}
 			defer func() {
-				resp.Body.Close()
 				duration := time.Since(start).Seconds()
 				m.SummaryHandlers.With(prometheus.Labels{metrics.Channel: method}).Observe(duration)
 			}()
 
-			body, err := io.ReadAll(resp.Body)
+			body, err := post(ctx, httpClient, jsonRpcUrl, payload)
 			if err != nil {
-				return nil, fmt.Errorf("could not read response body: %w", err)
+				return nil, err
 			}
 
 			var p entity.RpcResponse[T]
@@ -100,24 +148,24 @@ func doRpcRequest[T any](
 			}
 
 			if p.Error != nil {
-				return nil, fmt.Errorf("RPC code(%d) error: %s", p.Error.Code, p.Error.Message)
+				return nil, &CallError{RpcError: *p.Error}
 			}
 
 			if p.Result == nil {
 				return nil, fmt.Errorf("%s rpcResponse.Result is nil. payload %s: %w", method, string(payload), ErrEmptyResponse)
 			}
 
+			if verify != nil {
+				if err := verify(p.Result); err != nil {
+					return nil, err
+				}
+			}
+
 			return &p, nil
 		},
-		retry.Attempts(MaxAttempts),
-		retry.Delay(RetryDelay),
-		retry.MaxDelay(MaxDelay),
-		retry.DelayType(retry.CombineDelay(
-			retry.BackOffDelay,
-			retry.RandomDelay,
-		)),
 		retry.RetryIf(func(err error) bool {
-			return !errors.Is(err, ErrEmptyResponse)
+			// Every endpoint would revert the same way.
+			return !errors.Is(err, ErrEmptyResponse) && !isReverted(err)
 		}),
 	)
 	if err != nil {
@@ -125,49 +173,65 @@ func doRpcRequest[T any](
 			return nil, err
 		}
 
+		var callErr *CallError
+		if isReverted(err) && errors.As(err, &callErr) {
+			return nil, callErr
+		}
+
 		return nil, errors.New(text.LeaveOnlyDomainInURLs(err.Error()))
 	}
 
 	return out, nil
 }
 
+// post sends one JSON-RPC payload (single or batch) and returns the raw body.
+func post(ctx context.Context, httpClient *http.Client, jsonRpcUrl string, payload []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, jsonRpcUrl, bytes.NewBuffer(payload))
+	if err != nil {
+		return nil, fmt.Errorf("could not create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("could not send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("could not read response body: %w", err)
+	}
+
+	return body, nil
+}
+
 func (c *chain) FetchReceipts(ctx context.Context, blockHashes []string) (*entity.RpcResponse[[]entity.BlockReceipt], error) {
 	if len(blockHashes) == 0 {
 		return &entity.RpcResponse[[]entity.BlockReceipt]{Result: &[]entity.BlockReceipt{}}, nil
 	}
 
-	out, err := retry.DoWithData(
-		func() (*entity.RpcResponse[[]entity.BlockReceipt], error) {
-			requests := make([]entity.RpcRequest, 0, len(blockHashes))
-			for _, hash := range blockHashes {
-				requests = append(requests, entity.RpcRequest{
-					JsonRpc: JsonRpcVersion,
-					Method:  "eth_getBlockReceipts",
-					Params:  []any{hash},
-					ID:      hash,
-				})
-			}
-
-			payload, err := json.Marshal(requests)
-			if err != nil {
-				return nil, fmt.Errorf("marshal batch: %w", err)
-			}
-
-			req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.jsonRpcUrl, bytes.NewBuffer(payload))
-			if err != nil {
-				return nil, fmt.Errorf("create request: %w", err)
-			}
-			req.Header.Set("Content-Type", "application/json")
+	requests := make([]entity.RpcRequest, 0, len(blockHashes))
+	for _, hash := range blockHashes {
+		requests = append(requests, entity.RpcRequest{
+			JsonRpc: JsonRpcVersion,
+			Method:  "eth_getBlockReceipts",
+			Params:  []any{hash},
+			ID:      hash,
+		})
+	}
 
-			resp, err := c.httpClient.Do(req)
-			if err != nil {
-				return nil, fmt.Errorf("send request: %w", err)
-			}
-			defer resp.Body.Close()
+	payload, err := json.Marshal(requests)
+	if err != nil {
+		return nil, fmt.Errorf("marshal batch: %w", err)
+	}
 
-			body, err := io.ReadAll(resp.Body)
+	out, err := do(ctx, c.pool,
+		func(jsonRpcUrl string) (*entity.RpcResponse[[]entity.BlockReceipt], error) {
+			body, err := post(ctx, c.httpClient, jsonRpcUrl, payload)
 			if err != nil {
-				return nil, fmt.Errorf("read body: %w", err)
+				return nil, err
 			}
 
 			var rawResponses []entity.RpcResponse[[]entity.BlockReceipt]
@@ -191,14 +255,6 @@ func (c *chain) FetchReceipts(ctx context.Context, blockHashes []string) (*entit
 				Result:  &combined,
 			}, nil
 		},
-		retry.Attempts(MaxAttempts),
-		retry.Delay(RetryDelay),
-		retry.MaxDelay(MaxDelay),
-		retry.DelayType(retry.CombineDelay(
-			retry.BackOffDelay,
-			retry.RandomDelay,
-		)),
-		retry.Context(ctx),
 	)
 
 	if err != nil {
@@ -213,39 +269,27 @@ func (c *chain) FetchBlocksInRange(ctx context.Context, from, to int64) (*entity
 		return &entity.RpcResponse[[]entity.EthBlock]{Result: &[]entity.EthBlock{}}, nil
 	}
 
-	out, err := retry.DoWithData(
-		func() (*entity.RpcResponse[[]entity.EthBlock], error) {
-			requests := make([]entity.RpcRequest, 0, to-from+1)
-			for i := from; i <= to; i++ {
-				hexNum := fmt.Sprintf("0x%x", i)
-				requests = append(requests, entity.RpcRequest{
-					JsonRpc: JsonRpcVersion,
-					Method:  "eth_getBlockByNumber",
-					Params:  []any{hexNum, false},
-					ID:      hexNum,
-				})
-			}
-
-			payload, err := json.Marshal(requests)
-			if err != nil {
-				return nil, fmt.Errorf("marshal batch: %w", err)
-			}
-
-			req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.jsonRpcUrl, bytes.NewBuffer(payload))
-			if err != nil {
-				return nil, fmt.Errorf("create request: %w", err)
-			}
-			req.Header.Set("Content-Type", "application/json")
+	requests := make([]entity.RpcRequest, 0, to-from+1)
+	for i := from; i <= to; i++ {
+		hexNum := fmt.Sprintf("0x%x", i)
+		requests = append(requests, entity.RpcRequest{
+			JsonRpc: JsonRpcVersion,
+			Method:  "eth_getBlockByNumber",
+			Params:  []any{hexNum, false},
+			ID:      hexNum,
+		})
+	}
 
-			resp, err := c.httpClient.Do(req)
-			if err != nil {
-				return nil, fmt.Errorf("send request: %w", err)
-			}
-			defer resp.Body.Close()
+	payload, err := json.Marshal(requests)
+	if err != nil {
+		return nil, fmt.Errorf("marshal batch: %w", err)
+	}
 
-			body, err := io.ReadAll(resp.Body)
+	out, err := do(ctx, c.pool,
+		func(jsonRpcUrl string) (*entity.RpcResponse[[]entity.EthBlock], error) {
+			body, err := post(ctx, c.httpClient, jsonRpcUrl, payload)
 			if err != nil {
-				return nil, fmt.Errorf("read body: %w", err)
+				return nil, err
 			}
 
 			var rawResponses []entity.RpcResponse[entity.EthBlock]
@@ -269,14 +313,6 @@ func (c *chain) FetchBlocksInRange(ctx context.Context, from, to int64) (*entity
 				Result:  &blocks,
 			}, nil
 		},
-		retry.Attempts(MaxAttempts),
-		retry.Delay(RetryDelay),
-		retry.MaxDelay(MaxDelay),
-		retry.DelayType(retry.CombineDelay(
-			retry.BackOffDelay,
-			retry.RandomDelay,
-		)),
-		retry.Context(ctx),
 	)
 
 	if err != nil {
@@ -29,9 +29,9 @@ func Test_chain_GetLatestBlock(t *testing.T) {
 	metricsStore := metrics.New(promRegistry, cfg.AppConfig.MetricsPrefix, cfg.AppConfig.Name, cfg.AppConfig.Env)
 
 	type fields struct {
-		jsonRpcUrl string
-		httpClient *http.Client
-		metrics    *metrics.Store
+		jsonRpcUrls []string
+		httpClient  *http.Client
+		metrics     *metrics.Store
 	}
 	type args struct {
 		ctx context.Context
@@ -45,9 +45,9 @@ func Test_chain_GetLatestBlock(t *testing.T) {
 		{
 			name: "success",
 			fields: fields{
-				jsonRpcUrl: cfg.AppConfig.JsonRpcURL,
-				httpClient: &http.Client{},
-				metrics:    metricsStore,
+				jsonRpcUrls: cfg.AppConfig.JsonRpcURLs,
+				httpClient:  &http.Client{},
+				metrics:     metricsStore,
 			},
 			args: args{
 				ctx: context.Background(),
@@ -57,11 +57,7 @@ func Test_chain_GetLatestBlock(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			c := &chain{
-				jsonRpcUrl: tt.fields.jsonRpcUrl,
-				httpClient: tt.fields.httpClient,
-				metrics:    tt.fields.metrics,
-			}
+			c := NewChain(tt.fields.jsonRpcUrls, tt.fields.httpClient, tt.fields.metrics)
 			got, err := c.GetLatestBlock(tt.args.ctx)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("GetLatestBlock() error = %v, wantErr %v", err, tt.wantErr)
@@ -86,9 +82,9 @@ func Test_chain_GetLatestLogs(t *testing.T) {
 	metricsStore := metrics.New(promRegistry, cfg.AppConfig.MetricsPrefix, cfg.AppConfig.Name, cfg.AppConfig.Env)
 
 	type fields struct {
-		jsonRpcUrl string
-		httpClient *http.Client
-		metrics    *metrics.Store
+		jsonRpcUrls []string
+		httpClient  *http.Client
+		metrics     *metrics.Store
 	}
 	type args struct {
 		ctx       context.Context
@@ -103,9 +99,9 @@ func Test_chain_GetLatestLogs(t *testing.T) {
 		{
 			name: "success",
 			fields: fields{
-				jsonRpcUrl: cfg.AppConfig.JsonRpcURL,
-				httpClient: &http.Client{},
-				metrics:    metricsStore,
+				jsonRpcUrls: cfg.AppConfig.JsonRpcURLs,
+				httpClient:  &http.Client{},
+				metrics:     metricsStore,
 			},
 			args: args{
 				ctx:       context.Background(),
@@ -116,11 +112,7 @@ func Test_chain_GetLatestLogs(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			c := &chain{
-				jsonRpcUrl: tt.fields.jsonRpcUrl,
-				httpClient: tt.fields.httpClient,
-				metrics:    tt.fields.metrics,
-			}
+			c := NewChain(tt.fields.jsonRpcUrls, tt.fields.httpClient, tt.fields.metrics)
 			got, err := c.GetBlockReceipts(tt.args.ctx, tt.args.blockHash)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("GetBlockReceipts() error = %v, wantErr %v", err, tt.wantErr)
@@ -152,9 +144,9 @@ func Test_chain_FetchBlockByNumber(t *testing.T) {
 	metricsStore := metrics.New(promRegistry, cfg.AppConfig.MetricsPrefix, cfg.AppConfig.Name, cfg.AppConfig.Env)
 
 	type fields struct {
-		jsonRpcUrl string
-		httpClient *http.Client
-		metrics    *metrics.Store
+		jsonRpcUrls []string
+		httpClient  *http.Client
+		metrics     *metrics.Store
 	}
 	type args struct {
 		ctx         context.Context
@@ -170,9 +162,9 @@ func Test_chain_FetchBlockByNumber(t *testing.T) {
 		{
 			name: "Nil response",
 			fields: fields{
-				jsonRpcUrl: cfg.AppConfig.JsonRpcURL,
-				httpClient: &http.Client{},
-				metrics:    metricsStore,
+				jsonRpcUrls: cfg.AppConfig.JsonRpcURLs,
+				httpClient:  &http.Client{},
+				metrics:     metricsStore,
 			},
 			args: args{
 				ctx:         context.Background(),
@@ -184,9 +176,9 @@ func Test_chain_FetchBlockByNumber(t *testing.T) {
 		{
 			name: "success",
 			fields: fields{
-				jsonRpcUrl: cfg.AppConfig.JsonRpcURL,
-				httpClient: &http.Client{},
-				metrics:    metricsStore,
+				jsonRpcUrls: cfg.AppConfig.JsonRpcURLs,
+				httpClient:  &http.Client{},
+				metrics:     metricsStore,
 			},
 			args: args{
 				ctx:         context.Background(),
@@ -198,11 +190,7 @@ func Test_chain_FetchBlockByNumber(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			c := &chain{
-				jsonRpcUrl: tt.fields.jsonRpcUrl,
-				httpClient: tt.fields.httpClient,
-				metrics:    tt.fields.metrics,
-			}
+			c := NewChain(tt.fields.jsonRpcUrls, tt.fields.httpClient, tt.fields.metrics)
 			got, err := c.FetchBlockByNumber(tt.args.ctx, tt.args.blockNumber)
 			if err != nil && tt.wantErr != nil {
 				if errors.Is(err, tt.wantErr) {
@@ -230,9 +218,9 @@ func Test_chain_FetchBlocksInRange(t *testing.T) {
 	metricsStore := metrics.New(promRegistry, cfg.AppConfig.MetricsPrefix, cfg.AppConfig.Name, cfg.AppConfig.Env)
 
 	type fields struct {
-		jsonRpcUrl string
-		httpClient *http.Client
-		metrics    *metrics.Store
+		jsonRpcUrls []string
+		httpClient  *http.Client
+		metrics     *metrics.Store
 	}
 	type args struct {
 		ctx  context.Context
@@ -249,9 +237,9 @@ func Test_chain_FetchBlocksInRange(t *testing.T) {
 		{
 			name: "success",
 			fields: fields{
-				jsonRpcUrl: cfg.AppConfig.JsonRpcURL,
-				httpClient: &http.Client{},
-				metrics:    metricsStore,
+				jsonRpcUrls: cfg.AppConfig.JsonRpcURLs,
+				httpClient:  &http.Client{},
+				metrics:     metricsStore,
 			},
 			args: args{
 				ctx:  context.Background(),
@@ -264,11 +252,7 @@ func Test_chain_FetchBlocksInRange(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			c := &chain{
-				jsonRpcUrl: tt.fields.jsonRpcUrl,
-				httpClient: tt.fields.httpClient,
-				metrics:    tt.fields.metrics,
-			}
+			c := NewChain(tt.fields.jsonRpcUrls, tt.fields.httpClient, tt.fields.metrics)
 			got, err := c.FetchBlocksInRange(tt.args.ctx, tt.args.from, tt.args.to)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("FetchBlocksInRange() error = %v, wantErr %v", err, tt.wantErr)
@@ -291,9 +275,9 @@ func Test_chain_FetchReceipts(t *testing.T) {
 	metricsStore := metrics.New(promRegistry, cfg.AppConfig.MetricsPrefix, cfg.AppConfig.Name, cfg.AppConfig.Env)
 
 	type fields struct {
-		jsonRpcUrl string
-		httpClient *http.Client
-		metrics    *metrics.Store
+		jsonRpcUrls []string
+		httpClient  *http.Client
+		metrics     *metrics.Store
 	}
 	type args struct {
 		ctx         context.Context
@@ -309,9 +293,9 @@ func Test_chain_FetchReceipts(t *testing.T) {
 		{
 			name: "success",
 			fields: fields{
-				jsonRpcUrl: cfg.AppConfig.JsonRpcURL,
-				httpClient: &http.Client{},
-				metrics:    metricsStore,
+				jsonRpcUrls: cfg.AppConfig.JsonRpcURLs,
+				httpClient:  &http.Client{},
+				metrics:     metricsStore,
 			},
 			args: args{
 				ctx: context.Background(),
@@ -329,11 +313,7 @@ func Test_chain_FetchReceipts(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			c := &chain{
-				jsonRpcUrl: tt.fields.jsonRpcUrl,
-				httpClient: tt.fields.httpClient,
-				metrics:    tt.fields.metrics,
-			}
+			c := NewChain(tt.fields.jsonRpcUrls, tt.fields.httpClient, tt.fields.metrics)
 			got, err := c.FetchReceipts(tt.args.ctx, tt.args.blockHashes)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("FetchReceipts() error = %v, wantErr %v", err, tt.wantErr)
@@ -0,0 +1,85 @@
+package chain
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/lidofinance/onchain-mon/internal/pkg/chain/entity"
+	"github.com/lidofinance/onchain-mon/internal/utils/text"
+)
+
+// BlockVote is what one endpoint says about a height. Err is set when it gave
+// no answer, ErrEmptyResponse included: a lagging provider abstains, it does
+// not disagree.
+type BlockVote struct {
+	Endpoint string
+	Hash     string
+	TxCount  int
+	Err      error
+}
+
+// BlockVotes asks every endpoint for the block at number at once, one attempt
+// each. The votes come back in the configured order of the endpoints.
+func (c *chain) BlockVotes(ctx context.Context, number int64) []BlockVote {
+	payload, err := json.Marshal(entity.RpcRequest{
+		JsonRpc: JsonRpcVersion,
+		Method:  "eth_getBlockByNumber",
+		Params:  []any{fmt.Sprintf("0x%x", number), false},
+		ID:      uuid.New().String(),
+	})
+
+	votes := make([]BlockVote, len(c.pool.endpoints))
+	if err != nil {
+		for i, ep := range c.pool.endpoints {
+			votes[i] = BlockVote{Endpoint: ep.label, Err: fmt.Errorf("marshal request: %w", err)}
+		}
+
+		return votes
+	}
+
+	var wg sync.WaitGroup
+	for i, ep := range c.pool.endpoints {
+		wg.Go(func() {
+			start := time.Now()
+			block, voteErr := c.fetchVote(ctx, ep.url, payload)
+			c.pool.observe(ep, time.Since(start), voteErr)
+
+			votes[i] = BlockVote{Endpoint: ep.label, Err: voteErr}
+			if voteErr == nil {
+				votes[i].Hash = block.Hash
+				votes[i].TxCount = len(block.Transactions)
+			}
+		})
+	}
+	wg.Wait()
+
+	return votes
+}
+
+func (c *chain) fetchVote(ctx context.Context, url string, payload []byte) (*entity.EthBlock, error) {
+	body, err := post(ctx, c.httpClient, url, payload)
+	if err != nil {
+		return nil, errors.New(text.LeaveOnlyDomainInURLs(err.Error()))
+	}
+
+	var resp entity.RpcResponse[entity.EthBlock]
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("could not unmarshal response: %w", err)
+	}
+
+	if resp.Error != nil {
+		return nil, fmt.Errorf("RPC code(%d) error: %s", resp.Error.Code, resp.Error.Message)
+	}
+
+	if resp.Result == nil {
+		return nil, ErrEmptyResponse
+	}
+
+	return resp.Result, nil
+}
@@ -0,0 +1,34 @@
+package chain
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func Test_block_votes_ask_every_endpoint(t *testing.T) {
+	honest, _ := rpcStub(t, `{"jsonrpc":"2.0","id":"1","result":{"number":"0x64","hash":"0xabc","transactions":["0x1","0x2"]}}`)
+	stale, _ := rpcStub(t, `{"jsonrpc":"2.0","id":"1","result":{"number":"0x64","hash":"0xold","transactions":["0x1"]}}`)
+	lagging, _ := rpcStub(t, `{"jsonrpc":"2.0","id":"1","result":null}`)
+
+	c := NewChain([]string{honest.URL, stale.URL, lagging.URL}, &http.Client{}, newTestMetrics(t))
+
+	votes := c.BlockVotes(context.Background(), 100)
+	if len(votes) != 3 {
+		t.Fatalf("got %d votes, want one per endpoint", len(votes))
+	}
+
+	if votes[0].Err != nil || votes[0].Hash != "0xabc" || votes[0].TxCount != 2 {
+		t.Errorf("honest vote: %+v", votes[0])
+	}
+	if votes[1].Err != nil || votes[1].Hash != "0xold" || votes[1].TxCount != 1 {
+		t.Errorf("stale vote: %+v", votes[1])
+	}
+	if !errors.Is(votes[2].Err, ErrEmptyResponse) {
+		t.Errorf("a provider without the block abstains with ErrEmptyResponse, got %+v", votes[2])
+	}
+	if votes[0].Endpoint == votes[1].Endpoint {
+		t.Error("votes must name their endpoint")
+	}
+}
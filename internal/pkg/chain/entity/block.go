@@ -27,6 +27,16 @@ type EthBlock struct {
 	Timestamp             string   `json:"timestamp"`
 	TotalDifficulty       string   `json:"totalDifficulty"`
 	Transactions          []string `json:"transactions"`
+	// Withdrawals are absent before Shanghai.
+	Withdrawals []Withdrawal `json:"withdrawals"`
+}
+
+// Withdrawal is an EIP-4895 withdrawal, amount in gwei.
+type Withdrawal struct {
+	Index          string `json:"index"`
+	ValidatorIndex string `json:"validatorIndex"`
+	Address        string `json:"address"`
+	Amount         string `json:"amount"`
 }
 
 func (e *EthBlock) GetNumber() int64 {
@@ -1,5 +1,7 @@
 package entity
 
+import "encoding/json"
+
 type RpcRequest struct {
 	JsonRpc string `json:"jsonrpc"`
 	Method  string `json:"method"`
@@ -17,4 +19,6 @@ type RpcResponse[T any] struct {
 type RpcError struct {
 	Code    int    `json:"code"`
 	Message string `json:"message"`
+	// Data is the revert reason of a failed eth_call, when the node sends it.
+	Data json.RawMessage `json:"data,omitempty"`
 }
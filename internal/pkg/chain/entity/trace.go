@@ -0,0 +1,75 @@
+package entity
+
+// CallFrame is one call of the callTracer tree, as debug_traceBlockByHash
+// returns it.
+type CallFrame struct {
+	Type    string      `json:"type"`
+	From    string      `json:"from"`
+	To      string      `json:"to,omitempty"`
+	Value   string      `json:"value,omitempty"`
+	Gas     string      `json:"gas"`
+	GasUsed string      `json:"gasUsed"`
+	Input   string      `json:"input"`
+	Output  string      `json:"output,omitempty"`
+	Error   string      `json:"error,omitempty"`
+	Calls   []CallFrame `json:"calls,omitempty"`
+}
+
+// TxCallTrace is the call tree of one transaction. Older nodes leave TxHash
+// out; the result order is the block's transaction order either way.
+type TxCallTrace struct {
+	TxHash string    `json:"txHash"`
+	Result CallFrame `json:"result"`
+}
+
+// ParityTrace is one flat trace of trace_block. TraceAddress places it in the
+// call tree of its transaction; block rewards come without a transaction.
+type ParityTrace struct {
+	Action              ParityAction  `json:"action"`
+	Result              *ParityResult `json:"result"`
+	Error               string        `json:"error,omitempty"`
+	Subtraces           int           `json:"subtraces"`
+	TraceAddress        []int         `json:"traceAddress"`
+	TransactionHash     string        `json:"transactionHash"`
+	TransactionPosition *int          `json:"transactionPosition"`
+	BlockHash           string        `json:"blockHash"`
+	Type                string        `json:"type"`
+}
+
+type ParityAction struct {
+	CallType       string `json:"callType,omitempty"`
+	CreationMethod string `json:"creationMethod,omitempty"`
+	From           string `json:"from,omitempty"`
+	To             string `json:"to,omitempty"`
+	Value          string `json:"value,omitempty"`
+	Gas            string `json:"gas,omitempty"`
+	Input          string `json:"input,omitempty"`
+	Init           string `json:"init,omitempty"`
+	Address        string `json:"address,omitempty"`
+	RefundAddress  string `json:"refundAddress,omitempty"`
+	Balance        string `json:"balance,omitempty"`
+}
+
+type ParityResult struct {
+	GasUsed string `json:"gasUsed"`
+	Output  string `json:"output,omitempty"`
+	Address string `json:"address,omitempty"`
+	Code    string `json:"code,omitempty"`
+}
+
+// AccountDiff is an account in the prestateTracer's diff mode. Only the
+// storage slots the transaction changed are there; post leaves out the ones
+// it cleared.
+type AccountDiff struct {
+	Storage map[string]string `json:"storage,omitempty"`
+}
+
+// TxStateDiff is what one transaction changed: the accounts before and after.
+// Like TxCallTrace, older nodes leave TxHash out.
+type TxStateDiff struct {
+	TxHash string `json:"txHash"`
+	Result struct {
+		Pre  map[string]AccountDiff `json:"pre"`
+		Post map[string]AccountDiff `json:"post"`
+	} `json:"result"`
+}
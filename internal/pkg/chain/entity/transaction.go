@@ -0,0 +1,33 @@
+package entity
+
+type Transaction struct {
+	BlockHash            string `json:"blockHash"`
+	BlockNumber          string `json:"blockNumber"`
+	ChainID              string `json:"chainId,omitempty"`
+	From                 string `json:"from"`
+	Gas                  string `json:"gas"`
+	GasPrice             string `json:"gasPrice,omitempty"`
+	MaxFeePerGas         string `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas string `json:"maxPriorityFeePerGas,omitempty"`
+	Hash                 string `json:"hash"`
+	Input                string `json:"input"`
+	Nonce                string `json:"nonce"`
+	To                   string `json:"to,omitempty"`
+	TransactionIndex     string `json:"transactionIndex"`
+	Value                string `json:"value"`
+	Type                 string `json:"type"`
+}
+
+// EthFullBlock is eth_getBlockByHash with full transaction objects. Only what
+// the feeder reads is decoded; the header comes with EthBlock.
+type EthFullBlock struct {
+	Hash         string        `json:"hash"`
+	Number       string        `json:"number"`
+	Transactions []Transaction `json:"transactions"`
+}
+
+// TxPoolContent is txpool_content: pending transactions by sender and nonce.
+// The queued ones cannot be included yet and are not decoded.
+type TxPoolContent struct {
+	Pending map[string]map[string]Transaction `json:"pending"`
+}
@@ -0,0 +1,176 @@
+package chain
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/lidofinance/onchain-mon/internal/connectors/metrics"
+	"github.com/lidofinance/onchain-mon/internal/pkg/chain/entity"
+	"github.com/lidofinance/onchain-mon/internal/utils/text"
+)
+
+// HeadReadTimeout drops a subscription that went quiet. A few missed slots
+// mean the node stopped pushing, not that the chain stopped.
+const HeadReadTimeout = 45 * time.Second
+
+// Reconnect backoff of the head subscription, doubled after every failure.
+const HeadReconnectDelay = time.Second
+const HeadMaxReconnectDelay = 30 * time.Second
+
+// subscriptionMsg is either the answer to eth_subscribe (ID set) or a
+// notification carrying a head (Params set).
+type subscriptionMsg struct {
+	ID     string              `json:"id"`
+	Method string              `json:"method"`
+	Error  *entity.RpcError    `json:"error"`
+	Params *subscriptionParams `json:"params"`
+}
+
+type subscriptionParams struct {
+	Subscription string          `json:"subscription"`
+	Result       entity.EthBlock `json:"result"`
+}
+
+// HeadSubscriber follows eth_subscribe("newHeads") over a WebSocket. It only
+// tells when a new head appears: blocks and receipts are still fetched over
+// HTTP, so a dropped subscription costs latency, never blocks.
+type HeadSubscriber struct {
+	url      string
+	log      *slog.Logger
+	metrics  *metrics.Store
+	minDelay time.Duration
+}
+
+func NewHeadSubscriber(wsUrl string, log *slog.Logger, metricsStore *metrics.Store) *HeadSubscriber {
+	return &HeadSubscriber{
+		url:      wsUrl,
+		log:      log,
+		metrics:  metricsStore,
+		minDelay: HeadReconnectDelay,
+	}
+}
+
+// Heads streams new heads until ctx is done, reconnecting with backoff. The
+// channel holds only the newest head: a slow reader skips straight to it
+// instead of working through stale ones. It is closed when ctx is done.
+func (s *HeadSubscriber) Heads(ctx context.Context) <-chan entity.EthBlock {
+	out := make(chan entity.EthBlock, 1)
+
+	go func() {
+		defer close(out)
+
+		delay := s.minDelay
+		for {
+			subscribed, err := s.follow(ctx, out)
+			s.metrics.HeadSubscriptionUp.Set(0)
+
+			if ctx.Err() != nil {
+				return
+			}
+
+			if subscribed {
+				delay = s.minDelay
+			}
+
+			s.metrics.HeadSubscriptionReconnects.Inc()
+			s.log.Warn("newHeads subscription dropped, polling until it is back",
+				slog.String("error", text.LeaveOnlyDomainInURLs(err.Error())),
+				slog.Duration("retryIn", delay),
+			)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(delay):
+			}
+
+			delay = min(delay*2, HeadMaxReconnectDelay)
+		}
+	}()
+
+	return out
+}
+
+// follow runs one subscription until it breaks. It reports whether the node
+// accepted the subscription, so a flapping connection still backs off.
+func (s *HeadSubscriber) follow(ctx context.Context, out chan entity.EthBlock) (bool, error) {
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, s.url, nil)
+	if err != nil {
+		return false, fmt.Errorf("dial: %w", err)
+	}
+	defer conn.Close()
+
+	// ReadMessage does not watch ctx; closing the connection unblocks it.
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+
+	if err := conn.WriteJSON(entity.RpcRequest{
+		JsonRpc: JsonRpcVersion,
+		Method:  "eth_subscribe",
+		Params:  []any{"newHeads"},
+		ID:      "newHeads",
+	}); err != nil {
+		return false, fmt.Errorf("send eth_subscribe: %w", err)
+	}
+
+	subscribed := false
+	for {
+		if err := conn.SetReadDeadline(time.Now().Add(HeadReadTimeout)); err != nil {
+			return subscribed, err
+		}
+
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return subscribed, fmt.Errorf("read: %w", err)
+		}
+
+		var msg subscriptionMsg
+		if err := json.Unmarshal(data, &msg); err != nil {
+			return subscribed, fmt.Errorf("could not unmarshal message: %w", err)
+		}
+
+		if msg.ID != "" {
+			if msg.Error != nil {
+				return subscribed, fmt.Errorf("eth_subscribe code(%d) error: %s", msg.Error.Code, msg.Error.Message)
+			}
+
+			subscribed = true
+			s.metrics.HeadSubscriptionUp.Set(1)
+			s.log.Info("newHeads subscription is live")
+
+			continue
+		}
+
+		if msg.Method != "eth_subscription" || msg.Params == nil {
+			continue
+		}
+
+		if msg.Params.Result.Number == "" {
+			return subscribed, errors.New("head without a number")
+		}
+
+		push(out, msg.Params.Result)
+	}
+}
+
+// push replaces whatever head the reader has not picked up yet.
+func push(out chan entity.EthBlock, head entity.EthBlock) {
+	for {
+		select {
+		case out <- head:
+			return
+		default:
+		}
+
+		select {
+		case <-out:
+		default:
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package chain
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/lidofinance/onchain-mon/internal/pkg/chain/entity"
+)
+
+// wsStub accepts eth_subscribe, pushes the given heads and hangs up, the way a
+// node does when it restarts.
+func wsStub(t *testing.T, heads ...int64) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	conns := &atomic.Int32{}
+	upgrader := websocket.Upgrader{}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conns.Add(1)
+
+		var req entity.RpcRequest
+		if err := conn.ReadJSON(&req); err != nil || req.Method != "eth_subscribe" {
+			return
+		}
+
+		_ = conn.WriteMessage(websocket.TextMessage,
+			[]byte(fmt.Sprintf(`{"jsonrpc":"2.0","id":%q,"result":"0xsub"}`, req.ID)))
+
+		for _, n := range heads {
+			head, _ := json.Marshal(map[string]any{
+				"jsonrpc": "2.0",
+				"method":  "eth_subscription",
+				"params": map[string]any{
+					"subscription": "0xsub",
+					"result":       map[string]string{"number": fmt.Sprintf("0x%x", n), "hash": fmt.Sprintf("0x%d", n)},
+				},
+			})
+			_ = conn.WriteMessage(websocket.TextMessage, head)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, conns
+}
+
+func newTestSubscriber(t *testing.T, srv *httptest.Server) *HeadSubscriber {
+	t.Helper()
+
+	s := NewHeadSubscriber("ws"+strings.TrimPrefix(srv.URL, "http"), slog.New(slog.NewTextHandler(io.Discard, nil)), newTestMetrics(t))
+	s.minDelay = 10 * time.Millisecond
+
+	return s
+}
+
+func Test_heads_are_pushed_and_the_subscription_comes_back(t *testing.T) {
+	srv, conns := wsStub(t, 100)
+	s := newTestSubscriber(t, srv)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	heads := s.Heads(ctx)
+
+	// Every connection pushes block 100 and hangs up, so seeing it twice means
+	// the subscriber reconnected on its own.
+	for i := range 2 {
+		select {
+		case head := <-heads:
+			if head.GetNumber() != 100 {
+				t.Fatalf("got head %d, want 100", head.GetNumber())
+			}
+		case <-ctx.Done():
+			t.Fatalf("no head after %d connections", conns.Load())
+		}
+
+		if i == 0 && conns.Load() != 1 {
+			t.Errorf("got %d connections before the first head, want 1", conns.Load())
+		}
+	}
+
+	if v := testutil.ToFloat64(s.metrics.HeadSubscriptionReconnects); v < 1 {
+		t.Errorf("reconnect counter: got %v, want at least 1", v)
+	}
+
+	cancel()
+	for range heads {
+	}
+}
+
+func Test_slow_reader_gets_the_newest_head(t *testing.T) {
+	out := make(chan entity.EthBlock, 1)
+
+	push(out, entity.EthBlock{Number: "0x64"})
+	push(out, entity.EthBlock{Number: "0x65"})
+
+	head := <-out
+	if got := head.GetNumber(); got != 101 {
+		t.Errorf("got head %d, want the newest one, 101", got)
+	}
+}
+
+func Test_unreachable_node_closes_the_channel_on_cancel(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	s := newTestSubscriber(t, srv)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	heads := s.Heads(ctx)
+
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+
+	select {
+	case _, ok := <-heads:
+		if ok {
+			t.Fatal("an unreachable node must not produce heads")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the channel was not closed after cancel")
+	}
+}
@@ -0,0 +1,166 @@
+package chain
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/lidofinance/onchain-mon/internal/connectors/metrics"
+	"github.com/lidofinance/onchain-mon/internal/pkg/chain/entity"
+	"github.com/lidofinance/onchain-mon/internal/utils/text"
+)
+
+// PendingReadTimeout drops a pending subscription that went quiet. The
+// mempool of a busy chain never does; an L2 sequencer's may for a while.
+const PendingReadTimeout = 2 * time.Minute
+
+// PendingBuffer is how many pending transactions wait for the reader. Past
+// that they are dropped: the next ones are worth more than a backlog.
+const PendingBuffer = 4096
+
+type pendingMsg struct {
+	ID     string           `json:"id"`
+	Method string           `json:"method"`
+	Error  *entity.RpcError `json:"error"`
+	Params *struct {
+		Subscription string             `json:"subscription"`
+		Result       entity.Transaction `json:"result"`
+	} `json:"params"`
+}
+
+// PendingSubscriber follows eth_subscribe("newPendingTransactions", true) over
+// a WebSocket: every transaction the node admits to its mempool, in full.
+type PendingSubscriber struct {
+	url      string
+	log      *slog.Logger
+	metrics  *metrics.Store
+	minDelay time.Duration
+}
+
+func NewPendingSubscriber(wsUrl string, log *slog.Logger, metricsStore *metrics.Store) *PendingSubscriber {
+	return &PendingSubscriber{
+		url:      wsUrl,
+		log:      log,
+		metrics:  metricsStore,
+		minDelay: HeadReconnectDelay,
+	}
+}
+
+// Pending streams pending transactions until ctx is done, reconnecting with
+// the backoff of the head subscription. Transactions the reader is too slow
+// for are dropped and counted. The channel is closed when ctx is done.
+func (s *PendingSubscriber) Pending(ctx context.Context) <-chan entity.Transaction {
+	out := make(chan entity.Transaction, PendingBuffer)
+
+	go func() {
+		defer close(out)
+
+		delay := s.minDelay
+		for {
+			subscribed, err := s.follow(ctx, out)
+			s.metrics.PendingSubscriptionUp.Set(0)
+
+			if ctx.Err() != nil {
+				return
+			}
+
+			if subscribed {
+				delay = s.minDelay
+			}
+
+			s.metrics.PendingSubscriptionReconnects.Inc()
+			s.log.Warn("newPendingTransactions subscription dropped",
+				slog.String("error", text.LeaveOnlyDomainInURLs(err.Error())),
+				slog.Duration("retryIn", delay),
+			)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(delay):
+			}
+
+			delay = min(delay*2, HeadMaxReconnectDelay)
+		}
+	}()
+
+	return out
+}
+
+func (s *PendingSubscriber) follow(ctx context.Context, out chan entity.Transaction) (bool, error) {
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, s.url, nil)
+	if err != nil {
+		return false, fmt.Errorf("dial: %w", err)
+	}
+	defer conn.Close()
+
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+
+	// true asks for whole transactions instead of hashes to look up one by
+	// one. Geth, Erigon and Reth support it.
+	if err := conn.WriteJSON(entity.RpcRequest{
+		JsonRpc: JsonRpcVersion,
+		Method:  "eth_subscribe",
+		Params:  []any{"newPendingTransactions", true},
+		ID:      "newPendingTransactions",
+	}); err != nil {
+		return false, fmt.Errorf("send eth_subscribe: %w", err)
+	}
+
+	subscribed := false
+	for {
+		if err := conn.SetReadDeadline(time.Now().Add(PendingReadTimeout)); err != nil {
+			return subscribed, err
+		}
+
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return subscribed, fmt.Errorf("read: %w", err)
+		}
+
+		// A node ignoring the flag sends bare hashes, which fail here: better
+		// loudly than publishing nothing.
+		var msg pendingMsg
+		if err := json.Unmarshal(data, &msg); err != nil {
+			return subscribed, fmt.Errorf("could not unmarshal message: %w", err)
+		}
+
+		if msg.ID != "" {
+			if msg.Error != nil {
+				return subscribed, fmt.Errorf("eth_subscribe code(%d) error: %s", msg.Error.Code, msg.Error.Message)
+			}
+
+			subscribed = true
+			s.metrics.PendingSubscriptionUp.Set(1)
+			s.log.Info("newPendingTransactions subscription is live")
+
+			continue
+		}
+
+		if msg.Method != "eth_subscription" || msg.Params == nil {
+			continue
+		}
+
+		if msg.Params.Result.Hash == "" {
+			return subscribed, errors.New("pending transaction without a hash")
+		}
+
+		select {
+		case out <- msg.Params.Result:
+		default:
+			s.metrics.MempoolDropped.Inc()
+		}
+	}
+}
+
+// TxPoolContent reads the mempool of whichever endpoint answers first. Every
+// node has its own; any of them will do.
+func (c *chain) TxPoolContent(ctx context.Context) (*entity.RpcResponse[entity.TxPoolContent], error) {
+	return doRpcRequest[entity.TxPoolContent](ctx, "txpool_content", []any{}, c.httpClient, c.metrics, c.pool, nil)
+}
@@ -0,0 +1,105 @@
+package chain
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/lidofinance/onchain-mon/internal/pkg/chain/entity"
+)
+
+// pendingStub accepts eth_subscribe and pushes one notification per result.
+func pendingStub(t *testing.T, results ...string) *httptest.Server {
+	t.Helper()
+
+	upgrader := websocket.Upgrader{}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		var req struct {
+			ID     string `json:"id"`
+			Method string `json:"method"`
+			Params []any  `json:"params"`
+		}
+		if err := conn.ReadJSON(&req); err != nil || len(req.Params) != 2 || req.Params[1] != true {
+			return
+		}
+
+		_ = conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf(`{"jsonrpc":"2.0","id":%q,"result":"0xsub"}`, req.ID)))
+
+		for _, result := range results {
+			_ = conn.WriteMessage(websocket.TextMessage, []byte(
+				`{"jsonrpc":"2.0","method":"eth_subscription","params":{"subscription":"0xsub","result":`+result+`}}`))
+		}
+
+		// Keep the subscription open until the test is done with it.
+		_, _, _ = conn.ReadMessage()
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func newTestPendingSubscriber(t *testing.T, srv *httptest.Server) *PendingSubscriber {
+	t.Helper()
+
+	s := NewPendingSubscriber("ws"+strings.TrimPrefix(srv.URL, "http"), slog.New(slog.NewTextHandler(io.Discard, nil)), newTestMetrics(t))
+	s.minDelay = 10 * time.Millisecond
+
+	return s
+}
+
+func Test_pending_transactions_come_in_full(t *testing.T) {
+	srv := pendingStub(t,
+		`{"hash":"0xt1","from":"0xf1","to":"0xc0","input":"0x","nonce":"0x1","value":"0x0","gas":"0x5208","type":"0x2"}`,
+		`{"hash":"0xt2","from":"0xf2","input":"0x60","nonce":"0x0","value":"0x0","gas":"0x5208","type":"0x0"}`,
+	)
+	s := newTestPendingSubscriber(t, srv)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	pending := s.Pending(ctx)
+
+	for _, want := range []string{"0xt1", "0xt2"} {
+		select {
+		case tx := <-pending:
+			if tx.Hash != want {
+				t.Fatalf("got %s, want %s", tx.Hash, want)
+			}
+		case <-ctx.Done():
+			t.Fatalf("no %s", want)
+		}
+	}
+
+	cancel()
+	for range pending {
+	}
+}
+
+func Test_pending_hashes_only_are_refused(t *testing.T) {
+	// What a node ignoring the full-transactions flag sends.
+	srv := pendingStub(t, `"0xt1"`)
+	s := newTestPendingSubscriber(t, srv)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	subscribed, err := s.follow(ctx, make(chan entity.Transaction, 1))
+	if !subscribed || err == nil {
+		t.Errorf("got subscribed=%v err=%v, want a subscription that fails on the first hash", subscribed, err)
+	}
+}
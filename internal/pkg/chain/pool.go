@@ -0,0 +1,202 @@
+package chain
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/avast/retry-go/v4"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/lidofinance/onchain-mon/internal/connectors/metrics"
+	"github.com/lidofinance/onchain-mon/internal/utils/text"
+)
+
+var ErrNoEndpoints = errors.New("no rpc endpoints configured")
+
+// Health is kept as exponentially weighted averages: a single slow answer does
+// not reshuffle the pool, a provider that keeps failing drops behind the others
+// within a few requests.
+const HealthWeight = 0.2
+
+// ErrorPenalty turns the error rate into seconds so it can be added to latency:
+// an endpoint failing 10% of calls scores like one answering a second slower.
+const ErrorPenalty = 10 * time.Second
+
+// ScoreBand is how much better an endpoint has to score before it overtakes one
+// configured ahead of it, so the preferred provider is not dropped for being a
+// few milliseconds slower than a fallback.
+const ScoreBand = 250 * time.Millisecond
+
+// ErrorHalfLife lets a demoted endpoint win traffic back. Without the decay a
+// primary that failed once would never be probed again while the others answer.
+const ErrorHalfLife = time.Minute
+
+type endpoint struct {
+	url string
+	// label is the endpoint as it shows up in metrics and logs: the URL minus
+	// its path, which often carries the API key.
+	label string
+
+	mu        sync.Mutex
+	latency   float64
+	errorRate float64
+	updatedAt time.Time
+}
+
+// decayedErrorRate must be called with mu held.
+func (e *endpoint) decayedErrorRate(now time.Time) float64 {
+	if e.updatedAt.IsZero() {
+		return e.errorRate
+	}
+
+	return e.errorRate * math.Pow(0.5, float64(now.Sub(e.updatedAt))/float64(ErrorHalfLife))
+}
+
+func (e *endpoint) score(now time.Time) float64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.latency + e.decayedErrorRate(now)*ErrorPenalty.Seconds()
+}
+
+// observe folds one request into the endpoint's health. A failed request says
+// nothing useful about latency, so only the error rate moves.
+func (e *endpoint) observe(now time.Time, took time.Duration, failed bool) (latency, errorRate float64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	failure := 0.0
+	if failed {
+		failure = 1
+	}
+
+	e.errorRate = e.decayedErrorRate(now)*(1-HealthWeight) + failure*HealthWeight
+	if !failed {
+		if e.latency == 0 {
+			e.latency = took.Seconds()
+		} else {
+			e.latency = e.latency*(1-HealthWeight) + took.Seconds()*HealthWeight
+		}
+	}
+	e.updatedAt = now
+
+	return e.latency, e.errorRate
+}
+
+type pool struct {
+	endpoints []*endpoint
+	metrics   *metrics.Store
+}
+
+func newPool(urls []string, m *metrics.Store) *pool {
+	endpoints := make([]*endpoint, 0, len(urls))
+	seen := make(map[string]bool, len(urls))
+
+	for i, url := range urls {
+		label := text.LeaveOnlyDomainInURLs(url)
+		// Two keys on the same provider must not share a time series.
+		if seen[label] {
+			label = fmt.Sprintf("%s#%d", label, i)
+		}
+		seen[label] = true
+
+		endpoints = append(endpoints, &endpoint{url: url, label: label})
+	}
+
+	return &pool{
+		endpoints: endpoints,
+		metrics:   m,
+	}
+}
+
+// ordered returns the endpoints healthiest first. Scores are compared in bands
+// of ScoreBand and ties keep the configured order, so with no history yet, or
+// with all endpoints doing fine, the first URL is the primary.
+func (p *pool) ordered() []*endpoint {
+	now := time.Now()
+
+	type scored struct {
+		ep   *endpoint
+		band int64
+	}
+
+	list := make([]scored, 0, len(p.endpoints))
+	for _, ep := range p.endpoints {
+		list = append(list, scored{ep: ep, band: int64(ep.score(now) / ScoreBand.Seconds())})
+	}
+
+	slices.SortStableFunc(list, func(a, b scored) int {
+		return cmp.Compare(a.band, b.band)
+	})
+
+	out := make([]*endpoint, 0, len(list))
+	for _, s := range list {
+		out = append(out, s.ep)
+	}
+
+	return out
+}
+
+// observe records the outcome of one attempt. ErrEmptyResponse is a healthy
+// answer — the block simply is not there yet — and must not demote anyone;
+// neither must a reverted call.
+func (p *pool) observe(ep *endpoint, took time.Duration, err error) {
+	failed := err != nil && !errors.Is(err, ErrEmptyResponse) && !isReverted(err)
+	latency, errorRate := ep.observe(time.Now(), took, failed)
+
+	status := metrics.StatusOk
+	if failed {
+		status = metrics.StatusFail
+	}
+
+	p.metrics.RpcEndpointRequests.With(prometheus.Labels{metrics.Endpoint: ep.label, metrics.Status: status}).Inc()
+	p.metrics.RpcEndpointLatency.With(prometheus.Labels{metrics.Endpoint: ep.label}).Set(latency)
+	p.metrics.RpcEndpointErrorRate.With(prometheus.Labels{metrics.Endpoint: ep.label}).Set(errorRate)
+}
+
+// do runs one logical request against the pool: the healthiest endpoint first,
+// the next one on every retry, so a flaky provider costs a retry instead of the
+// whole request.
+func do[T any](ctx context.Context, p *pool, fn func(url string) (T, error), opts ...retry.Option) (T, error) {
+	order := p.ordered()
+	if len(order) == 0 {
+		var zero T
+		return zero, ErrNoEndpoints
+	}
+
+	attempt := 0
+	retryOpts := []retry.Option{
+		retry.Attempts(MaxAttempts),
+		retry.Delay(RetryDelay),
+		retry.MaxDelay(MaxDelay),
+		retry.DelayType(retry.CombineDelay(
+			retry.BackOffDelay,
+			retry.RandomDelay,
+		)),
+		retry.Context(ctx),
+	}
+
+	return retry.DoWithData(
+		func() (T, error) {
+			ep := order[attempt%len(order)]
+			attempt++
+
+			start := time.Now()
+			out, err := fn(ep.url)
+			p.observe(ep, time.Since(start), err)
+
+			if err == nil && ep != order[0] {
+				p.metrics.RpcFailovers.Inc()
+			}
+
+			return out, err
+		},
+		append(retryOpts, opts...)...,
+	)
+}
@@ -0,0 +1,120 @@
+package chain
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/lidofinance/onchain-mon/internal/connectors/metrics"
+)
+
+func newTestMetrics(t *testing.T) *metrics.Store {
+	t.Helper()
+	return metrics.New(prometheus.NewRegistry(), "chain_test", "t", "t")
+}
+
+// rpcStub answers every request with the given body and counts the calls.
+func rpcStub(t *testing.T, body string) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	calls := &atomic.Int32{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, calls
+}
+
+const latestBlockBody = `{"jsonrpc":"2.0","id":"1","result":{"number":"0x64","hash":"0xabc"}}`
+
+func Test_request_fails_over_to_the_next_endpoint(t *testing.T) {
+	broken, brokenCalls := rpcStub(t, `<html>502 Bad Gateway</html>`)
+	healthy, healthyCalls := rpcStub(t, latestBlockBody)
+
+	m := newTestMetrics(t)
+	c := NewChain([]string{broken.URL, healthy.URL}, &http.Client{}, m)
+
+	got, err := c.GetLatestBlock(context.Background())
+	if err != nil {
+		t.Fatalf("the healthy endpoint must answer within the same call: %v", err)
+	}
+	if got.Result.GetNumber() != 100 {
+		t.Errorf("got block %d, want 100", got.Result.GetNumber())
+	}
+	if brokenCalls.Load() != 1 || healthyCalls.Load() != 1 {
+		t.Errorf("calls: broken=%d healthy=%d, want 1 and 1", brokenCalls.Load(), healthyCalls.Load())
+	}
+	if v := testutil.ToFloat64(m.RpcFailovers); v != 1 {
+		t.Errorf("failover counter: got %v, want 1", v)
+	}
+
+	// The broken endpoint is demoted: the next request goes straight to the
+	// healthy one.
+	if _, err := c.GetLatestBlock(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if brokenCalls.Load() != 1 {
+		t.Errorf("the demoted endpoint was asked again: %d calls", brokenCalls.Load())
+	}
+}
+
+func Test_empty_response_does_not_demote_an_endpoint(t *testing.T) {
+	lagging, _ := rpcStub(t, `{"jsonrpc":"2.0","id":"1","result":null}`)
+	other, _ := rpcStub(t, latestBlockBody)
+
+	c := NewChain([]string{lagging.URL, other.URL}, &http.Client{}, newTestMetrics(t))
+
+	if _, err := c.FetchBlockByNumber(context.Background(), 101); err == nil {
+		t.Fatal("expected ErrEmptyResponse")
+	}
+
+	if first := c.pool.ordered()[0]; first.url != lagging.URL {
+		t.Errorf("an empty answer is not a failure, yet %s took over", first.url)
+	}
+}
+
+func Test_ordered_prefers_config_order_then_health(t *testing.T) {
+	p := newPool([]string{"https://primary.example/key", "https://fallback.example/key"}, newTestMetrics(t))
+	primary, fallback := p.endpoints[0], p.endpoints[1]
+
+	if got := p.ordered()[0]; got != primary {
+		t.Fatalf("without history the first configured endpoint leads, got %s", got.label)
+	}
+
+	now := time.Now()
+	primary.observe(now, 50*time.Millisecond, true)
+	fallback.observe(now, 300*time.Millisecond, false)
+
+	if got := p.ordered()[0]; got != fallback {
+		t.Fatalf("a failing primary must yield to a slower healthy fallback, got %s", got.label)
+	}
+
+	// The error fades, so the primary gets probed again instead of being
+	// benched forever.
+	primary.mu.Lock()
+	primary.updatedAt = now.Add(-10 * ErrorHalfLife)
+	primary.mu.Unlock()
+
+	if got := p.ordered()[0]; got != primary {
+		t.Errorf("the primary should lead again once its errors decayed, got %s", got.label)
+	}
+}
+
+func Test_endpoint_labels_hide_the_api_key(t *testing.T) {
+	p := newPool([]string{"https://rpc.example/secret-a", "https://rpc.example/secret-b"}, newTestMetrics(t))
+
+	want := []string{"https://rpc.example", "https://rpc.example#1"}
+	for i, ep := range p.endpoints {
+		if ep.label != want[i] {
+			t.Errorf("endpoint %d: got label %q, want %q", i, ep.label, want[i])
+		}
+	}
+}
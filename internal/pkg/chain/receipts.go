@@ -0,0 +1,124 @@
+package chain
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/sha3"
+
+	"github.com/lidofinance/onchain-mon/internal/connectors/metrics"
+	"github.com/lidofinance/onchain-mon/internal/pkg/chain/entity"
+)
+
+var ErrReceiptsMismatch = errors.New("receipts do not match the block")
+
+// BloomLength is the size of logsBloom in bytes: 2048 bits.
+const BloomLength = 256
+
+// ReceiptsMismatchError tells which check the receipts failed. Reason is one of
+// the metrics.ReasonReceipts* values.
+type ReceiptsMismatchError struct {
+	Reason string
+	Detail string
+}
+
+func (e *ReceiptsMismatchError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrReceiptsMismatch, e.Detail)
+}
+
+func (e *ReceiptsMismatchError) Unwrap() error {
+	return ErrReceiptsMismatch
+}
+
+// VerifyReceipts checks that receipts are the complete set of block: one per
+// transaction, in order, from this block, and that their logs add up to the
+// block's logsBloom. It catches the truncated lists some providers return
+// under load without rebuilding the receipts trie.
+func VerifyReceipts(block *entity.EthBlock, receipts []entity.BlockReceipt) error {
+	if len(receipts) != len(block.Transactions) {
+		return &ReceiptsMismatchError{
+			Reason: metrics.ReasonReceiptsCount,
+			Detail: fmt.Sprintf("block %s has %d transactions, got %d receipts", block.Hash, len(block.Transactions), len(receipts)),
+		}
+	}
+
+	for i := range receipts {
+		if !strings.EqualFold(receipts[i].TransactionHash, block.Transactions[i]) || !strings.EqualFold(receipts[i].BlockHash, block.Hash) {
+			return &ReceiptsMismatchError{
+				Reason: metrics.ReasonReceiptsOrder,
+				Detail: fmt.Sprintf("receipt %d is for tx %s in block %s, want tx %s in block %s",
+					i, receipts[i].TransactionHash, receipts[i].BlockHash, block.Transactions[i], block.Hash),
+			}
+		}
+	}
+
+	// Some fakes and exotic chains leave it out; nothing to compare with.
+	if block.LogsBloom == "" {
+		return nil
+	}
+
+	want, err := hex.DecodeString(strings.TrimPrefix(block.LogsBloom, "0x"))
+	if err != nil || len(want) != BloomLength {
+		return &ReceiptsMismatchError{
+			Reason: metrics.ReasonReceiptsBloom,
+			Detail: fmt.Sprintf("block %s has a malformed logsBloom", block.Hash),
+		}
+	}
+
+	got, err := logsBloom(receipts)
+	if err != nil {
+		return &ReceiptsMismatchError{Reason: metrics.ReasonReceiptsBloom, Detail: err.Error()}
+	}
+
+	if !bytes.Equal(got, want) {
+		return &ReceiptsMismatchError{
+			Reason: metrics.ReasonReceiptsBloom,
+			Detail: fmt.Sprintf("logs of block %s do not add up to its logsBloom", block.Hash),
+		}
+	}
+
+	return nil
+}
+
+// logsBloom rebuilds the block bloom from the logs: every address and topic
+// sets three of the 2048 bits, picked by its keccak256.
+func logsBloom(receipts []entity.BlockReceipt) ([]byte, error) {
+	bloom := make([]byte, BloomLength)
+
+	add := func(value string) error {
+		raw, err := hex.DecodeString(strings.TrimPrefix(value, "0x"))
+		if err != nil {
+			return fmt.Errorf("malformed log field %q: %w", value, err)
+		}
+
+		h := sha3.NewLegacyKeccak256()
+		h.Write(raw)
+		sum := h.Sum(nil)
+
+		for i := 0; i < 6; i += 2 {
+			bit := (uint(sum[i])<<8 | uint(sum[i+1])) & 2047
+			bloom[BloomLength-1-bit/8] |= 1 << (bit % 8)
+		}
+
+		return nil
+	}
+
+	for i := range receipts {
+		for _, l := range receipts[i].Logs {
+			if err := add(l.Address); err != nil {
+				return nil, err
+			}
+
+			for _, topic := range l.Topics {
+				if err := add(topic); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	return bloom, nil
+}
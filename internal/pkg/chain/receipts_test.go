@@ -0,0 +1,144 @@
+package chain
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/lidofinance/onchain-mon/internal/connectors/metrics"
+	"github.com/lidofinance/onchain-mon/internal/pkg/chain/entity"
+)
+
+// Two logs and the bloom go-ethereum computes for them.
+const twoLogsBloom = `0x00000100000000000000000000000000000000000000000000000000000000000000000000000000000000000000000002000000080000000000000000000000000000000000000000000008000000000000000000000000000000208000000000000000000000000000000000200000000000000000000000000010000000000000000000000000000000000000000002000001000000080000000000000000000000000000000000000000000200000000000000000000000000000000000000000002000000000000000000000000000000000000000000000000000000000000200000000000000000000000000000000000000000400000000000000000`
+
+func twoLogsBlock() (*entity.EthBlock, []entity.BlockReceipt) {
+	block := &entity.EthBlock{
+		Hash:         "0xb10c",
+		LogsBloom:    twoLogsBloom,
+		Transactions: []string{"0xt1", "0xt2"},
+	}
+
+	receipts := []entity.BlockReceipt{
+		{BlockHash: "0xb10c", TransactionHash: "0xt1", Logs: []entity.Log{{
+			Address: "0xae7ab96520de3a18e5e111b5eaab095312d7fe84",
+			Topics: []string{
+				"0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef",
+				"0x000000000000000000000000b9d7934878b5fb9610b3fe8a5e441e8fad7e293f",
+			},
+		}}},
+		{BlockHash: "0xb10c", TransactionHash: "0xt2", Logs: []entity.Log{{
+			Address: "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2",
+			Topics:  []string{"0xe1fffcc4923d04b559f4d29a8bfc6cda04eb5b0d3c460751c2402c5c5cc9109c"},
+		}}},
+	}
+
+	return block, receipts
+}
+
+func Test_verify_receipts(t *testing.T) {
+	tests := []struct {
+		name   string
+		spoil  func(*entity.EthBlock, []entity.BlockReceipt) []entity.BlockReceipt
+		reason string
+	}{
+		{"complete", func(_ *entity.EthBlock, r []entity.BlockReceipt) []entity.BlockReceipt { return r }, ""},
+		{"truncated", func(_ *entity.EthBlock, r []entity.BlockReceipt) []entity.BlockReceipt { return r[:1] }, metrics.ReasonReceiptsCount},
+		{"out_of_order", func(_ *entity.EthBlock, r []entity.BlockReceipt) []entity.BlockReceipt {
+			return []entity.BlockReceipt{r[1], r[0]}
+		}, metrics.ReasonReceiptsOrder},
+		{"foreign_block", func(_ *entity.EthBlock, r []entity.BlockReceipt) []entity.BlockReceipt {
+			r[1].BlockHash = "0xother"
+			return r
+		}, metrics.ReasonReceiptsOrder},
+		{"missing_log", func(_ *entity.EthBlock, r []entity.BlockReceipt) []entity.BlockReceipt {
+			r[1].Logs = nil
+			return r
+		}, metrics.ReasonReceiptsBloom},
+		{"no_bloom_to_compare", func(b *entity.EthBlock, r []entity.BlockReceipt) []entity.BlockReceipt {
+			b.LogsBloom = ""
+			r[1].Logs = nil
+			return r
+		}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			block, receipts := twoLogsBlock()
+			err := VerifyReceipts(block, tt.spoil(block, receipts))
+
+			if tt.reason == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			var mismatch *ReceiptsMismatchError
+			if !errors.As(err, &mismatch) || !errors.Is(err, ErrReceiptsMismatch) {
+				t.Fatalf("got %v, want a ReceiptsMismatchError", err)
+			}
+			if mismatch.Reason != tt.reason {
+				t.Errorf("got reason %q, want %q", mismatch.Reason, tt.reason)
+			}
+		})
+	}
+}
+
+func Test_truncated_receipts_fail_over(t *testing.T) {
+	truncated, truncatedCalls := rpcStub(t, `{"jsonrpc":"2.0","id":"1","result":[
+		{"blockHash":"0xb10c","transactionHash":"0xt1","logs":[]}
+	]}`)
+	complete, _ := rpcStub(t, `{"jsonrpc":"2.0","id":"1","result":[
+		{"blockHash":"0xb10c","transactionHash":"0xt1","logs":[]},
+		{"blockHash":"0xb10c","transactionHash":"0xt2","logs":[]}
+	]}`)
+
+	m := newTestMetrics(t)
+	c := NewChain([]string{truncated.URL, complete.URL}, &http.Client{}, m)
+
+	block := &entity.EthBlock{Hash: "0xb10c", Transactions: []string{"0xt1", "0xt2"}}
+
+	got, err := c.GetVerifiedReceipts(context.Background(), block)
+	if err != nil {
+		t.Fatalf("the complete endpoint must answer within the same call: %v", err)
+	}
+	if len(*got.Result) != 2 {
+		t.Errorf("got %d receipts, want 2", len(*got.Result))
+	}
+	if truncatedCalls.Load() != 1 {
+		t.Errorf("the truncating endpoint was asked %d times, want 1", truncatedCalls.Load())
+	}
+	if v := testutil.ToFloat64(m.ReceiptsMismatches.WithLabelValues(metrics.ReasonReceiptsCount)); v != 1 {
+		t.Errorf("mismatch counter: got %v, want 1", v)
+	}
+}
+
+func Test_reordered_transactions_fail_over(t *testing.T) {
+	reordered, reorderedCalls := rpcStub(t, `{"jsonrpc":"2.0","id":"1","result":{"hash":"0xb10c","transactions":[
+		{"hash":"0xt2","input":"0x"},
+		{"hash":"0xt1","input":"0x"}
+	]}}`)
+	ordered, _ := rpcStub(t, `{"jsonrpc":"2.0","id":"1","result":{"hash":"0xb10c","transactions":[
+		{"hash":"0xt1","input":"0xa9059cbb"},
+		{"hash":"0xt2","input":"0x"}
+	]}}`)
+
+	c := NewChain([]string{reordered.URL, ordered.URL}, &http.Client{}, newTestMetrics(t))
+
+	block := &entity.EthBlock{Hash: "0xb10c", Transactions: []string{"0xt1", "0xt2"}}
+
+	got, err := c.GetBlockTransactions(context.Background(), block)
+	if err != nil {
+		t.Fatalf("the ordered endpoint must answer within the same call: %v", err)
+	}
+	if input := got.Result.Transactions[0].Input; input != "0xa9059cbb" {
+		t.Errorf("got input %q of the first transaction, want the ordered endpoint's", input)
+	}
+	if reorderedCalls.Load() != 1 {
+		t.Errorf("the reordering endpoint was asked %d times, want 1", reorderedCalls.Load())
+	}
+}
@@ -0,0 +1,72 @@
+package chain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/lidofinance/onchain-mon/internal/utils/text"
+)
+
+// StorageSlot is one eth_getStorageAt of a StorageBatch: a 32-byte slot of
+// the contract at Address.
+type StorageSlot struct {
+	Address string
+	Slot    string
+}
+
+// StorageBatch reads slots as one JSON-RPC batch at the block blockHash and
+// returns their 32-byte words in the same order. Any error fails the whole
+// batch over to the next endpoint.
+func (c *chain) StorageBatch(ctx context.Context, slots []StorageSlot, blockHash string) ([]string, error) {
+	if len(slots) == 0 {
+		return nil, nil
+	}
+
+	block := map[string]any{"blockHash": blockHash}
+
+	params := make([][]any, 0, len(slots))
+	for _, s := range slots {
+		params = append(params, []any{s.Address, s.Slot, block})
+	}
+
+	payload, err := batchPayload("eth_getStorageAt", params)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := do(ctx, c.pool,
+		func(jsonRpcUrl string) ([]string, error) {
+			body, err := post(ctx, c.httpClient, jsonRpcUrl, payload)
+			if err != nil {
+				return nil, err
+			}
+
+			answers, err := batchAnswers(body, len(slots))
+			if err != nil {
+				return nil, err
+			}
+
+			words := make([]string, len(slots))
+			for i, r := range answers {
+				if r.Error != nil {
+					return nil, &CallError{RpcError: *r.Error}
+				}
+
+				if r.Result == nil {
+					return nil, fmt.Errorf("eth_getStorageAt %d: %w", i, ErrEmptyResponse)
+				}
+
+				words[i] = *r.Result
+			}
+
+			return words, nil
+		},
+	)
+
+	if err != nil {
+		return nil, errors.New(text.LeaveOnlyDomainInURLs(err.Error()))
+	}
+
+	return out, nil
+}
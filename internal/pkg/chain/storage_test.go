@@ -0,0 +1,52 @@
+package chain
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"testing"
+)
+
+func Test_storage_batch_returns_words_in_request_order(t *testing.T) {
+	srv, _ := rpcStub(t, `[
+		{"jsonrpc":"2.0","id":"1","result":"0x000000000000000000000000b8ffc3cd6e7cf5a098a1c92f48009765b24088dc"},
+		{"jsonrpc":"2.0","id":"0","result":"0x0000000000000000000000000000000000000000000000000000000000000000"}
+	]`)
+
+	c := NewChain([]string{srv.URL}, &http.Client{}, newTestMetrics(t))
+
+	got, err := c.StorageBatch(context.Background(), []StorageSlot{{Address: "0xc0", Slot: "0x0"}, {Address: "0xc0", Slot: "0x1"}}, "0xb10c")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"0x0000000000000000000000000000000000000000000000000000000000000000",
+		"0x000000000000000000000000b8ffc3cd6e7cf5a098a1c92f48009765b24088dc",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %v", got)
+	}
+}
+
+func Test_storage_batch_fails_over_on_an_error(t *testing.T) {
+	// A pruned node no longer has the state of the block.
+	pruned, _ := rpcStub(t, `[{"jsonrpc":"2.0","id":"0","error":{"code":-32000,"message":"missing trie node"}}]`)
+	archive, archiveCalls := rpcStub(t, `[{"jsonrpc":"2.0","id":"0","result":"0x01"}]`)
+
+	c := NewChain([]string{pruned.URL, archive.URL}, &http.Client{}, newTestMetrics(t))
+
+	got, err := c.StorageBatch(context.Background(), []StorageSlot{{Address: "0xc0", Slot: "0x0"}}, "0xb10c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if archiveCalls.Load() != 1 || got[0] != "0x01" {
+		t.Errorf("want the archive node's answer, got %v", got)
+	}
+
+	// Every endpoint failing is the batch failing.
+	c = NewChain([]string{pruned.URL}, &http.Client{}, newTestMetrics(t))
+	if _, err := c.StorageBatch(context.Background(), []StorageSlot{{Address: "0xc0", Slot: "0x0"}}, "0xb10c"); err == nil {
+		t.Error("expected an error")
+	}
+}
@@ -0,0 +1,72 @@
+package chain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/lidofinance/onchain-mon/internal/pkg/chain/entity"
+)
+
+// Call-trace APIs a node may serve. debug is debug_traceBlockByHash with the
+// callTracer (geth, reth, Nethermind); parity is trace_block (Erigon, reth,
+// Nethermind).
+const (
+	TraceAPIDebug  = `debug`
+	TraceAPIParity = `parity`
+)
+
+var ErrTracesMismatch = errors.New("traces do not match the block")
+
+// TraceBlockCalls returns the call tree of every transaction of block. An
+// answer with a tree per transaction missing fails over to the next endpoint.
+func (c *chain) TraceBlockCalls(ctx context.Context, block *entity.EthBlock) (*entity.RpcResponse[[]entity.TxCallTrace], error) {
+	params := []any{block.Hash, map[string]string{"tracer": "callTracer"}}
+
+	return doRpcRequest[[]entity.TxCallTrace](ctx, "debug_traceBlockByHash", params, c.httpClient, c.metrics, c.pool,
+		func(traces *[]entity.TxCallTrace) error {
+			if len(*traces) != len(block.Transactions) {
+				return fmt.Errorf("%w: block %s has %d transactions, got %d traces",
+					ErrTracesMismatch, block.Hash, len(block.Transactions), len(*traces))
+			}
+
+			return nil
+		},
+	)
+}
+
+// TraceBlock returns the flat traces of block. trace_block only takes a
+// number, so an endpoint answering for another block at that height (it saw a
+// reorg the others did not) fails over to the next one.
+func (c *chain) TraceBlock(ctx context.Context, block *entity.EthBlock) (*entity.RpcResponse[[]entity.ParityTrace], error) {
+	return doRpcRequest[[]entity.ParityTrace](ctx, "trace_block", []any{block.Number}, c.httpClient, c.metrics, c.pool,
+		func(traces *[]entity.ParityTrace) error {
+			for i := range *traces {
+				if hash := (*traces)[i].BlockHash; !strings.EqualFold(hash, block.Hash) {
+					return fmt.Errorf("%w: got a trace of block %s, want %s", ErrTracesMismatch, hash, block.Hash)
+				}
+			}
+
+			return nil
+		},
+	)
+}
+
+// TraceBlockStateDiffs returns what every transaction of block changed, from
+// the prestateTracer in diff mode. It is as heavy as the callTracer, so the
+// feeder only asks for it about a block it already knows changed a slot.
+func (c *chain) TraceBlockStateDiffs(ctx context.Context, block *entity.EthBlock) (*entity.RpcResponse[[]entity.TxStateDiff], error) {
+	params := []any{block.Hash, map[string]any{"tracer": "prestateTracer", "tracerConfig": map[string]bool{"diffMode": true}}}
+
+	return doRpcRequest[[]entity.TxStateDiff](ctx, "debug_traceBlockByHash", params, c.httpClient, c.metrics, c.pool,
+		func(diffs *[]entity.TxStateDiff) error {
+			if len(*diffs) != len(block.Transactions) {
+				return fmt.Errorf("%w: block %s has %d transactions, got %d state diffs",
+					ErrTracesMismatch, block.Hash, len(block.Transactions), len(*diffs))
+			}
+
+			return nil
+		},
+	)
+}
@@ -0,0 +1,75 @@
+package chain
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/lidofinance/onchain-mon/internal/pkg/chain/entity"
+)
+
+func Test_traces_of_another_block_fail_over(t *testing.T) {
+	block := &entity.EthBlock{Number: "0x64", Hash: "0xb10c", Transactions: []string{"0xt1"}}
+
+	t.Run("trace_block", func(t *testing.T) {
+		// Answers by height, and this endpoint is on the other side of a reorg.
+		foreign, foreignCalls := rpcStub(t, `{"jsonrpc":"2.0","id":"1","result":[
+			{"type":"call","blockHash":"0xother","transactionHash":"0xt9","transactionPosition":0,"traceAddress":[],"action":{"callType":"call"}}
+		]}`)
+		canonical, _ := rpcStub(t, `{"jsonrpc":"2.0","id":"1","result":[
+			{"type":"call","blockHash":"0xb10c","transactionHash":"0xt1","transactionPosition":0,"traceAddress":[],"action":{"callType":"call"}}
+		]}`)
+
+		c := NewChain([]string{foreign.URL, canonical.URL}, &http.Client{}, newTestMetrics(t))
+
+		got, err := c.TraceBlock(context.Background(), block)
+		if err != nil {
+			t.Fatalf("the canonical endpoint must answer within the same call: %v", err)
+		}
+		if hash := (*got.Result)[0].TransactionHash; hash != "0xt1" {
+			t.Errorf("got a trace of %s, want 0xt1", hash)
+		}
+		if foreignCalls.Load() != 1 {
+			t.Errorf("the foreign endpoint was asked %d times, want 1", foreignCalls.Load())
+		}
+	})
+
+	t.Run("debug_traceBlockByHash", func(t *testing.T) {
+		short, shortCalls := rpcStub(t, `{"jsonrpc":"2.0","id":"1","result":[]}`)
+		complete, _ := rpcStub(t, `{"jsonrpc":"2.0","id":"1","result":[{"txHash":"0xt1","result":{"type":"CALL"}}]}`)
+
+		c := NewChain([]string{short.URL, complete.URL}, &http.Client{}, newTestMetrics(t))
+
+		got, err := c.TraceBlockCalls(context.Background(), block)
+		if err != nil {
+			t.Fatalf("the complete endpoint must answer within the same call: %v", err)
+		}
+		if len(*got.Result) != 1 {
+			t.Errorf("got %d traces, want 1", len(*got.Result))
+		}
+		if shortCalls.Load() != 1 {
+			t.Errorf("the short endpoint was asked %d times, want 1", shortCalls.Load())
+		}
+	})
+}
+
+func Test_state_diffs_are_decoded_per_transaction(t *testing.T) {
+	block := &entity.EthBlock{Number: "0x64", Hash: "0xb10c", Transactions: []string{"0xt1"}}
+
+	srv, _ := rpcStub(t, `{"jsonrpc":"2.0","id":"1","result":[{"txHash":"0xt1","result":{
+		"pre":{"0xc0":{"balance":"0x0","storage":{"0x01":"0x0a"}}},
+		"post":{"0xc0":{"storage":{"0x01":"0x0b"}}}
+	}}]}`)
+
+	c := NewChain([]string{srv.URL}, &http.Client{}, newTestMetrics(t))
+
+	got, err := c.TraceBlockStateDiffs(context.Background(), block)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	diff := (*got.Result)[0]
+	if diff.TxHash != "0xt1" || diff.Result.Pre["0xc0"].Storage["0x01"] != "0x0a" || diff.Result.Post["0xc0"].Storage["0x01"] != "0x0b" {
+		t.Errorf("got %+v", diff)
+	}
+}
@@ -0,0 +1,63 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/vmihailenco/msgpack/v5"
+
+	"github.com/lidofinance/onchain-mon/pkg/envelope"
+)
+
+// Subject is where payloads in encoding go, for the subject base+partition:
+// JSON keeps the subject the bots subscribe to today, every other encoding
+// comes right after base, so a wildcard over the partitions only matches one
+// encoding. blocks.mainnet.l1.logs.0xabc in MessagePack is
+// blocks.mainnet.l1.msgpack.logs.0xabc.
+func Subject(base, partition, encoding string) string {
+	if encoding == envelope.JSON {
+		return base + partition
+	}
+
+	return base + "." + encoding + partition
+}
+
+// Marshal encodes v, one of the generated databus types. MessagePack keys
+// follow the json tags, so both encodings share the field names of the
+// schemas in brief/databus.
+func Marshal(encoding string, v any) ([]byte, error) {
+	switch encoding {
+	case envelope.JSON:
+		return json.Marshal(v)
+	case envelope.MsgPack:
+		var buf bytes.Buffer
+
+		enc := msgpack.NewEncoder(&buf)
+		enc.SetCustomStructTag("json")
+		enc.UseCompactInts(true)
+
+		if err := enc.Encode(v); err != nil {
+			return nil, err
+		}
+
+		return buf.Bytes(), nil
+	default:
+		return nil, fmt.Errorf("unknown encoding %q", encoding)
+	}
+}
+
+// Unmarshal decodes a payload Marshal produced.
+func Unmarshal(encoding string, data []byte, v any) error {
+	switch encoding {
+	case envelope.JSON:
+		return json.Unmarshal(data, v)
+	case envelope.MsgPack:
+		dec := msgpack.NewDecoder(bytes.NewReader(data))
+		dec.SetCustomStructTag("json")
+
+		return dec.Decode(v)
+	default:
+		return fmt.Errorf("unknown encoding %q", encoding)
+	}
+}
@@ -0,0 +1,82 @@
+package codec
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/lidofinance/onchain-mon/generated/databus"
+	"github.com/lidofinance/onchain-mon/pkg/envelope"
+)
+
+func testBlock() databus.BlockDtoJson {
+	to := "0xto"
+	return databus.BlockDtoJson{
+		Number:     100,
+		Timestamp:  1_700_000_000,
+		ParentHash: "0xparent",
+		Hash:       "0xabc",
+		Receipts: []databus.BlockDtoJsonReceiptsElem{{
+			TransactionHash: "0xt1",
+			From:            "0xfrom",
+			To:              &to,
+			Logs: []databus.BlockDtoJsonReceiptsElemLogsElem{{
+				Address: "0xaddr",
+				Topics:  []string{"0xtopic"},
+				Data:    "0x",
+			}},
+		}},
+	}
+}
+
+func Test_round_trip(t *testing.T) {
+	for _, encoding := range []string{envelope.JSON, envelope.MsgPack} {
+		t.Run(encoding, func(t *testing.T) {
+			data, err := Marshal(encoding, testBlock())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got databus.BlockDtoJson
+			if err := Unmarshal(encoding, data, &got); err != nil {
+				t.Fatal(err)
+			}
+
+			if want := testBlock(); !reflect.DeepEqual(got, want) {
+				t.Errorf("got %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+// Bots decoding MessagePack into their own types look fields up by the schema
+// names, not by the Go ones.
+func Test_msgpack_keys_follow_the_schema(t *testing.T) {
+	data, err := Marshal(envelope.MsgPack, testBlock())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got map[string]any
+	if err := Unmarshal(envelope.MsgPack, data, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range []string{"number", "timestamp", "parentHash", "hash", "receipts"} {
+		if _, ok := got[key]; !ok {
+			t.Errorf("no %q in %v", key, got)
+		}
+	}
+}
+
+func Test_subject(t *testing.T) {
+	if got := Subject("blocks.mainnet.l1", ".traces", envelope.JSON); got != "blocks.mainnet.l1.traces" {
+		t.Errorf("json goes to %s, want the subject itself", got)
+	}
+	if got := Subject("blocks.mainnet.l1", "", envelope.MsgPack); got != "blocks.mainnet.l1.msgpack" {
+		t.Errorf("msgpack blocks go to %s", got)
+	}
+	// blocks.mainnet.l1.logs.> must not match the MessagePack batches.
+	if got := Subject("blocks.mainnet.l1", ".logs.0xabc", envelope.MsgPack); got != "blocks.mainnet.l1.msgpack.logs.0xabc" {
+		t.Errorf("msgpack logs go to %s", got)
+	}
+}
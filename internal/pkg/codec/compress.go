@@ -0,0 +1,93 @@
+package codec
+
+import (
+	"fmt"
+
+	"github.com/klauspost/compress/dict"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compressor zstd-compresses payloads, with a trained dictionary when it has
+// one. It is safe for concurrent use: the head and finality streams of a
+// chain share it.
+type Compressor struct {
+	enc *zstd.Encoder
+	// plain compresses without the dictionary, to tell what it gains; nil
+	// without a dictionary.
+	plain  *zstd.Encoder
+	dictID uint32
+}
+
+// NewCompressor builds a compressor using dict, a dictionary in the zstd
+// format (cmd/zstd-dict, zstd --train). An empty dict compresses without one,
+// the way the feeder always has.
+func NewCompressor(dict []byte) (*Compressor, error) {
+	if len(dict) == 0 {
+		enc, err := zstd.NewWriter(nil)
+		if err != nil {
+			return nil, fmt.Errorf("could not create zstd encoder: %w", err)
+		}
+
+		return &Compressor{enc: enc}, nil
+	}
+
+	info, err := zstd.InspectDictionary(dict)
+	if err != nil {
+		return nil, fmt.Errorf("could not read zstd dictionary: %w", err)
+	}
+
+	// ID 0 means "no dictionary" in the frame header, so decoders could not
+	// tell which one to use.
+	if info.ID() == 0 {
+		return nil, fmt.Errorf("zstd dictionary has no ID")
+	}
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderDict(dict))
+	if err != nil {
+		return nil, fmt.Errorf("could not create zstd encoder with dictionary %d: %w", info.ID(), err)
+	}
+
+	plain, err := zstd.NewWriter(nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not create zstd encoder: %w", err)
+	}
+
+	return &Compressor{enc: enc, plain: plain, dictID: info.ID()}, nil
+}
+
+// DictID is the ID of the dictionary payloads are compressed with, 0 without
+// one.
+func (c *Compressor) DictID() uint32 {
+	return c.dictID
+}
+
+// Compress returns payload as one zstd frame.
+func (c *Compressor) Compress(payload []byte) []byte {
+	return c.enc.EncodeAll(payload, nil)
+}
+
+// SizeWithoutDictionary is how big payload would be compressed without the
+// dictionary. It compresses the payload once more, so only blocks are
+// measured.
+func (c *Compressor) SizeWithoutDictionary(payload []byte) int {
+	if c.plain == nil {
+		return len(c.enc.EncodeAll(payload, nil))
+	}
+
+	return len(c.plain.EncodeAll(payload, nil))
+}
+
+// DefaultDictionarySize is the zstd CLI's default for trained dictionaries.
+const DefaultDictionarySize = 110 << 10
+
+// TrainDictionary builds a zstd dictionary of at most size bytes from samples
+// of uncompressed payloads, tuned for the level Compressor uses. It stays
+// readable by zstd 1.5.5 and older, which some bots link against.
+func TrainDictionary(samples [][]byte, size int) ([]byte, error) {
+	return dict.BuildZstdDict(samples, dict.Options{
+		MaxDictSize:    size,
+		HashBytes:      6,
+		ZstdDictCompat: true,
+		ZstdLevel:      zstd.SpeedDefault,
+	})
+}
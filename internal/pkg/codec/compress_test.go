@@ -0,0 +1,86 @@
+package codec
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/lidofinance/onchain-mon/pkg/envelope"
+)
+
+// Blocks of one chain repeat the same contracts and event signatures, which is
+// what a dictionary picks up.
+func samplePayloads(n int) [][]byte {
+	out := make([][]byte, 0, n)
+	for i := range n {
+		out = append(out, []byte(fmt.Sprintf(
+			`{"number":%d,"hash":"0x%064x","receipts":[{"logs":[{"address":"0xae7ab96520de3a18e5e111b5eaab095312d7fe84","topics":["0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"],"data":"0x%064x"}]}]}`,
+			i, i*7919, i*104729,
+		)))
+	}
+
+	return out
+}
+
+func Test_dictionary_round_trip(t *testing.T) {
+	samples := samplePayloads(500)
+
+	dict, err := TrainDictionary(samples, 16<<10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := NewCompressor(dict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.DictID() == 0 {
+		t.Fatal("a trained dictionary must carry an ID")
+	}
+
+	payload := samplePayloads(501)[500]
+	compressed := c.Compress(payload)
+
+	if got := c.SizeWithoutDictionary(payload); got <= len(compressed) {
+		t.Errorf("the dictionary gains nothing: %d bytes with it, %d without", len(compressed), got)
+	}
+
+	dec, err := zstd.NewReader(nil, zstd.WithDecoderDicts(dict))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dec.Close()
+
+	got, err := dec.DecodeAll(compressed, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, payload) {
+		t.Errorf("decoded %q, want %q", got, payload)
+	}
+
+	id, err := envelope.DictionaryIDOf(envelope.Header(envelope.JSON, c.DictID()))
+	if err != nil || id != c.DictID() {
+		t.Errorf("header carries dictionary %d (%v), want %d", id, err, c.DictID())
+	}
+}
+
+func Test_no_dictionary(t *testing.T) {
+	c, err := NewCompressor(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c.DictID() != 0 {
+		t.Errorf("no dictionary, yet ID %d", c.DictID())
+	}
+	if got := envelope.Header(envelope.JSON, c.DictID()).Get(envelope.HeaderDictionaryID); got != "" {
+		t.Errorf("advertised dictionary %q without one", got)
+	}
+
+	if _, err := NewCompressor([]byte("not a dictionary")); err == nil {
+		t.Error("a malformed dictionary must be rejected")
+	}
+}
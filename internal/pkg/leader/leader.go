@@ -0,0 +1,141 @@
+package leader
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/lidofinance/onchain-mon/internal/connectors/metrics"
+)
+
+// Takes the lease when it is free, renews it when it is ours.
+const acquireScript = `
+	local owner = redis.call("GET", KEYS[1])
+	if not owner then
+		redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[2])
+		return 1
+	end
+	if owner == ARGV[1] then
+		redis.call("PEXPIRE", KEYS[1], ARGV[2])
+		return 1
+	end
+	return 0
+`
+
+const releaseScript = `
+	if redis.call("GET", KEYS[1]) == ARGV[1] then
+		return redis.call("DEL", KEYS[1])
+	end
+	return 0
+`
+
+// Elector holds a lease in Redis shared by every instance, so exactly one of
+// them leads at a time. The lease is how long a leader stays one without
+// renewing: a standby takes over at most a lease plus a renew interval after
+// the leader is gone.
+type Elector struct {
+	rdb     *redis.Client
+	key     string
+	id      string
+	lease   time.Duration
+	log     *slog.Logger
+	metrics *metrics.Store
+
+	// Unix nanos until which this instance may act as the leader. Kept
+	// locally, so losing Redis demotes the leader once its lease runs out
+	// instead of leaving two leaders.
+	leaseUntil atomic.Int64
+	wasLeader  bool
+}
+
+func New(rdb *redis.Client, key, id string, lease time.Duration, log *slog.Logger, metricsStore *metrics.Store) *Elector {
+	return &Elector{
+		rdb:     rdb,
+		key:     key,
+		id:      id,
+		lease:   lease,
+		log:     log,
+		metrics: metricsStore,
+	}
+}
+
+func (e *Elector) IsLeader() bool {
+	return time.Now().UnixNano() < e.leaseUntil.Load()
+}
+
+// Run campaigns for the lease every third of it until ctx is done, then hands
+// the lease back so a standby does not have to wait for it to expire.
+func (e *Elector) Run(ctx context.Context, g *errgroup.Group) {
+	g.Go(func() error {
+		ticker := time.NewTicker(e.lease / 3)
+		defer ticker.Stop()
+
+		for {
+			e.campaign(ctx)
+
+			select {
+			case <-ctx.Done():
+				e.release()
+				return ctx.Err()
+			case <-ticker.C:
+			}
+		}
+	})
+}
+
+func (e *Elector) campaign(ctx context.Context) {
+	// The lease is counted from before the request: Redis may have set it
+	// any time after.
+	start := time.Now()
+
+	got, err := e.rdb.Eval(ctx, acquireScript, []string{e.key}, e.id, e.lease.Milliseconds()).Int()
+	switch {
+	case err != nil:
+		if ctx.Err() == nil {
+			e.log.Error(fmt.Sprintf("Could not campaign for %s: %v", e.key, err))
+		}
+	case got == 1:
+		e.leaseUntil.Store(start.Add(e.lease).UnixNano())
+	default:
+		e.leaseUntil.Store(0)
+	}
+
+	isLeader := e.IsLeader()
+	if isLeader == e.wasLeader {
+		return
+	}
+
+	e.wasLeader = isLeader
+	e.metrics.LeaderTransitions.Inc()
+
+	if isLeader {
+		e.metrics.Leader.Set(1)
+		e.log.Info("Became the leader", slog.String("key", e.key), slog.String("id", e.id))
+
+		return
+	}
+
+	e.metrics.Leader.Set(0)
+	e.log.Warn("Lost leadership, standing by", slog.String("key", e.key), slog.String("id", e.id))
+}
+
+func (e *Elector) release() {
+	if !e.wasLeader {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	e.leaseUntil.Store(0)
+	e.metrics.Leader.Set(0)
+
+	if err := e.rdb.Eval(ctx, releaseScript, []string{e.key}, e.id).Err(); err != nil {
+		e.log.Error(fmt.Sprintf("Could not release %s: %v", e.key, err))
+	}
+}
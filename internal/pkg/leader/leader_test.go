@@ -0,0 +1,107 @@
+package leader
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/lidofinance/onchain-mon/internal/connectors/metrics"
+)
+
+const testRedisAddr = "127.0.0.1:6379"
+const testRedisDB = 15
+
+var testMetricsOnce sync.Once
+var testMetrics *metrics.Store
+
+func newTestElector(rdb *redis.Client, key, id string, lease time.Duration) *Elector {
+	testMetricsOnce.Do(func() {
+		testMetrics = metrics.New(prometheus.NewRegistry(), "leader_test", "test", "test")
+	})
+
+	return New(rdb, key, id, lease, slog.New(slog.NewTextHandler(io.Discard, nil)), testMetrics)
+}
+
+func dialTestRedis(t *testing.T) *redis.Client {
+	t.Helper()
+
+	rdb := redis.NewClient(&redis.Options{Addr: testRedisAddr, DB: testRedisDB})
+	if err := rdb.Ping(context.Background()).Err(); err != nil {
+		t.Skipf("redis is not reachable at %s: %v", testRedisAddr, err)
+	}
+
+	return rdb
+}
+
+func Test_only_one_instance_leads(t *testing.T) {
+	rdb := dialTestRedis(t)
+	key := "test:leader:" + t.Name()
+	t.Cleanup(func() { rdb.Del(context.Background(), key) })
+
+	a := newTestElector(rdb, key, "a", time.Minute)
+	b := newTestElector(rdb, key, "b", time.Minute)
+
+	a.campaign(context.Background())
+	b.campaign(context.Background())
+
+	if !a.IsLeader() || b.IsLeader() {
+		t.Fatalf("got a=%v b=%v, want only the first one leading", a.IsLeader(), b.IsLeader())
+	}
+
+	// Renewing keeps the lease with its owner.
+	a.campaign(context.Background())
+	b.campaign(context.Background())
+	if !a.IsLeader() || b.IsLeader() {
+		t.Fatalf("after renewal got a=%v b=%v, want a to keep leading", a.IsLeader(), b.IsLeader())
+	}
+}
+
+func Test_standby_takes_over_after_release(t *testing.T) {
+	rdb := dialTestRedis(t)
+	key := "test:leader:" + t.Name()
+	t.Cleanup(func() { rdb.Del(context.Background(), key) })
+
+	a := newTestElector(rdb, key, "a", time.Minute)
+	b := newTestElector(rdb, key, "b", time.Minute)
+
+	a.campaign(context.Background())
+	a.release()
+
+	if a.IsLeader() {
+		t.Fatal("a released the lease but still thinks it leads")
+	}
+
+	b.campaign(context.Background())
+	if !b.IsLeader() {
+		t.Fatal("the standby did not take the released lease")
+	}
+}
+
+func Test_lost_lease_is_taken_over(t *testing.T) {
+	rdb := dialTestRedis(t)
+	key := "test:leader:" + t.Name()
+	t.Cleanup(func() { rdb.Del(context.Background(), key) })
+
+	a := newTestElector(rdb, key, "a", 200*time.Millisecond)
+	b := newTestElector(rdb, key, "b", 200*time.Millisecond)
+
+	a.campaign(context.Background())
+
+	// a is gone without a word: no renewals, no release.
+	time.Sleep(300 * time.Millisecond)
+
+	if a.IsLeader() {
+		t.Error("a must step down on its own once the lease ran out")
+	}
+
+	b.campaign(context.Background())
+	if !b.IsLeader() {
+		t.Fatal("the standby did not take the expired lease")
+	}
+}
@@ -0,0 +1,127 @@
+// Package chunks puts back together the payloads the feeder splits into
+// chunks when they are too big for one NATS message.
+//
+// A bot hands every message of a subject to a Reassembler, in order, and goes
+// on with the payload once it is whole:
+//
+//	r := chunks.NewReassembler(chunks.DefaultMaxPending)
+//	payload, ok, err := r.Add(msg.Headers(), msg.Data())
+//	if err != nil || !ok {
+//		return // not a block yet
+//	}
+//	// zstd-decompress and decode payload as usual
+//
+// Messages that were never split come back right away.
+package chunks
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/nats-io/nats.go"
+
+	"github.com/lidofinance/onchain-mon/pkg/envelope"
+)
+
+// DefaultMaxPending is how many split payloads a Reassembler waits for at
+// once. A subject carries one block at a time, so a few cover redeliveries.
+const DefaultMaxPending = 8
+
+type partial struct {
+	chunks   [][]byte
+	received int
+	size     int
+}
+
+// Reassembler collects the chunks of split payloads. It is safe for concurrent
+// use.
+type Reassembler struct {
+	mu         sync.Mutex
+	pending    map[string]*partial
+	order      []string
+	maxPending int
+}
+
+// NewReassembler waits for at most maxPending split payloads at once; the
+// oldest is dropped to make room for a new one.
+func NewReassembler(maxPending int) *Reassembler {
+	if maxPending <= 0 {
+		maxPending = DefaultMaxPending
+	}
+
+	return &Reassembler{
+		pending:    make(map[string]*partial),
+		maxPending: maxPending,
+	}
+}
+
+// Add takes one message. It returns the payload and true once it is whole:
+// right away for a message that was not split, on the last missing chunk for
+// one that was. Chunks may come in any order, and a redelivered chunk is
+// ignored.
+func (r *Reassembler) Add(header nats.Header, data []byte) ([]byte, bool, error) {
+	chunk, ok, err := envelope.ChunkOf(header)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if !ok {
+		return data, true, nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p, ok := r.pending[chunk.ID]
+	if !ok {
+		p = &partial{chunks: make([][]byte, chunk.Count)}
+		r.track(chunk.ID, p)
+	}
+
+	if len(p.chunks) != chunk.Count {
+		return nil, false, fmt.Errorf("chunk %d of %s claims %d chunks, earlier ones %d", chunk.Index, chunk.ID, chunk.Count, len(p.chunks))
+	}
+
+	if p.chunks[chunk.Index] != nil {
+		return nil, false, nil
+	}
+
+	// The message may be reused by the caller once Add returns.
+	p.chunks[chunk.Index] = append([]byte(nil), data...)
+	p.received++
+	p.size += len(data)
+
+	if p.received < chunk.Count {
+		return nil, false, nil
+	}
+
+	r.forget(chunk.ID)
+
+	payload := make([]byte, 0, p.size)
+	for _, c := range p.chunks {
+		payload = append(payload, c...)
+	}
+
+	return payload, true, nil
+}
+
+func (r *Reassembler) track(id string, p *partial) {
+	if len(r.order) >= r.maxPending {
+		delete(r.pending, r.order[0])
+		r.order = r.order[1:]
+	}
+
+	r.pending[id] = p
+	r.order = append(r.order, id)
+}
+
+func (r *Reassembler) forget(id string) {
+	delete(r.pending, id)
+
+	for i, pendingID := range r.order {
+		if pendingID == id {
+			r.order = append(r.order[:i], r.order[i+1:]...)
+			break
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package chunks
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/lidofinance/onchain-mon/pkg/envelope"
+)
+
+func Test_whole_messages_pass_through(t *testing.T) {
+	r := NewReassembler(0)
+
+	payload, ok, err := r.Add(envelope.Header(envelope.JSON, 0), []byte("block"))
+	if err != nil || !ok || string(payload) != "block" {
+		t.Errorf("got %q, %v, %v, want the message as is", payload, ok, err)
+	}
+}
+
+func Test_chunks_are_put_back_together(t *testing.T) {
+	r := NewReassembler(0)
+	header := envelope.Header(envelope.JSON, 0)
+
+	parts := [][]byte{[]byte("he"), []byte("av"), []byte("y")}
+
+	// Out of order, with a redelivery in between.
+	for _, i := range []int{2, 0, 0} {
+		if payload, ok, err := r.Add(envelope.ChunkHeader(header, "0xabc", i, 3), parts[i]); err != nil || ok {
+			t.Fatalf("chunk %d: got %q, %v, %v before the payload is whole", i, payload, ok, err)
+		}
+	}
+
+	payload, ok, err := r.Add(envelope.ChunkHeader(header, "0xabc", 1, 3), parts[1])
+	if err != nil || !ok {
+		t.Fatalf("last chunk: %v, %v", ok, err)
+	}
+	if !bytes.Equal(payload, []byte("heavy")) {
+		t.Errorf("got %q, want heavy", payload)
+	}
+
+	if len(r.pending) != 0 || len(r.order) != 0 {
+		t.Errorf("a whole payload must be forgotten, still pending %v", r.order)
+	}
+}
+
+func Test_oldest_partial_payload_is_dropped(t *testing.T) {
+	r := NewReassembler(1)
+	header := envelope.Header(envelope.JSON, 0)
+
+	_, _, _ = r.Add(envelope.ChunkHeader(header, "0x1", 0, 2), []byte("a"))
+	_, _, _ = r.Add(envelope.ChunkHeader(header, "0x2", 0, 2), []byte("b"))
+
+	if _, ok := r.pending["0x1"]; ok {
+		t.Error("the oldest partial payload must make room")
+	}
+
+	// 0x1 starts over, so its second chunk alone is not enough.
+	if _, ok, _ := r.Add(envelope.ChunkHeader(header, "0x1", 1, 2), []byte("c")); ok {
+		t.Error("a dropped payload cannot be completed")
+	}
+}
+
+func Test_bad_chunk_header(t *testing.T) {
+	h := envelope.ChunkHeader(envelope.Header(envelope.JSON, 0), "0xabc", 3, 3)
+
+	if _, _, err := NewReassembler(0).Add(h, []byte("x")); err == nil {
+		t.Error("a chunk past the count must be an error")
+	}
+}
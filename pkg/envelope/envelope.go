@@ -0,0 +1,144 @@
+// Package envelope is the header every message of the feeder carries: the
+// encoding and compression of its payload, the zstd dictionary it needs, and
+// for a payload split into chunks, the place of each one among them. Bots
+// read it to decode whatever subject a message came from.
+package envelope
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/nats-io/nats.go"
+)
+
+// Encodings of the databus payloads. JSON is the default.
+const (
+	JSON    = `json`
+	MsgPack = `msgpack`
+)
+
+// Version of the databus envelope, bumped on a breaking change of the
+// payloads in brief/databus.
+const Version = `1`
+
+// Headers of every message the feeder publishes. Bots that read more than one
+// subject decode by them instead of by the subject name.
+const (
+	HeaderContentType     = `Content-Type`
+	HeaderContentEncoding = `Content-Encoding`
+	HeaderVersion         = `Databus-Version`
+	// HeaderDictionaryID names the trained zstd dictionary the payload was
+	// compressed with; absent without one.
+	HeaderDictionaryID = `Zstd-Dictionary-Id`
+)
+
+// Headers of the chunks a payload too big for one message is split into: the
+// message ID of the whole payload, and the place of the chunk among them.
+// Every chunk also carries the envelope of the whole payload.
+const (
+	HeaderChunkID    = `Databus-Chunk-Id`
+	HeaderChunkIndex = `Databus-Chunk-Index`
+	HeaderChunkCount = `Databus-Chunk-Count`
+)
+
+// ContentEncodingZstd is the compression every payload goes through.
+const ContentEncodingZstd = `zstd`
+
+var contentTypes = map[string]string{
+	JSON:    `application/json`,
+	MsgPack: `application/msgpack`,
+}
+
+// IsEncoding reports whether payloads can be encoded as encoding.
+func IsEncoding(encoding string) bool {
+	_, ok := contentTypes[encoding]
+	return ok
+}
+
+// Header is the envelope of a payload in encoding, compressed with the zstd
+// dictionary dictID, or without one when it is 0.
+func Header(encoding string, dictID uint32) nats.Header {
+	h := nats.Header{}
+	h.Set(HeaderContentType, contentTypes[encoding])
+	h.Set(HeaderContentEncoding, ContentEncodingZstd)
+	h.Set(HeaderVersion, Version)
+
+	if dictID != 0 {
+		h.Set(HeaderDictionaryID, strconv.FormatUint(uint64(dictID), 10))
+	}
+
+	return h
+}
+
+// DictionaryIDOf tells the zstd dictionary a message was compressed with, 0
+// for none.
+func DictionaryIDOf(h nats.Header) (uint32, error) {
+	value := h.Get(HeaderDictionaryID)
+	if value == "" {
+		return 0, nil
+	}
+
+	id, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("bad %s %q: %w", HeaderDictionaryID, value, err)
+	}
+
+	return uint32(id), nil
+}
+
+// EncodingOf tells the encoding of a message by its Content-Type. Messages
+// published before the envelope carry no header and are JSON.
+func EncodingOf(h nats.Header) (string, error) {
+	contentType := h.Get(HeaderContentType)
+	if contentType == "" {
+		return JSON, nil
+	}
+
+	for encoding, ct := range contentTypes {
+		if ct == contentType {
+			return encoding, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown content type %q", contentType)
+}
+
+// ChunkHeader is the header of chunk index of count the payload id with
+// envelope h was split into.
+func ChunkHeader(h nats.Header, id string, index, count int) nats.Header {
+	out := make(nats.Header, len(h)+3)
+	for k, v := range h {
+		out[k] = append([]string(nil), v...)
+	}
+
+	out.Set(HeaderChunkID, id)
+	out.Set(HeaderChunkIndex, strconv.Itoa(index))
+	out.Set(HeaderChunkCount, strconv.Itoa(count))
+
+	return out
+}
+
+// Chunk is the place of a message among the chunks of one payload.
+type Chunk struct {
+	// ID is the message ID of the whole payload.
+	ID    string
+	Index int
+	Count int
+}
+
+// ChunkOf tells which chunk a message is. ok is false for a message carrying
+// a whole payload.
+func ChunkOf(h nats.Header) (Chunk, bool, error) {
+	id := h.Get(HeaderChunkID)
+	if id == "" {
+		return Chunk{}, false, nil
+	}
+
+	index, indexErr := strconv.Atoi(h.Get(HeaderChunkIndex))
+	count, countErr := strconv.Atoi(h.Get(HeaderChunkCount))
+	if indexErr != nil || countErr != nil || count <= 0 || index < 0 || index >= count {
+		return Chunk{}, false, fmt.Errorf("bad chunk %q of %q of %s", h.Get(HeaderChunkIndex), h.Get(HeaderChunkCount), id)
+	}
+
+	return Chunk{ID: id, Index: index, Count: count}, true, nil
+}
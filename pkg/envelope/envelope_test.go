@@ -0,0 +1,27 @@
+package envelope
+
+import (
+	"testing"
+
+	"github.com/nats-io/nats.go"
+)
+
+func Test_encoding_of(t *testing.T) {
+	for _, encoding := range []string{JSON, MsgPack} {
+		got, err := EncodingOf(Header(encoding, 0))
+		if err != nil || got != encoding {
+			t.Errorf("EncodingOf(Header(%s)) = %s, %v", encoding, got, err)
+		}
+	}
+
+	// Published before the envelope existed.
+	if got, err := EncodingOf(nats.Header{}); err != nil || got != JSON {
+		t.Errorf("no header = %s, %v, want json", got, err)
+	}
+
+	h := nats.Header{}
+	h.Set(HeaderContentType, "application/xml")
+	if _, err := EncodingOf(h); err == nil {
+		t.Error("an unknown content type must be an error")
+	}
+}